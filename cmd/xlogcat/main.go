@@ -0,0 +1,191 @@
+// Command xlogcat prints rows of Tarantool .xlog and .snap files as JSON,
+// one row per line.
+//
+// Usage:
+//
+//	xlogcat [-meta] FILE...
+//
+// Since: 2.0.0
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tarantool/go-iproto"
+
+	"github.com/tarantool/go-tarantool/v2/datetime"
+	"github.com/tarantool/go-tarantool/v2/decimal"
+	"github.com/tarantool/go-tarantool/v2/xlog"
+)
+
+func main() {
+	meta := flag.Bool("meta", false, "print a header of each file before rows")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-meta] FILE...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	enc := json.NewEncoder(out)
+	for _, path := range flag.Args() {
+		if err := cat(enc, path, *meta); err != nil {
+			out.Flush()
+			fmt.Fprintf(os.Stderr, "xlogcat: %s: %s\n", path, err)
+			os.Exit(1)
+		}
+	}
+}
+
+// cat prints rows of the file.
+func cat(enc *json.Encoder, path string, meta bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return catReader(enc, file, meta)
+}
+
+func catReader(enc *json.Encoder, r io.Reader, meta bool) error {
+	reader, err := xlog.NewReader(r)
+	if err != nil {
+		return err
+	}
+
+	if meta {
+		if err := enc.Encode(metaObject(reader.Meta())); err != nil {
+			return err
+		}
+	}
+	for reader.Next() {
+		if err := enc.Encode(rowObject(reader.Row())); err != nil {
+			return err
+		}
+	}
+	return reader.Err()
+}
+
+func metaObject(meta xlog.Meta) map[string]interface{} {
+	obj := map[string]interface{}{
+		"filetype": meta.Filetype,
+		"version":  meta.Version,
+		"vclock":   meta.VClock,
+	}
+	if meta.ServerVersion != "" {
+		obj["server_version"] = meta.ServerVersion
+	}
+	if meta.InstanceUUID != "" {
+		obj["instance_uuid"] = meta.InstanceUUID
+	}
+	if meta.PrevVClock != nil {
+		obj["prev_vclock"] = meta.PrevVClock
+	}
+	return obj
+}
+
+func rowObject(row xlog.Row) map[string]interface{} {
+	header := row.Header
+	obj := map[string]interface{}{
+		"type":       strings.TrimPrefix(header.Type.String(), "IPROTO_"),
+		"replica_id": header.ReplicaId,
+		"lsn":        header.Lsn,
+		"tsn":        header.Tsn,
+		"commit":     header.Flags&iproto.IPROTO_FLAG_COMMIT != 0,
+	}
+	if header.GroupId != 0 {
+		obj["group_id"] = header.GroupId
+	}
+	if header.Sync != 0 {
+		obj["sync"] = header.Sync
+	}
+	if header.StreamId != 0 {
+		obj["stream_id"] = header.StreamId
+	}
+	if !header.Timestamp.IsZero() {
+		obj["timestamp"] = header.Timestamp.UTC().Format(time.RFC3339Nano)
+	}
+
+	switch body := row.Body.(type) {
+	case *xlog.DMLBody:
+		dml := map[string]interface{}{
+			"space_id": body.SpaceId,
+		}
+		if header.Type == iproto.IPROTO_UPDATE || header.Type == iproto.IPROTO_DELETE {
+			dml["index_id"] = body.IndexId
+		}
+		if body.IndexBase != 0 {
+			dml["index_base"] = body.IndexBase
+		}
+		if body.Key != nil {
+			dml["key"] = jsonValue(body.Key)
+		}
+		if body.Tuple != nil {
+			dml["tuple"] = jsonValue(body.Tuple)
+		}
+		if body.Ops != nil {
+			dml["operations"] = jsonValue(body.Ops)
+		}
+		obj["body"] = dml
+	case map[iproto.Key]interface{}:
+		raw := make(map[string]interface{}, len(body))
+		for key, value := range body {
+			name := strings.ToLower(strings.TrimPrefix(key.String(), "IPROTO_"))
+			raw[name] = jsonValue(value)
+		}
+		obj["body"] = raw
+	}
+	return obj
+}
+
+// jsonValue converts a decoded MessagePack value into a value supported by
+// encoding/json.
+func jsonValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case []interface{}:
+		arr := make([]interface{}, len(value))
+		for i, item := range value {
+			arr[i] = jsonValue(item)
+		}
+		return arr
+	case map[interface{}]interface{}:
+		obj := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			obj[fmt.Sprint(jsonValue(key))] = jsonValue(item)
+		}
+		return obj
+	case map[string]interface{}:
+		obj := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			obj[key] = jsonValue(item)
+		}
+		return obj
+	case float32:
+		return jsonValue(float64(value))
+	case float64:
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return fmt.Sprint(value)
+		}
+		return value
+	case datetime.Datetime:
+		return value.ToTime().Format(time.RFC3339Nano)
+	case decimal.Decimal:
+		return value.String()
+	}
+	return value
+}
@@ -3,7 +3,9 @@
 package tarantool
 
 import (
+	"container/heap"
 	"context"
+	"crypto/tls"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -21,6 +23,12 @@ import (
 
 const requestsMap = 128
 const ignoreStreamId = 0
+const ignoreSchemaVersion = 0
+
+// schemaReloadRetryDelay is a pause between attempts to reload a schema in
+// background after a failure.
+const schemaReloadRetryDelay = 500 * time.Millisecond
+
 const (
 	connDisconnected = 0
 	connConnected    = 1
@@ -60,6 +68,8 @@ const (
 	LogUnexpectedResultId
 	// LogWatchEventReadFailed is logged when failed to read a watch event.
 	LogWatchEventReadFailed
+	// LogSchemaReloadFailed is logged when failed to reload a schema.
+	LogSchemaReloadFailed
 )
 
 // ConnEvent is sent throw Notify channel specified in Opts.
@@ -102,6 +112,10 @@ func (d defaultLogger) Report(event ConnLogKind, conn *Connection, v ...interfac
 	case LogWatchEventReadFailed:
 		err := v[0].(error)
 		log.Printf("tarantool: unable to parse watch event: %s", err)
+	case LogSchemaReloadFailed:
+		err := v[0].(error)
+		log.Printf("tarantool: connection %s failed to reload schema: %s",
+			conn.addr, err)
 	default:
 		args := append([]interface{}{"tarantool: unexpected event ", event, conn}, v...)
 		log.Print(args...)
@@ -129,7 +143,8 @@ func (d defaultLogger) Report(event ConnLogKind, conn *Connection, v ...interfac
 // ErrConnectionClosed}. Connection could become "Closed" when
 // Connection.Close() method called, or when Tarantool disconnected and
 // Reconnect pause is not specified or MaxReconnects is specified and
-// MaxReconnect reconnect attempts already performed.
+// MaxReconnect reconnect attempts already performed (or ReconnectPolicy
+// gave it up).
 //
 // You may perform data manipulation operation by calling its methods:
 // Call*, Insert*, Replace*, Update*, Upsert*, Call*, Eval*.
@@ -137,6 +152,11 @@ func (d defaultLogger) Report(event ConnLogKind, conn *Connection, v ...interfac
 // In any method that accepts space you my pass either space number or space
 // name (in this case it will be looked up in schema). Same is true for index.
 //
+// The schema is reloaded automatically after a reconnect and when Tarantool
+// reports a newer schema version. A request packed with an outdated schema
+// is rejected by Tarantool with ER_WRONG_SCHEMA_VERSION, in this case the
+// connection reloads the schema and sends the request again.
+//
 // ATTENTION: tuple, key, ops and args arguments for any method should be
 // and array or should serialize to msgpack array.
 //
@@ -162,6 +182,21 @@ type Connection struct {
 	cond  *sync.Cond
 	// Schema contains schema loaded on connection.
 	Schema *Schema
+	// schemaVersion contains a version of the Schema.
+	schemaVersion uint64
+	// schemaMutex serializes schema reloads.
+	schemaMutex sync.Mutex
+	// schemaReload is a state of background schema reloads.
+	schemaReload struct {
+		sync.Mutex
+		// running is set while the schema is reloading in background.
+		running bool
+		// pending is set if the schema should be reloaded again.
+		pending bool
+		// version is a version of the pending reload, ignoreSchemaVersion
+		// forces the reload.
+		version uint64
+	}
 	// requestId contains the last request ID for requests with nil context.
 	requestId uint32
 	// contextRequestId contains the last request ID for requests with context.
@@ -182,6 +217,9 @@ type Connection struct {
 	lastStreamId uint64
 
 	serverProtocolInfo ProtocolInfo
+	// resolver resolves spaces and indexes of requests. It is replaced
+	// under the shards lock when the schema or the server changes.
+	resolver *schemaResolver
 	// watchMap is a map of key -> chan watchState.
 	watchMap sync.Map
 
@@ -189,6 +227,12 @@ type Connection struct {
 	shutdownWatcher Watcher
 	// requestCnt is a counter of active requests.
 	requestCnt int64
+	// nextTimeout is a time since epoch when the timeouts goroutine wakes up
+	// next time. It is zero if the goroutine does not wait for a timeout.
+	nextTimeout int64
+	// timeoutsWake wakes up the timeouts goroutine to schedule an earlier
+	// timeout.
+	timeoutsWake chan struct{}
 }
 
 var _ = Connector(&Connection{}) // Check compatibility with connector interface.
@@ -225,24 +269,71 @@ func (list *futureList) addFuture(fut *Future) {
 	list.last = &fut.next
 }
 
-func (list *futureList) clear(err error, conn *Connection) {
+// fetchAll removes all futures from the list and appends them to futs.
+func (list *futureList) fetchAll(futs []*Future) []*Future {
 	fut := list.first
 	list.first = nil
 	list.last = &list.first
 	for fut != nil {
-		fut.SetError(err)
-		conn.markDone(fut)
+		futs = append(futs, fut)
 		fut, fut.next = fut.next, nil
 	}
+	return futs
+}
+
+// futureHeap is a min-heap of futures by their timeouts.
+type futureHeap []*Future
+
+func (h futureHeap) Len() int {
+	return len(h)
+}
+
+func (h futureHeap) Less(i, j int) bool {
+	return h[i].timeout < h[j].timeout
+}
+
+func (h futureHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].heapIndex = i
+	h[j].heapIndex = j
+}
+
+func (h *futureHeap) Push(x interface{}) {
+	fut := x.(*Future)
+	fut.heapIndex = len(*h)
+	*h = append(*h, fut)
+}
+
+func (h *futureHeap) Pop() interface{} {
+	old := *h
+	fut := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return fut
+}
+
+// contains returns true if the future is in the heap.
+func (h futureHeap) contains(fut *Future) bool {
+	return fut.heapIndex < len(h) && h[fut.heapIndex] == fut
+}
+
+// remove removes the future from the heap if it is there.
+func (h *futureHeap) remove(fut *Future) {
+	if h.contains(fut) {
+		heap.Remove(h, fut.heapIndex)
+	}
 }
 
 type connShard struct {
 	rmut            sync.Mutex
 	requests        [requestsMap]futureList
 	requestsWithCtx [requestsMap]futureList
-	bufmut          sync.Mutex
-	buf             smallWBuf
-	enc             *msgpack.Encoder
+	// timeouts contains futures with timeouts. Futures are expired in order
+	// of the heap, so the timeouts goroutine does not walk the lists.
+	timeouts futureHeap
+	bufmut   sync.Mutex
+	buf      smallWBuf
+	enc      *msgpack.Encoder
 }
 
 // RLimitActions is an enumeration type for an action to do when a rate limit
@@ -274,6 +365,9 @@ type Opts struct {
 	// the timeout option for Connection does not affect the lifetime
 	// of the request. For those purposes use context.WithTimeout() as
 	// the root context.
+	//
+	// A request could have its own timeout, see SelectRequest.ResponseTimeout()
+	// for example.
 	Timeout time.Duration
 	// Timeout between reconnect attempts. If Reconnect is zero, no
 	// reconnect attempts will be made.
@@ -287,6 +381,16 @@ type Opts struct {
 	// endlessly.
 	// After MaxReconnects attempts Connection becomes closed.
 	MaxReconnects uint
+	// ReconnectPolicy controls pauses between reconnect attempts and when
+	// to give it up. If ReconnectPolicy is set, Reconnect and MaxReconnects
+	// are ignored. If ReconnectPolicy is nil, ConstantReconnectPolicy with
+	// Reconnect pause and MaxReconnects attempts is used when Reconnect is
+	// specified.
+	// A dial timeout of a reconnect attempt is a half of the pause before
+	// it, but not more than 5 seconds. The first attempt is made without
+	// a pause, so its timeout is a half of Reconnect or 500 milliseconds if
+	// Reconnect is zero.
+	ReconnectPolicy ReconnectPolicy
 	// Username for logging in to Tarantool.
 	User string
 	// User password for logging in to Tarantool.
@@ -306,6 +410,9 @@ type Opts struct {
 	Concurrency uint32
 	// SkipSchema disables schema loading. Without disabling schema loading,
 	// there is no way to create Connection for currently not accessible Tarantool.
+	// It also disables schema reloading after a reconnect or a schema
+	// change. Requests could still use space and index names if a server
+	// supports SpaceAndIndexNamesFeature.
 	SkipSchema bool
 	// Notify is a channel which receives notifications about Connection status
 	// changes.
@@ -315,10 +422,18 @@ type Opts struct {
 	Handle interface{}
 	// Logger is user specified logger used for error messages.
 	Logger Logger
+	// Observer is user specified observer of requests lifecycle. It could be
+	// used to collect metrics or to trace requests.
+	Observer Observer
 	// Transport is the connection type, by default the connection is unencrypted.
+	// The "ssl" transport uses OpenSSL, the "tls" transport uses crypto/tls.
 	Transport string
 	// SslOpts is used only if the Transport == 'ssl' is set.
 	Ssl SslOpts
+	// Tls is a crypto/tls configuration used only if the Transport == 'tls'
+	// is set. It does not require cgo and OpenSSL. If Tls.ServerName is
+	// empty, a host of the address is used for the verification.
+	Tls *tls.Config
 	// RequiredProtocolInfo contains minimal protocol version and
 	// list of protocol features that should be supported by
 	// Tarantool server. By default there are no restrictions.
@@ -361,6 +476,7 @@ type SslOpts struct {
 func (opts Opts) Clone() Opts {
 	optsCopy := opts
 	optsCopy.RequiredProtocolInfo = opts.RequiredProtocolInfo.Clone()
+	optsCopy.Tls = opts.Tls.Clone()
 
 	return optsCopy
 }
@@ -381,7 +497,9 @@ func Connect(ctx context.Context, addr string, opts Opts) (conn *Connection, err
 		requestId:        0,
 		contextRequestId: 1,
 		Greeting:         &Greeting{},
+		resolver:         &schemaResolver{},
 		control:          make(chan struct{}),
+		timeoutsWake:     make(chan struct{}, 1),
 		opts:             opts.Clone(),
 		dec:              msgpack.NewDecoder(&smallBuf{}),
 	}
@@ -417,6 +535,13 @@ func Connect(ctx context.Context, addr string, opts Opts) (conn *Connection, err
 		}
 	}
 
+	if conn.opts.ReconnectPolicy == nil && conn.opts.Reconnect > 0 {
+		conn.opts.ReconnectPolicy = ConstantReconnectPolicy{
+			Delay:       conn.opts.Reconnect,
+			MaxAttempts: conn.opts.MaxReconnects,
+		}
+	}
+
 	if conn.opts.Logger == nil {
 		conn.opts.Logger = defaultLogger{}
 	}
@@ -428,11 +553,8 @@ func Connect(ctx context.Context, addr string, opts Opts) (conn *Connection, err
 	}
 
 	go conn.pinger()
-	if conn.opts.Timeout > 0 {
-		go conn.timeouts()
-	}
+	go conn.timeouts()
 
-	// TODO: reload schema after reconnect.
 	if !conn.opts.SkipSchema {
 		if err = conn.loadSchema(); err != nil {
 			conn.mutex.Lock()
@@ -501,10 +623,16 @@ func (conn *Connection) Handle() interface{} {
 	return conn.opts.Handle
 }
 
+// RequestsInFlight returns a number of sent requests that are not completed
+// yet.
+func (conn *Connection) RequestsInFlight() int64 {
+	return atomic.LoadInt64(&conn.requestCnt)
+}
+
 func (conn *Connection) cancelFuture(fut *Future, err error) {
 	if fut = conn.fetchFuture(fut.requestId); fut != nil {
-		fut.SetError(err)
-		conn.markDone(fut)
+		conn.observe(RequestCanceled, fut, nil, err)
+		conn.finishFuture(fut, nil, err)
 	}
 }
 
@@ -516,6 +644,7 @@ func (conn *Connection) dial(ctx context.Context) error {
 		IoTimeout:        opts.Timeout,
 		Transport:        opts.Transport,
 		Ssl:              opts.Ssl,
+		Tls:              opts.Tls,
 		RequiredProtocol: opts.RequiredProtocolInfo,
 		Auth:             opts.Auth,
 		User:             opts.User,
@@ -556,6 +685,11 @@ func (conn *Connection) dial(ctx context.Context) error {
 	// Only if connected and fully initialized.
 	conn.lockShards()
 	conn.c = c
+	conn.resolver = &schemaResolver{
+		schema: conn.Schema,
+		namesUseSupported: isFeatureInSlice(SpaceAndIndexNamesFeature,
+			conn.serverProtocolInfo.Features),
+	}
 	atomic.StoreUint32(&conn.state, connConnected)
 	conn.cond.Broadcast()
 	conn.unlockShards()
@@ -572,43 +706,60 @@ func (conn *Connection) dial(ctx context.Context) error {
 		conn.shutdownWatcher = watcher
 	}
 
+	// The schema could be changed while the connection was broken or we
+	// could be connected to another instance, so reload it after reconnect.
+	if !conn.opts.SkipSchema && atomic.LoadUint64(&conn.schemaVersion) != 0 {
+		conn.startSchemaReload(ignoreSchemaVersion)
+	}
+
 	return nil
 }
 
-func pack(h *smallWBuf, enc *msgpack.Encoder, reqid uint32,
-	req Request, streamId uint64, res SchemaResolver) (err error) {
+// appendHeaderUint appends an IPROTO header key and an unsigned value to the
+// byte slice. The value is encoded as uint32 or uint64.
+func appendHeaderUint(b []byte, key iproto.Key, value uint64) []byte {
 	const uint32Code = 0xce
 	const uint64Code = 0xcf
-	const streamBytesLenUint64 = 10
-	const streamBytesLenUint32 = 6
+
+	if value > math.MaxUint32 {
+		b = append(b, byte(key), uint64Code, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(b[len(b)-8:], value)
+	} else {
+		b = append(b, byte(key), uint32Code, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(b[len(b)-4:], uint32(value))
+	}
+	return b
+}
+
+func pack(h *smallWBuf, enc *msgpack.Encoder, reqid uint32,
+	req Request, streamId uint64, schemaVersion uint64,
+	res SchemaResolver) (err error) {
+	const uint32Code = 0xce
 
 	hl := h.Len()
 
-	var streamBytesLen = 0
-	var streamBytes [streamBytesLenUint64]byte
 	hMapLen := byte(0x82) // 2 element map.
 	if streamId != ignoreStreamId {
-		hMapLen = byte(0x83) // 3 element map.
-		streamBytes[0] = byte(iproto.IPROTO_STREAM_ID)
-		if streamId > math.MaxUint32 {
-			streamBytesLen = streamBytesLenUint64
-			streamBytes[1] = uint64Code
-			binary.BigEndian.PutUint64(streamBytes[2:], streamId)
-		} else {
-			streamBytesLen = streamBytesLenUint32
-			streamBytes[1] = uint32Code
-			binary.BigEndian.PutUint32(streamBytes[2:], uint32(streamId))
-		}
+		hMapLen += 1
+	}
+	if schemaVersion != ignoreSchemaVersion {
+		hMapLen += 1
 	}
 
-	hBytes := append([]byte{
+	hBytes := []byte{
 		uint32Code, 0, 0, 0, 0, // Length.
 		hMapLen,
 		byte(iproto.IPROTO_REQUEST_TYPE), byte(req.Type()), // Request type.
 		byte(iproto.IPROTO_SYNC), uint32Code,
 		byte(reqid >> 24), byte(reqid >> 16),
 		byte(reqid >> 8), byte(reqid),
-	}, streamBytes[:streamBytesLen]...)
+	}
+	if streamId != ignoreStreamId {
+		hBytes = appendHeaderUint(hBytes, iproto.IPROTO_STREAM_ID, streamId)
+	}
+	if schemaVersion != ignoreSchemaVersion {
+		hBytes = appendHeaderUint(hBytes, iproto.IPROTO_SCHEMA_VERSION, schemaVersion)
+	}
 
 	h.Write(hBytes)
 
@@ -640,6 +791,15 @@ func (conn *Connection) createConnection(ctx context.Context) error {
 }
 
 func (conn *Connection) closeConnection(neterr error, forever bool) (err error) {
+	var futs []*Future
+	// Futures are finished after the shards unlock, so callbacks of
+	// the futures could send requests.
+	defer func() {
+		for _, fut := range futs {
+			conn.observe(RequestFailed, fut, nil, neterr)
+			conn.finishFuture(fut, nil, neterr)
+		}
+	}()
 	conn.lockShards()
 	defer conn.unlockShards()
 	if forever {
@@ -665,21 +825,24 @@ func (conn *Connection) closeConnection(neterr error, forever bool) (err error)
 	}
 	for i := range conn.shard {
 		conn.shard[i].buf.Reset()
+		conn.shard[i].timeouts = nil
 		requestsLists := []*[requestsMap]futureList{
 			&conn.shard[i].requests,
 			&conn.shard[i].requestsWithCtx,
 		}
 		for _, requests := range requestsLists {
 			for pos := range requests {
-				requests[pos].clear(neterr, conn)
+				futs = requests[pos].fetchAll(futs)
 			}
 		}
 	}
 	return
 }
 
-func (conn *Connection) getDialTimeout() time.Duration {
-	dialTimeout := conn.opts.Reconnect / 2
+// getDialTimeout returns a timeout of a reconnect attempt made after
+// the pause.
+func getDialTimeout(delay time.Duration) time.Duration {
+	dialTimeout := delay / 2
 	if dialTimeout == 0 {
 		dialTimeout = 500 * time.Millisecond
 	} else if dialTimeout > 5*time.Second {
@@ -689,11 +852,13 @@ func (conn *Connection) getDialTimeout() time.Duration {
 }
 
 func (conn *Connection) runReconnects() error {
-	dialTimeout := conn.getDialTimeout()
+	// The first attempt is made right after a disconnect.
+	dialTimeout := getDialTimeout(conn.opts.Reconnect)
 	var reconnects uint
+	var delay time.Duration
 	var err error
 
-	for conn.opts.MaxReconnects == 0 || reconnects <= conn.opts.MaxReconnects {
+	for {
 		now := time.Now()
 
 		ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
@@ -712,9 +877,16 @@ func (conn *Connection) runReconnects() error {
 		conn.opts.Logger.Report(LogReconnectFailed, conn, reconnects, err)
 		conn.notify(ReconnectFailed)
 		reconnects++
+
+		var ok bool
+		delay, ok = conn.opts.ReconnectPolicy.NextDelay(reconnects, delay, err)
+		if !ok {
+			break
+		}
+		dialTimeout = getDialTimeout(delay)
 		conn.mutex.Unlock()
 
-		time.Sleep(time.Until(now.Add(conn.opts.Reconnect)))
+		time.Sleep(time.Until(now.Add(delay)))
 
 		conn.mutex.Lock()
 	}
@@ -725,7 +897,7 @@ func (conn *Connection) runReconnects() error {
 }
 
 func (conn *Connection) reconnectImpl(neterr error, c Conn) {
-	if conn.opts.Reconnect > 0 {
+	if conn.opts.ReconnectPolicy != nil {
 		if c == conn.c {
 			conn.closeConnection(neterr, false)
 			if err := conn.runReconnects(); err != nil {
@@ -911,14 +1083,18 @@ func (conn *Connection) reader(r io.Reader, c Conn) {
 				conn.opts.Logger.Report(LogWatchEventReadFailed, conn, err)
 			}
 			continue
-		} else if resp.Code == PushCode {
+		}
+
+		conn.checkSchemaVersion(resp.schemaVersion)
+		if resp.Code == PushCode {
 			if fut = conn.peekFuture(resp.RequestId); fut != nil {
+				conn.observe(PushReceived, fut, resp, nil)
 				fut.AppendPush(resp)
 			}
-		} else {
+		} else if fut = conn.resendOnSchemaMismatch(resp); fut == nil {
 			if fut = conn.fetchFuture(resp.RequestId); fut != nil {
-				fut.SetResponse(resp)
-				conn.markDone(fut)
+				conn.observe(ResponseReceived, fut, resp, nil)
+				conn.finishFuture(fut, resp, nil)
 			}
 		}
 
@@ -953,8 +1129,15 @@ func (conn *Connection) eventer(events <-chan connWatchEvent) {
 	}
 }
 
-func (conn *Connection) newFuture(ctx context.Context) (fut *Future) {
+func (conn *Connection) newFuture(req Request, streamId uint64) (fut *Future) {
+	ctx := req.Ctx()
 	fut = NewFuture()
+	fut.req = req
+	fut.streamId = streamId
+	fut.respTimeout = conn.responseTimeout(req)
+	if conn.opts.Observer != nil {
+		fut.start = time.Now()
+	}
 	if conn.rlimit != nil && conn.opts.RLimitAction == RLimitDrop {
 		select {
 		case conn.rlimit <- struct{}{}:
@@ -972,30 +1155,8 @@ func (conn *Connection) newFuture(ctx context.Context) (fut *Future) {
 	shardn := fut.requestId & (conn.opts.Concurrency - 1)
 	shard := &conn.shard[shardn]
 	shard.rmut.Lock()
-	switch atomic.LoadUint32(&conn.state) {
-	case connClosed:
-		fut.err = ClientError{
-			ErrConnectionClosed,
-			"using closed connection",
-		}
-		fut.ready = nil
-		fut.done = nil
-		shard.rmut.Unlock()
-		return
-	case connDisconnected:
-		fut.err = ClientError{
-			ErrConnectionNotReady,
-			"client connection is not ready",
-		}
-		fut.ready = nil
-		fut.done = nil
-		shard.rmut.Unlock()
-		return
-	case connShutdown:
-		fut.err = ClientError{
-			ErrConnectionShutdown,
-			"server shutdown in progress",
-		}
+	if err := conn.stateError(); err != nil {
+		fut.err = err
 		fut.ready = nil
 		fut.done = nil
 		shard.rmut.Unlock()
@@ -1005,18 +1166,18 @@ func (conn *Connection) newFuture(ctx context.Context) (fut *Future) {
 	if ctx != nil {
 		select {
 		case <-ctx.Done():
-			fut.SetError(fmt.Errorf("context is done"))
+			err := fmt.Errorf("context is done")
 			shard.rmut.Unlock()
+			conn.observe(RequestCanceled, fut, nil, err)
+			fut.SetError(err)
 			return
 		default:
 		}
 		shard.requestsWithCtx[pos].addFuture(fut)
 	} else {
 		shard.requests[pos].addFuture(fut)
-		if conn.opts.Timeout > 0 {
-			fut.timeout = time.Since(epoch) + conn.opts.Timeout
-		}
 	}
+	conn.setTimeout(shard, fut, time.Since(epoch))
 	shard.rmut.Unlock()
 	if conn.rlimit != nil && conn.opts.RLimitAction == RLimitWait {
 		select {
@@ -1035,6 +1196,29 @@ func (conn *Connection) newFuture(ctx context.Context) (fut *Future) {
 	return
 }
 
+// stateError returns an error if requests could not be sent in the current
+// state of the connection.
+func (conn *Connection) stateError() error {
+	switch atomic.LoadUint32(&conn.state) {
+	case connClosed:
+		return ClientError{
+			ErrConnectionClosed,
+			"using closed connection",
+		}
+	case connDisconnected:
+		return ClientError{
+			ErrConnectionNotReady,
+			"client connection is not ready",
+		}
+	case connShutdown:
+		return ClientError{
+			ErrConnectionShutdown,
+			"server shutdown in progress",
+		}
+	}
+	return nil
+}
+
 // This method removes a future from the internal queue if the context
 // is "done" before the response is come.
 func (conn *Connection) contextWatchdog(fut *Future, ctx context.Context) {
@@ -1064,9 +1248,10 @@ func (conn *Connection) decrementRequestCnt() {
 func (conn *Connection) send(req Request, streamId uint64) *Future {
 	conn.incrementRequestCnt()
 
-	fut := conn.newFuture(req.Ctx())
+	fut := conn.newFuture(req, streamId)
 	if fut.ready == nil {
 		conn.decrementRequestCnt()
+		conn.observe(RequestFailed, fut, nil, fut.err)
 		return fut
 	}
 
@@ -1084,6 +1269,169 @@ func (conn *Connection) send(req Request, streamId uint64) *Future {
 	return fut
 }
 
+// sendBatch sends the requests with the stream identifier and stores their
+// futures into futs. Requests with already stored futures are skipped.
+func (conn *Connection) sendBatch(reqs []Request, futs []*Future, streamId uint64) {
+	if conn.rlimit != nil {
+		// The rate limit could be reached in the middle of a batch, so
+		// requests are sent one by one.
+		for i, req := range reqs {
+			if futs[i] == nil {
+				futs[i] = conn.send(req, streamId)
+			}
+		}
+		return
+	}
+
+	// Requests with and without contexts have different sequences of
+	// identifiers and could not share a shard.
+	var run, runCtx []int
+	for i, req := range reqs {
+		if futs[i] != nil {
+			continue
+		}
+		if req.Ctx() != nil {
+			runCtx = append(runCtx, i)
+		} else {
+			run = append(run, i)
+		}
+	}
+	if len(run) > 0 {
+		conn.sendRun(reqs, futs, run, false, streamId)
+	}
+	if len(runCtx) > 0 {
+		conn.sendRun(reqs, futs, runCtx, true, streamId)
+	}
+}
+
+// sendRun registers futures of the requests with the indexes from the run
+// and packs the requests into a single shard buffer.
+func (conn *Connection) sendRun(reqs []Request, futs []*Future, run []int,
+	withCtx bool, streamId uint64) {
+	// Identifiers differ by a multiple of Concurrency, so all requests of
+	// the run belong to the same shard.
+	step := conn.opts.Concurrency
+	if step < 2 {
+		step = 2
+	}
+	counter := &conn.requestId
+	if withCtx {
+		counter = &conn.contextRequestId
+	}
+	size := uint32(len(run))
+	requestId := atomic.AddUint32(counter, size*step) - size*step
+
+	batch := make([]*Future, 0, len(run))
+	for _, i := range run {
+		requestId += step
+		fut := NewFuture()
+		fut.req = reqs[i]
+		fut.streamId = streamId
+		fut.requestId = requestId
+		fut.respTimeout = conn.responseTimeout(reqs[i])
+		if conn.opts.Observer != nil {
+			fut.start = time.Now()
+		}
+		futs[i] = fut
+		batch = append(batch, fut)
+		conn.incrementRequestCnt()
+	}
+
+	shardn := requestId & (conn.opts.Concurrency - 1)
+	shard := &conn.shard[shardn]
+	shard.rmut.Lock()
+	if err := conn.stateError(); err != nil {
+		shard.rmut.Unlock()
+		for _, fut := range batch {
+			fut.err = err
+			fut.ready = nil
+			fut.done = nil
+			conn.decrementRequestCnt()
+			conn.observe(RequestFailed, fut, nil, err)
+		}
+		return
+	}
+	var canceled []*Future
+	now := time.Since(epoch)
+	for _, fut := range batch {
+		pos := (fut.requestId / conn.opts.Concurrency) & (requestsMap - 1)
+		if ctx := fut.req.Ctx(); ctx != nil {
+			select {
+			case <-ctx.Done():
+				canceled = append(canceled, fut)
+				continue
+			default:
+			}
+			shard.requestsWithCtx[pos].addFuture(fut)
+		} else {
+			shard.requests[pos].addFuture(fut)
+		}
+		conn.setTimeout(shard, fut, now)
+	}
+	shard.rmut.Unlock()
+
+	for _, fut := range canceled {
+		err := fmt.Errorf("context is done")
+		conn.observe(RequestCanceled, fut, nil, err)
+		conn.finishFuture(fut, nil, err)
+	}
+	if withCtx {
+		for _, fut := range batch {
+			select {
+			case <-fut.done:
+			default:
+				go conn.contextWatchdog(fut, fut.req.Ctx())
+			}
+		}
+	}
+
+	type packError struct {
+		fut *Future
+		err error
+	}
+	var failed []packError
+	sent := batch[:0]
+
+	shard.bufmut.Lock()
+	firstWritten := shard.buf.Len() == 0
+	if shard.buf.Cap() == 0 {
+		shard.buf.b = make([]byte, 0, 128)
+		shard.enc = msgpack.NewEncoder(&shard.buf)
+	}
+	res := conn.resolver
+	for _, fut := range batch {
+		select {
+		case <-fut.done:
+			continue
+		default:
+		}
+		fut.schemaVersion = ignoreSchemaVersion
+		if _, ok := fut.req.(schemaRequest); !ok && conn.Schema != nil {
+			fut.schemaVersion = uint64(conn.Schema.Version)
+		}
+		blen := shard.buf.Len()
+		err := pack(&shard.buf, shard.enc, fut.requestId, fut.req, streamId,
+			fut.schemaVersion, res)
+		if err != nil {
+			shard.buf.Trunc(blen)
+			failed = append(failed, packError{fut, err})
+			continue
+		}
+		sent = append(sent, fut)
+	}
+	shard.bufmut.Unlock()
+
+	if firstWritten && len(sent) > 0 {
+		conn.dirtyShard <- shardn
+	}
+	for _, f := range failed {
+		conn.failPacked(f.fut, f.err)
+	}
+	for _, fut := range sent {
+		conn.sent(fut)
+	}
+}
+
 func (conn *Connection) putFuture(fut *Future, req Request, streamId uint64) {
 	shardn := fut.requestId & (conn.opts.Concurrency - 1)
 	shard := &conn.shard[shardn]
@@ -1101,28 +1449,16 @@ func (conn *Connection) putFuture(fut *Future, req Request, streamId uint64) {
 	}
 	blen := shard.buf.Len()
 	reqid := fut.requestId
-	if err := pack(&shard.buf, shard.enc, reqid, req, streamId, conn.Schema); err != nil {
+	fut.schemaVersion = ignoreSchemaVersion
+	if _, ok := req.(schemaRequest); !ok && conn.Schema != nil {
+		fut.schemaVersion = uint64(conn.Schema.Version)
+	}
+	err := pack(&shard.buf, shard.enc, reqid, req, streamId, fut.schemaVersion,
+		conn.resolver)
+	if err != nil {
 		shard.buf.Trunc(blen)
 		shard.bufmut.Unlock()
-		if f := conn.fetchFuture(reqid); f == fut {
-			fut.SetError(err)
-			conn.markDone(fut)
-		} else if f != nil {
-			/* in theory, it is possible. In practice, you have
-			 * to have race condition that lasts hours */
-			panic("Unknown future")
-		} else {
-			fut.wait()
-			if fut.err == nil {
-				panic("Future removed from queue without error")
-			}
-			if _, ok := fut.err.(ClientError); ok {
-				// packing error is more important than connection
-				// error, because it is indication of programmer's
-				// mistake.
-				fut.SetError(err)
-			}
-		}
+		conn.failPacked(fut, err)
 		return
 	}
 	shard.bufmut.Unlock()
@@ -1130,19 +1466,186 @@ func (conn *Connection) putFuture(fut *Future, req Request, streamId uint64) {
 	if firstWritten {
 		conn.dirtyShard <- shardn
 	}
+	conn.sent(fut)
+}
+
+// failPacked sets the packing error to the future.
+func (conn *Connection) failPacked(fut *Future, err error) {
+	if f := conn.fetchFuture(fut.requestId); f == fut {
+		conn.observe(RequestFailed, fut, nil, err)
+		conn.finishFuture(fut, nil, err)
+	} else if f != nil {
+		/* in theory, it is possible. In practice, you have
+		 * to have race condition that lasts hours */
+		panic("Unknown future")
+	} else {
+		fut.wait()
+		if fut.err == nil {
+			panic("Future removed from queue without error")
+		}
+		if _, ok := fut.err.(ClientError); ok {
+			// packing error is more important than connection
+			// error, because it is indication of programmer's
+			// mistake.
+			fut.SetError(err)
+		}
+	}
+}
+
+// sent finishes sending of the packed future. A response is not expected
+// for asynchronous requests.
+func (conn *Connection) sent(fut *Future) {
+	conn.observe(RequestSent, fut, nil, nil)
 
-	if req.Async() {
+	if fut.req.Async() {
+		reqid := fut.requestId
 		if fut = conn.fetchFuture(reqid); fut != nil {
 			resp := &Response{
 				RequestId: reqid,
 				Code:      OkCode,
 			}
-			fut.SetResponse(resp)
-			conn.markDone(fut)
+			conn.observe(ResponseReceived, fut, resp, nil)
+			conn.finishFuture(fut, resp, nil)
+		}
+	}
+}
+
+// resendOnSchemaMismatch checks that the response is an ER_WRONG_SCHEMA_VERSION
+// error for a request packed with an outdated schema. In this case it
+// reloads the schema and sends the request again in background. It returns
+// the future of the request or nil if the response should be processed as
+// usual.
+func (conn *Connection) resendOnSchemaMismatch(resp *Response) *Future {
+	const wrongSchemaVersionCode = uint32(iproto.IPROTO_TYPE_ERROR) |
+		uint32(iproto.ER_WRONG_SCHEMA_VERSION)
+
+	if resp.Code != wrongSchemaVersionCode || conn.opts.SkipSchema {
+		return nil
+	}
+
+	shard := &conn.shard[resp.RequestId&(conn.opts.Concurrency-1)]
+	shard.rmut.Lock()
+	fut := conn.getFutureImp(resp.RequestId, false)
+	if fut == nil {
+		shard.rmut.Unlock()
+		return nil
+	}
+	shard.bufmut.Lock()
+	req, streamId, version := fut.req, fut.streamId, fut.schemaVersion
+	shard.bufmut.Unlock()
+	shard.rmut.Unlock()
+
+	if req == nil || version == ignoreSchemaVersion || version == resp.schemaVersion {
+		return nil
+	}
+
+	go func() {
+		err := conn.reloadSchema(resp.schemaVersion)
+		if err != nil {
+			conn.opts.Logger.Report(LogSchemaReloadFailed, conn, err)
+			conn.startSchemaReload(resp.schemaVersion)
+		}
+		if err != nil || atomic.LoadUint64(&conn.schemaVersion) == version {
+			if fut := conn.fetchFuture(resp.RequestId); fut != nil {
+				conn.observe(ResponseReceived, fut, resp, nil)
+				conn.finishFuture(fut, resp, nil)
+			}
+			return
+		}
+		conn.putFuture(fut, req, streamId)
+	}()
+	return fut
+}
+
+// checkSchemaVersion starts a schema reloading in background if the version
+// differs from the version of the current schema. The version could be
+// lower after a failover to another instance.
+func (conn *Connection) checkSchemaVersion(version uint64) {
+	current := atomic.LoadUint64(&conn.schemaVersion)
+	if conn.opts.SkipSchema || current == ignoreSchemaVersion ||
+		version == ignoreSchemaVersion || version == current {
+		return
+	}
+	conn.startSchemaReload(version)
+}
+
+// startSchemaReload reloads the schema in background. If another reload is
+// in progress, the reload is queued and performed after it.
+func (conn *Connection) startSchemaReload(version uint64) {
+	if conn.queueSchemaReload(version) {
+		go conn.schemaReloader()
+	}
+}
+
+// queueSchemaReload queues a background reload of the schema. It returns
+// true if a new schemaReloader() goroutine should be started.
+func (conn *Connection) queueSchemaReload(version uint64) bool {
+	conn.schemaReload.Lock()
+	defer conn.schemaReload.Unlock()
+
+	// A forced reload could not be replaced by a conditional one.
+	if !conn.schemaReload.pending || conn.schemaReload.version != ignoreSchemaVersion {
+		conn.schemaReload.version = version
+	}
+	conn.schemaReload.pending = true
+	if conn.schemaReload.running {
+		return false
+	}
+	conn.schemaReload.running = true
+	return true
+}
+
+// schemaReloader performs queued schema reloads. A failed reload is retried
+// after schemaReloadRetryDelay while the connection is established. The
+// schema is reloaded anyway after a reconnect.
+func (conn *Connection) schemaReloader() {
+	for {
+		conn.schemaReload.Lock()
+		if !conn.schemaReload.pending {
+			conn.schemaReload.running = false
+			conn.schemaReload.Unlock()
+			return
+		}
+		version := conn.schemaReload.version
+		conn.schemaReload.pending = false
+		conn.schemaReload.Unlock()
+
+		err := conn.reloadSchema(version)
+		if err == nil {
+			continue
+		}
+		conn.opts.Logger.Report(LogSchemaReloadFailed, conn, err)
+		if atomic.LoadUint32(&conn.state) != connConnected {
+			continue
+		}
+
+		conn.queueSchemaReload(version)
+		timer := time.NewTimer(schemaReloadRetryDelay)
+		select {
+		case <-timer.C:
+		case <-conn.control:
+			timer.Stop()
+			conn.schemaReload.Lock()
+			conn.schemaReload.running = false
+			conn.schemaReload.pending = false
+			conn.schemaReload.Unlock()
+			return
 		}
 	}
 }
 
+// reloadSchema reloads the schema if a version of the current schema differs
+// from the version. A zero version forces the reload.
+func (conn *Connection) reloadSchema(version uint64) error {
+	conn.schemaMutex.Lock()
+	defer conn.schemaMutex.Unlock()
+
+	if version != ignoreSchemaVersion && atomic.LoadUint64(&conn.schemaVersion) == version {
+		return nil
+	}
+	return conn.loadSchema()
+}
+
 func (conn *Connection) markDone(fut *Future) {
 	if conn.rlimit != nil {
 		<-conn.rlimit
@@ -1150,23 +1653,29 @@ func (conn *Connection) markDone(fut *Future) {
 	conn.decrementRequestCnt()
 }
 
+// finishFuture sets the response or the error for the future. Callbacks of
+// the future are called after the request is marked as done, so its rate
+// limit slot is free for requests of the callbacks.
+func (conn *Connection) finishFuture(fut *Future, resp *Response, err error) {
+	var callbacks doneCallbacks
+	if err != nil {
+		callbacks = fut.setError(err)
+	} else {
+		callbacks = fut.setResponse(resp)
+	}
+	conn.markDone(fut)
+	callbacks.call()
+}
+
 func (conn *Connection) peekFuture(reqid uint32) (fut *Future) {
 	shard := &conn.shard[reqid&(conn.opts.Concurrency-1)]
-	pos := (reqid / conn.opts.Concurrency) & (requestsMap - 1)
 	shard.rmut.Lock()
 	defer shard.rmut.Unlock()
 
-	if conn.opts.Timeout > 0 {
-		if fut = conn.getFutureImp(reqid, true); fut != nil {
-			pair := &shard.requests[pos]
-			*pair.last = fut
-			pair.last = &fut.next
-			fut.timeout = time.Since(epoch) + conn.opts.Timeout
-		}
-	} else {
-		fut = conn.getFutureImp(reqid, false)
+	// The timeout is reset by a push message.
+	if fut = conn.getFutureImp(reqid, false); fut != nil {
+		conn.setTimeout(shard, fut, time.Since(epoch))
 	}
-
 	return fut
 }
 
@@ -1181,55 +1690,82 @@ func (conn *Connection) fetchFuture(reqid uint32) (fut *Future) {
 func (conn *Connection) getFutureImp(reqid uint32, fetch bool) *Future {
 	shard := &conn.shard[reqid&(conn.opts.Concurrency-1)]
 	pos := (reqid / conn.opts.Concurrency) & (requestsMap - 1)
+	var fut *Future
 	// futures with even requests id belong to requests list with nil context
 	if reqid%2 == 0 {
-		return shard.requests[pos].findFuture(reqid, fetch)
+		fut = shard.requests[pos].findFuture(reqid, fetch)
+	} else {
+		fut = shard.requestsWithCtx[pos].findFuture(reqid, fetch)
+	}
+	if fut != nil && fetch {
+		shard.timeouts.remove(fut)
+	}
+	return fut
+}
+
+// responseTimeout returns a timeout for a response to the request: its own
+// timeout or Opts.Timeout for requests without a context.
+func (conn *Connection) responseTimeout(req Request) time.Duration {
+	if req, ok := req.(interface{ responseTimeout() time.Duration }); ok {
+		if timeout := req.responseTimeout(); timeout > 0 {
+			return timeout
+		}
+	}
+	if req.Ctx() != nil {
+		return 0
+	}
+	return conn.opts.Timeout
+}
+
+// setTimeout sets or resets a timeout of the future. It must be called under
+// the shard lock.
+func (conn *Connection) setTimeout(shard *connShard, fut *Future, now time.Duration) {
+	if fut.respTimeout <= 0 {
+		return
+	}
+	fut.timeout = now + fut.respTimeout
+	if shard.timeouts.contains(fut) {
+		heap.Fix(&shard.timeouts, fut.heapIndex)
 	} else {
-		return shard.requestsWithCtx[pos].findFuture(reqid, fetch)
+		heap.Push(&shard.timeouts, fut)
+	}
+
+	// The goroutine waits for a later timeout or does not wait at all.
+	next := atomic.LoadInt64(&conn.nextTimeout)
+	if next == 0 || int64(fut.timeout) < next {
+		select {
+		case conn.timeoutsWake <- struct{}{}:
+		default:
+		}
 	}
 }
 
 func (conn *Connection) timeouts() {
-	timeout := conn.opts.Timeout
-	t := time.NewTimer(timeout)
+	t := time.NewTimer(time.Hour)
+	t.Stop()
 	for {
-		var nowepoch time.Duration
 		select {
 		case <-conn.control:
 			t.Stop()
 			return
 		case <-t.C:
-		}
-		minNext := time.Since(epoch) + timeout
-		for i := range conn.shard {
-			nowepoch = time.Since(epoch)
-			shard := &conn.shard[i]
-			for pos := range shard.requests {
-				shard.rmut.Lock()
-				pair := &shard.requests[pos]
-				for pair.first != nil && pair.first.timeout < nowepoch {
-					shard.bufmut.Lock()
-					fut := pair.first
-					pair.first = fut.next
-					if fut.next == nil {
-						pair.last = &pair.first
-					} else {
-						fut.next = nil
-					}
-					fut.SetError(ClientError{
-						Code: ErrTimeouted,
-						Msg:  fmt.Sprintf("client timeout for request %d", fut.requestId),
-					})
-					conn.markDone(fut)
-					shard.bufmut.Unlock()
-				}
-				if pair.first != nil && pair.first.timeout < minNext {
-					minNext = pair.first.timeout
+		case <-conn.timeoutsWake:
+			if !t.Stop() {
+				select {
+				case <-t.C:
+				default:
 				}
-				shard.rmut.Unlock()
 			}
 		}
-		nowepoch = time.Since(epoch)
+
+		// Futures added during the walk wake up the goroutine again.
+		atomic.StoreInt64(&conn.nextTimeout, 0)
+		minNext := conn.expireTimeouts()
+		if minNext == 0 {
+			continue
+		}
+		atomic.StoreInt64(&conn.nextTimeout, int64(minNext))
+		nowepoch := time.Since(epoch)
 		if nowepoch+time.Microsecond < minNext {
 			t.Reset(minNext - nowepoch)
 		} else {
@@ -1238,6 +1774,41 @@ func (conn *Connection) timeouts() {
 	}
 }
 
+// expireTimeouts finishes timed out futures. It returns the next timeout
+// or zero if there are no futures with timeouts.
+func (conn *Connection) expireTimeouts() (minNext time.Duration) {
+	var expired []*Future
+	for i := range conn.shard {
+		shard := &conn.shard[i]
+		shard.rmut.Lock()
+		nowepoch := time.Since(epoch)
+		for len(shard.timeouts) > 0 && shard.timeouts[0].timeout < nowepoch {
+			fut := heap.Pop(&shard.timeouts).(*Future)
+			conn.getFutureImp(fut.requestId, true)
+			expired = append(expired, fut)
+		}
+		if len(shard.timeouts) > 0 {
+			if next := shard.timeouts[0].timeout; minNext == 0 || next < minNext {
+				minNext = next
+			}
+		}
+		shard.rmut.Unlock()
+
+		// Futures are finished out of the lock, so callbacks of the futures
+		// could send requests.
+		for _, fut := range expired {
+			err := ClientError{
+				Code: ErrTimeouted,
+				Msg:  fmt.Sprintf("client timeout for request %d", fut.requestId),
+			}
+			conn.observe(RequestTimedOut, fut, nil, err)
+			conn.finishFuture(fut, nil, err)
+		}
+		expired = expired[:0]
+	}
+	return minNext
+}
+
 func read(r io.Reader, lenbuf []byte) (response []byte, err error) {
 	var length int
 
@@ -1286,12 +1857,38 @@ func (conn *Connection) Do(req Request) *Future {
 	return conn.send(req, ignoreStreamId)
 }
 
+// DoBatch performs the requests asynchronously on the connection and returns
+// their futures in the order of the requests.
+//
+// Requests are packed into a single buffer under one lock and are flushed to
+// the network at once, so it is cheaper than Do() for each request. Requests
+// with contexts are packed into another buffer, so their order relative to
+// requests without contexts is not preserved. If Opts.RateLimit is set,
+// requests are sent one by one as with Do().
+func (conn *Connection) DoBatch(reqs []Request) []*Future {
+	futs := make([]*Future, len(reqs))
+	for i, req := range reqs {
+		if connectedReq, ok := req.(ConnectedRequest); ok {
+			if connectedReq.Conn() != conn {
+				futs[i] = NewFuture()
+				futs[i].SetError(errUnknownRequest)
+			}
+		}
+	}
+	conn.sendBatch(reqs, futs, ignoreStreamId)
+	return futs
+}
+
 // ConfiguredTimeout returns a timeout from connection config.
 func (conn *Connection) ConfiguredTimeout() time.Duration {
 	return conn.opts.Timeout
 }
 
 // OverrideSchema sets Schema for the connection.
+//
+// The schema is still reloaded automatically after a reconnect or a schema
+// change on Tarantool side unless Opts.SkipSchema is set or Schema.Version
+// is zero.
 func (conn *Connection) OverrideSchema(s *Schema) {
 	if s != nil {
 		conn.mutex.Lock()
@@ -1300,6 +1897,8 @@ func (conn *Connection) OverrideSchema(s *Schema) {
 		defer conn.unlockShards()
 
 		conn.Schema = s
+		conn.resolver = conn.resolver.withSchema(s)
+		atomic.StoreUint64(&conn.schemaVersion, uint64(s.Version))
 	}
 }
 
@@ -1437,7 +2036,9 @@ func isFeatureInSlice(expected ProtocolFeature, actualSlice []ProtocolFeature) b
 // NewWatcher creates a new Watcher object for the connection.
 //
 // You need to require WatchersFeature to use watchers, see examples for the
-// function.
+// function. A watcher could be created without the requirement if the
+// connected server supports the feature, but it does not get events after
+// a reconnect to a server without watchers support.
 //
 // After watcher creation, the watcher callback is invoked for the first time.
 // In this case, the callback is triggered whether or not the key has already
@@ -1472,9 +2073,11 @@ func (conn *Connection) NewWatcher(key string, callback WatchCallback) (Watcher,
 	// asynchronous. We do not expect any response from a Tarantool instance
 	// That's why we can't just check the Tarantool response for an unsupported
 	// request error.
-	if !isFeatureInSlice(WatchersFeature, conn.opts.RequiredProtocolInfo.Features) {
+	if !isFeatureInSlice(WatchersFeature, conn.opts.RequiredProtocolInfo.Features) &&
+		!isFeatureInSlice(WatchersFeature, conn.serverProtocolInfo.Features) {
 		err := fmt.Errorf("the feature %s must be required by connection "+
-			"options to create a watcher", WatchersFeature)
+			"options or supported by the server to create a watcher",
+			WatchersFeature)
 		return nil, err
 	}
 
@@ -1609,6 +2212,7 @@ func (conn *Connection) shutdown(forever bool) error {
 		// We don't want to reconnect any more.
 		conn.opts.Reconnect = 0
 		conn.opts.MaxReconnects = 0
+		conn.opts.ReconnectPolicy = nil
 	}
 
 	conn.cond.Broadcast()
@@ -1,11 +1,19 @@
 package tarantool_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	. "github.com/tarantool/go-tarantool/v2"
+	"github.com/tarantool/go-tarantool/v2/test_helpers"
+	"github.com/tarantool/go-tarantool/v2/test_helpers/fakeserver"
 )
 
 func TestOptsClonePreservesRequiredProtocolFeatures(t *testing.T) {
@@ -29,3 +37,649 @@ func TestOptsClonePreservesRequiredProtocolFeatures(t *testing.T) {
 			},
 		})
 }
+
+func startBatchServer(t *testing.T) *fakeserver.Server {
+	t.Helper()
+
+	srv, err := fakeserver.StartWithSpace(fakeserver.Opts{}, fakeserver.Space{
+		Id:   1000,
+		Name: "batch",
+		Indexes: []fakeserver.Index{
+			{Name: "primary", Parts: []fakeserver.IndexPart{{Field: 0, Type: "unsigned"}}},
+		},
+	})
+	require.NoError(t, err)
+	return srv
+}
+
+func TestConnection_DoBatch(t *testing.T) {
+	for _, concurrency := range []uint32{0, 1, 2} {
+		srv := startBatchServer(t)
+
+		conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{
+			Timeout:     5 * time.Second,
+			Concurrency: concurrency,
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		canceled, cancelCanceled := context.WithCancel(context.Background())
+		cancelCanceled()
+
+		reqs := []Request{}
+		for i := 1; i <= 100; i++ {
+			req := NewInsertRequest("batch").Tuple([]interface{}{uint(i)})
+			if i%10 == 0 {
+				// Mix requests with and without contexts.
+				req = req.Context(ctx)
+			}
+			reqs = append(reqs, req)
+		}
+		reqs = append(reqs,
+			NewInsertRequest("batch").Tuple([]interface{}{make(chan int)}),
+			NewInsertRequest("batch").Tuple([]interface{}{uint(101)}).Context(canceled))
+
+		futs := conn.DoBatch(reqs)
+		require.Len(t, futs, len(reqs))
+		for i, fut := range futs[:100] {
+			var inserted [][]uint
+			require.NoError(t, fut.GetTyped(&inserted), "request %d", i)
+			assert.Equal(t, [][]uint{{uint(i + 1)}}, inserted)
+		}
+		_, err := futs[100].Get()
+		assert.Error(t, err)
+		_, err = futs[101].Get()
+		assert.EqualError(t, err, "context is done")
+
+		var tuples [][]uint
+		err = conn.Do(NewSelectRequest("batch").Iterator(IterAll).Limit(1000)).
+			GetTyped(&tuples)
+		require.NoError(t, err)
+		require.Len(t, tuples, 100)
+		for i, tuple := range tuples {
+			assert.Equal(t, []uint{uint(i + 1)}, tuple)
+		}
+
+		cancel()
+		conn.Close()
+		srv.Close()
+	}
+}
+
+func TestConnection_DoBatch_rateLimit(t *testing.T) {
+	srv := startBatchServer(t)
+	defer srv.Close()
+
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{
+		Timeout:      5 * time.Second,
+		RateLimit:    3,
+		RLimitAction: RLimitWait,
+	})
+	defer conn.Close()
+
+	reqs := []Request{}
+	for i := 1; i <= 10; i++ {
+		reqs = append(reqs, NewReplaceRequest(uint(1000)).Tuple([]interface{}{uint(i)}))
+	}
+	for _, fut := range conn.DoBatch(reqs) {
+		_, err := fut.Get()
+		assert.NoError(t, err)
+	}
+}
+
+func TestConnection_DoBatch_closed(t *testing.T) {
+	srv := startBatchServer(t)
+	defer srv.Close()
+
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{
+		Timeout: 5 * time.Second,
+	})
+	conn.Close()
+
+	futs := conn.DoBatch([]Request{NewPingRequest(), NewPingRequest()})
+	require.Len(t, futs, 2)
+	for _, fut := range futs {
+		_, err := fut.Get()
+		require.Error(t, err)
+		assert.Equal(t, uint32(ErrConnectionClosed), err.(ClientError).Code)
+	}
+}
+
+func TestStream_DoBatch(t *testing.T) {
+	srv := startBatchServer(t)
+	defer srv.Close()
+
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{
+		Timeout: 5 * time.Second,
+	})
+	defer conn.Close()
+
+	stream, err := conn.NewStream()
+	require.NoError(t, err)
+
+	futs := stream.DoBatch([]Request{
+		NewBeginRequest(),
+		NewInsertRequest(uint(1000)).Tuple([]interface{}{uint(1)}),
+		NewInsertRequest(uint(1000)).Tuple([]interface{}{uint(2)}),
+		NewRollbackRequest(),
+		NewSelectRequest(uint(1000)).Iterator(IterAll),
+	})
+	require.Len(t, futs, 5)
+	for _, fut := range futs[:4] {
+		_, err := fut.Get()
+		require.NoError(t, err)
+	}
+	resp, err := futs[4].Get()
+	require.NoError(t, err)
+	assert.Empty(t, resp.Data)
+}
+
+// startSleepServer starts a server with the "sleep" function that does not
+// return until the server is closed.
+func startSleepServer(t testing.TB) (*fakeserver.Server, func()) {
+	t.Helper()
+
+	srv, err := fakeserver.Start(fakeserver.Opts{})
+	require.NoError(t, err)
+
+	wake := make(chan struct{})
+	srv.RegisterFunction("sleep", func(call fakeserver.Call) ([]interface{}, error) {
+		<-wake
+		return nil, nil
+	})
+	return srv, func() {
+		close(wake)
+		srv.Close()
+	}
+}
+
+func requireTimeouted(t *testing.T, err error) {
+	t.Helper()
+
+	var clientErr ClientError
+	require.True(t, errors.As(err, &clientErr), err)
+	require.Equal(t, uint32(ErrTimeouted), clientErr.Code, err)
+}
+
+func TestConnection_SchemaReload_lowerVersion(t *testing.T) {
+	srv := startTupleServer(t, fakeserver.Opts{})
+	defer srv.Close()
+	// The server has a high schema version at start.
+	srv.SetSchemaVersion(10)
+
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{
+		Timeout:   5 * time.Second,
+		Reconnect: 10 * time.Millisecond,
+	})
+	defer conn.Close()
+
+	insert := func(id uint) {
+		t.Helper()
+
+		_, err := conn.Do(NewInsertRequest("users").
+			Tuple([]interface{}{id, fmt.Sprintf("%d@example.com", id), nil})).Get()
+		require.NoError(t, err)
+	}
+	insert(1)
+
+	// A failover to an instance with a lower schema version: requests with
+	// the outdated version are sent again after a schema reload.
+	srv.SetSchemaVersion(5)
+	insert(2)
+
+	// A reconnect to an instance with a lower schema version.
+	srv.SetSchemaVersion(2)
+	srv.DropConnections()
+	require.Eventually(t, func() bool {
+		_, err := conn.Do(NewPingRequest()).Get()
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	insert(3)
+
+	var tuples [][]interface{}
+	err := conn.Do(NewSelectRequest("users").Limit(10)).GetTyped(&tuples)
+	require.NoError(t, err)
+	assert.Len(t, tuples, 3)
+}
+
+// reconnectDialer dials a server only once and records timeouts of
+// the following failed dials.
+type reconnectDialer struct {
+	mutex    sync.Mutex
+	dials    int
+	timeouts []time.Duration
+}
+
+func (d *reconnectDialer) Dial(ctx context.Context, address string,
+	opts DialOpts) (Conn, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.dials++
+	if d.dials == 1 {
+		return TtDialer{}.Dial(ctx, address, opts)
+	}
+	deadline, _ := ctx.Deadline()
+	d.timeouts = append(d.timeouts, time.Until(deadline))
+	return nil, errReconnect
+}
+
+func TestConnection_ReconnectPolicy_dialTimeout(t *testing.T) {
+	srv, err := fakeserver.Start(fakeserver.Opts{})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	dialer := &reconnectDialer{}
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{
+		Timeout: 5 * time.Second,
+		Dialer:  dialer,
+		ReconnectPolicy: ConstantReconnectPolicy{
+			Delay:       2 * time.Second,
+			MaxAttempts: 1,
+		},
+	})
+	defer conn.Close()
+
+	srv.DropConnections()
+	require.Eventually(t, conn.ClosedNow, 5*time.Second, 10*time.Millisecond)
+
+	dialer.mutex.Lock()
+	defer dialer.mutex.Unlock()
+	// The first attempt is made without a pause, the second one is made
+	// after the pause of the policy.
+	require.Len(t, dialer.timeouts, 2)
+	assert.InDelta(t, 500*time.Millisecond, dialer.timeouts[0], float64(100*time.Millisecond))
+	assert.InDelta(t, time.Second, dialer.timeouts[1], float64(100*time.Millisecond))
+}
+
+func TestConnection_NewWatcher_notRequired(t *testing.T) {
+	srv, err := fakeserver.Start(fakeserver.Opts{})
+	require.NoError(t, err)
+	defer srv.Close()
+	srv.Broadcast("key", "value")
+
+	// WatchersFeature is not required, but the server supports it.
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{
+		Timeout: 5 * time.Second,
+	})
+	defer conn.Close()
+
+	events := make(chan WatchEvent, 1)
+	watcher, err := conn.NewWatcher("key", func(event WatchEvent) {
+		events <- event
+	})
+	require.NoError(t, err)
+	defer watcher.Unregister()
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "value", event.Value)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Failed to get a watch event")
+	}
+}
+
+func TestConnection_NewWatcher_noWatchersFeature(t *testing.T) {
+	srv, err := fakeserver.Start(fakeserver.Opts{
+		ProtocolInfo: ProtocolInfo{
+			Auth:     ChapSha1Auth,
+			Version:  ProtocolVersion(6),
+			Features: []ProtocolFeature{StreamsFeature},
+		},
+	})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{
+		Timeout: 5 * time.Second,
+	})
+	defer conn.Close()
+
+	watcher, err := conn.NewWatcher("key", func(event WatchEvent) {})
+	assert.Nil(t, watcher)
+	assert.EqualError(t, err, "the feature WatchersFeature must be required by "+
+		"connection options or supported by the server to create a watcher")
+}
+
+func TestConnection_ResponseTimeout(t *testing.T) {
+	srv, stop := startSleepServer(t)
+	defer stop()
+
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{
+		Timeout: 10 * time.Second,
+	})
+	defer conn.Close()
+
+	long := conn.Do(NewCallRequest("sleep"))
+	// A shorter timeout is scheduled after a longer one.
+	start := time.Now()
+	_, err := conn.Do(NewCallRequest("sleep").ResponseTimeout(50 * time.Millisecond)).Get()
+	requireTimeouted(t, err)
+	assert.Less(t, int64(time.Since(start)), int64(5*time.Second))
+
+	// The timeout works for requests with a context too.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err = conn.Do(NewCallRequest("sleep").Context(ctx).
+		ResponseTimeout(50 * time.Millisecond)).Get()
+	requireTimeouted(t, err)
+
+	_, err = conn.Do(NewPingRequest().ResponseTimeout(time.Second)).Get()
+	require.NoError(t, err)
+
+	select {
+	case <-long.WaitChan():
+		t.Fatalf("the request with Opts.Timeout is finished: %v", long.Err())
+	default:
+	}
+}
+
+func TestConnection_ResponseTimeout_opts(t *testing.T) {
+	srv, stop := startSleepServer(t)
+	defer stop()
+
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{
+		Timeout: 100 * time.Millisecond,
+	})
+	defer conn.Close()
+
+	futs := make([]*Future, 0, 100)
+	for i := 0; i < 100; i++ {
+		futs = append(futs, conn.Do(NewCallRequest("sleep")))
+	}
+	for _, fut := range futs {
+		_, err := fut.Get()
+		requireTimeouted(t, err)
+	}
+
+	// Requests with a context do not use Opts.Timeout.
+	ctx, cancel := context.WithCancel(context.Background())
+	fut := conn.Do(NewCallRequest("sleep").Context(ctx))
+	time.Sleep(200 * time.Millisecond)
+	select {
+	case <-fut.WaitChan():
+		t.Fatalf("the request with a context is timed out: %v", fut.Err())
+	default:
+	}
+	cancel()
+	assert.Error(t, fut.Err())
+}
+
+func TestFuture_OnDone_connection(t *testing.T) {
+	srv, stop := startSleepServer(t)
+	defer stop()
+
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{
+		Timeout:     5 * time.Second,
+		Concurrency: 1,
+	})
+	defer conn.Close()
+
+	// Callbacks could send requests: after a response, a timeout and a close.
+	done := make(chan error, 3)
+	chain := func(resp *Response, err error) {
+		conn.Do(NewPingRequest()).OnDone(func(*Response, error) {
+			done <- err
+		})
+	}
+	conn.Do(NewPingRequest()).OnDone(chain)
+	conn.Do(NewCallRequest("sleep").ResponseTimeout(10 * time.Millisecond)).OnDone(chain)
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-done:
+			if err != nil {
+				requireTimeouted(t, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("a callback is not called")
+		}
+	}
+
+	conn.Do(NewCallRequest("sleep")).OnDone(chain)
+	conn.Close()
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("a callback is not called")
+	}
+}
+
+func TestFuture_OnDone_rateLimit(t *testing.T) {
+	srv, err := fakeserver.Start(fakeserver.Opts{})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{
+		Timeout:      5 * time.Second,
+		RateLimit:    1,
+		RLimitAction: RLimitWait,
+	})
+	defer conn.Close()
+
+	// A slot of the request is free when a callback sends a new request.
+	done := make(chan error, 1)
+	conn.Do(NewPingRequest()).OnDone(func(*Response, error) {
+		conn.Do(NewPingRequest()).OnDone(func(_ *Response, err error) {
+			done <- err
+		})
+	})
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("a callback is blocked")
+	}
+}
+
+func TestWaitFirstSuccess_connection(t *testing.T) {
+	srv, stop := startSleepServer(t)
+	defer stop()
+
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{
+		Timeout: 5 * time.Second,
+	})
+	defer conn.Close()
+
+	futs := []*Future{
+		conn.Do(NewCallRequest("unknown")),
+		conn.Do(NewCallRequest("sleep")),
+		conn.Do(NewPingRequest()),
+	}
+	i, err := WaitFirstSuccess(context.Background(), futs)
+	require.NoError(t, err)
+	assert.Equal(t, 2, i)
+
+	i, err = WaitFirstSuccess(context.Background(), futs[:1])
+	assert.Equal(t, -1, i)
+	var tntErr Error
+	require.True(t, errors.As(err, &tntErr), err)
+	assert.Equal(t, "Procedure 'unknown' is not defined", tntErr.Msg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, WaitAll(ctx, futs))
+}
+
+type progress struct {
+	_msgpack struct{} `msgpack:",asArray"` //nolint: structcheck,unused
+	Step     uint
+	Name     string
+}
+
+// startPushServer starts a server with the "progress" function that pushes
+// the number of progress events from the first argument and returns after
+// the release.
+func startPushServer(t *testing.T) (*fakeserver.Server, chan struct{}) {
+	t.Helper()
+
+	srv, err := fakeserver.Start(fakeserver.Opts{})
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	srv.RegisterFunction("progress", func(call fakeserver.Call) ([]interface{}, error) {
+		for i := uint64(0); i < call.Args[0].(uint64); i++ {
+			if err := call.Push([]interface{}{i, "step"}); err != nil {
+				return nil, err
+			}
+		}
+		<-release
+		return []interface{}{"done"}, nil
+	})
+	return srv, release
+}
+
+func TestFuture_Pushes(t *testing.T) {
+	srv, release := startPushServer(t)
+	defer srv.Close()
+
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{
+		Timeout: 5 * time.Second,
+	})
+	defer conn.Close()
+
+	fut := conn.Do(NewCallRequest("progress").Args([]interface{}{3}))
+	pushes := fut.Pushes(context.Background())
+	for i := 0; i < 3; i++ {
+		push := <-pushes
+		assert.Equal(t, PushCode, push.Code)
+		assert.Equal(t, []interface{}{[]interface{}{int8(i), "step"}}, push.Data)
+	}
+	close(release)
+	_, ok := <-pushes
+	assert.False(t, ok)
+
+	resp, err := fut.Get()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"done"}, resp.Data)
+
+	// A new channel receives all push messages.
+	cnt := 0
+	for range fut.Pushes(context.Background()) {
+		cnt++
+	}
+	assert.Equal(t, 3, cnt)
+}
+
+func TestFuture_PushesTyped(t *testing.T) {
+	srv, release := startPushServer(t)
+	defer srv.Close()
+	defer close(release)
+
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{
+		Timeout: 5 * time.Second,
+	})
+	defer conn.Close()
+
+	fut := conn.Do(NewCallRequest("progress").Args([]interface{}{100}))
+
+	// A consumer that does not read does not block other requests.
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan []progress)
+	errs := fut.PushesTyped(ctx, events)
+	_, err := conn.Do(NewPingRequest()).Get()
+	require.NoError(t, err)
+
+	for i := uint(0); i < 10; i++ {
+		event := <-events
+		assert.Equal(t, []progress{{Step: i, Name: "step"}}, event)
+	}
+	cancel()
+	assert.Equal(t, context.Canceled, <-errs)
+	// The channel is closed by the future.
+	for range events {
+	}
+
+	errs = fut.PushesTyped(context.Background(), 1)
+	assert.EqualError(t, <-errs, "unable to send push messages into int")
+	errs = fut.PushesTyped(context.Background(), make(<-chan int))
+	assert.Error(t, <-errs)
+}
+
+func TestFuture_Pushes_canceled(t *testing.T) {
+	srv, release := startPushServer(t)
+	defer srv.Close()
+	defer close(release)
+
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{
+		Timeout: 5 * time.Second,
+	})
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pushes := conn.Do(NewCallRequest("progress").Args([]interface{}{0})).Pushes(ctx)
+	cancel()
+	select {
+	case _, ok := <-pushes:
+		assert.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the channel is not closed")
+	}
+}
+
+// BenchmarkConnection_expireTimeouts measures a wake up of the timeouts
+// goroutine with many requests in flight. The per-shard heaps are compared
+// with the walk over all request lists.
+func BenchmarkConnection_expireTimeouts(b *testing.B) {
+	expires := []struct {
+		name   string
+		expire func(conn *Connection)
+	}{
+		{"heap", ExpireTimeouts},
+		{"scan", ScanTimeouts},
+	}
+	for _, inflight := range []int{0, 10000, 50000} {
+		for _, expire := range expires {
+			name := fmt.Sprintf("inflight=%d/%s", inflight, expire.name)
+			b.Run(name, func(b *testing.B) {
+				srv, stop := startSleepServer(b)
+				defer stop()
+
+				conn, err := Connect(context.Background(), srv.Addr(), Opts{
+					Timeout:     time.Minute,
+					Concurrency: 128,
+				})
+				require.NoError(b, err)
+				defer conn.Close()
+
+				for i := 0; i < inflight; i++ {
+					conn.Do(NewCallRequest("sleep"))
+				}
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					expire.expire(conn)
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkConnection_timeoutsInFlight measures requests while many other
+// requests with timeouts are waiting for responses.
+func BenchmarkConnection_timeoutsInFlight(b *testing.B) {
+	for _, inflight := range []int{1000, 10000, 50000} {
+		b.Run(fmt.Sprintf("inflight=%d", inflight), func(b *testing.B) {
+			srv, stop := startSleepServer(b)
+			defer stop()
+
+			conn, err := Connect(context.Background(), srv.Addr(), Opts{
+				Timeout:     time.Minute,
+				Concurrency: 128,
+			})
+			require.NoError(b, err)
+			defer conn.Close()
+
+			for i := 0; i < inflight; i++ {
+				conn.Do(NewCallRequest("sleep"))
+			}
+			ping := NewPingRequest()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := conn.Do(ping).Get(); err != nil {
+						b.Error(err)
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,329 @@
+// Package console implements a client for the Tarantool admin console.
+//
+// The admin console (console.listen()) speaks a text protocol instead of
+// IPROTO: a client sends Lua chunks and receives results in YAML or Lua
+// format. The package dials a console port, sets a delimiter to send
+// multi-line chunks and parses replies into Go values.
+//
+//	conn, err := console.Connect(ctx, "unix:/var/run/tarantool/app.control",
+//		console.Opts{Timeout: time.Second})
+//	if err != nil {
+//		return err
+//	}
+//	defer conn.Close()
+//
+//	values, err := conn.Eval("return box.info.status")
+//
+// # See also
+//
+// * Tarantool admin console https://www.tarantool.io/en/doc/latest/reference/reference_lua/console/
+//
+// Since: 2.0.0
+package console
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/tarantool/go-tarantool/v2"
+)
+
+// Format is an output format of the console.
+type Format string
+
+const (
+	// YAMLFormat is the YAML output format.
+	YAMLFormat Format = "yaml"
+	// LuaFormat is the Lua output format.
+	LuaFormat Format = "lua"
+)
+
+const (
+	// delimiter ends a chunk. It is set on connect so chunks could contain
+	// new lines.
+	delimiter = "$EOF$"
+	// greetingSize is a size of the console greeting.
+	greetingSize = 128
+	// yamlEnd is the last line of a reply in YAML format.
+	yamlEnd = "...\n"
+	// luaEnd ends a reply in Lua format.
+	luaEnd = ';'
+)
+
+var (
+	// ErrNotConsole is returned by Connect() if a server sends a greeting
+	// of another protocol, for example, if the address is a binary port.
+	ErrNotConsole = errors.New("not an admin console")
+	// ErrDelimiter is returned by Conn.Eval() if a chunk contains
+	// the delimiter followed by a new line.
+	ErrDelimiter = errors.New("chunk contains the delimiter " + delimiter)
+	// ErrClosed is returned after Conn.Close().
+	ErrClosed = errors.New("console connection is closed")
+)
+
+// Error is an error raised by a Lua chunk.
+type Error struct {
+	// Message is an error message.
+	Message string
+}
+
+// Error converts an Error to a string.
+func (err *Error) Error() string {
+	return err.Message
+}
+
+// Opts is options for a console connection.
+type Opts struct {
+	// Timeout is a timeout for the greeting and for each Eval() call. There
+	// is no timeout if it is zero. A connection is closed after a timeout
+	// because a late reply could not be matched with a chunk.
+	Timeout time.Duration
+	// Format is an output format of the console. YAMLFormat is used by
+	// default.
+	Format Format
+}
+
+// Conn is a connection to the admin console. It is safe for concurrent
+// use, but chunks are evaluated one by one.
+type Conn struct {
+	mutex    sync.Mutex
+	net      net.Conn
+	reader   *bufio.Reader
+	opts     Opts
+	greeting tarantool.Greeting
+	// err is set if the connection is unusable.
+	err error
+}
+
+// Connect connects to the admin console at the address. The address
+// formats are the same as for tarantool.Connect().
+func Connect(ctx context.Context, address string, opts Opts) (*Conn, error) {
+	if opts.Format == "" {
+		opts.Format = YAMLFormat
+	}
+	if opts.Format != YAMLFormat && opts.Format != LuaFormat {
+		return nil, fmt.Errorf("unsupported output format: %s", opts.Format)
+	}
+
+	network, address := tarantool.ParseAddress(address)
+	dialer := net.Dialer{}
+	netConn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial: %w", err)
+	}
+
+	conn := &Conn{
+		net:    netConn,
+		reader: bufio.NewReader(netConn),
+		opts:   opts,
+	}
+	if err := conn.handshake(ctx); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// handshake reads the greeting and configures the console session.
+func (c *Conn) handshake(ctx context.Context) error {
+	deadline, ok := ctx.Deadline()
+	if c.opts.Timeout > 0 {
+		timeout := time.Now().Add(c.opts.Timeout)
+		if !ok || timeout.Before(deadline) {
+			deadline, ok = timeout, true
+		}
+	}
+	if ok {
+		c.net.SetDeadline(deadline)
+	}
+
+	data := make([]byte, greetingSize)
+	if _, err := io.ReadFull(c.reader, data); err != nil {
+		return fmt.Errorf("failed to read greeting: %w", err)
+	}
+	version := strings.TrimSpace(string(data[:greetingSize/2]))
+	if !strings.HasSuffix(version, "(Lua console)") {
+		return fmt.Errorf("%w: unexpected greeting %q", ErrNotConsole, version)
+	}
+	c.greeting.Version = version
+
+	// The output format is applied to the reply of the command itself.
+	commands := []string{
+		"\\set output " + string(c.opts.Format) + "\n",
+		"\\set delimiter " + delimiter + "\n",
+	}
+	for _, command := range commands {
+		if _, err := c.eval(command); err != nil {
+			return fmt.Errorf("failed to configure the console: %w", err)
+		}
+	}
+
+	c.net.SetDeadline(time.Time{})
+	return nil
+}
+
+// Greeting returns the console greeting.
+func (c *Conn) Greeting() tarantool.Greeting {
+	return c.greeting
+}
+
+// Eval evaluates the Lua chunk and returns values returned by it. YAML
+// values are decoded as gopkg.in/yaml.v3 does, Lua tables are decoded into
+// []interface{} if keys are 1..n, into map[string]interface{} if keys are
+// strings and into map[interface{}]interface{} otherwise.
+//
+// If the chunk raises an error, an *Error is returned. Note that the
+// console could not distinguish a raised error from a returned table with
+// the single "error" key.
+func (c *Conn) Eval(chunk string) ([]interface{}, error) {
+	if strings.Contains(chunk, delimiter+"\n") {
+		return nil, ErrDelimiter
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	if c.opts.Timeout > 0 {
+		c.net.SetDeadline(time.Now().Add(c.opts.Timeout))
+	}
+	values, err := c.eval(chunk + delimiter + "\n")
+	if err != nil {
+		var consoleErr *Error
+		if !errors.As(err, &consoleErr) {
+			// The reply stream is broken.
+			c.err = err
+			c.net.Close()
+		}
+		return nil, err
+	}
+	return values, nil
+}
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	// Unblocks an active Eval() call.
+	err := c.net.Close()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.err == ErrClosed {
+		return ErrClosed
+	}
+	c.err = ErrClosed
+	return err
+}
+
+// eval sends a command and reads a reply.
+func (c *Conn) eval(command string) ([]interface{}, error) {
+	if _, err := io.WriteString(c.net, command); err != nil {
+		return nil, fmt.Errorf("failed to send a chunk: %w", err)
+	}
+
+	var values []interface{}
+	var err error
+	if c.opts.Format == LuaFormat {
+		values, err = c.readLua()
+	} else {
+		values, err = c.readYAML()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values) == 1 {
+		if err := replyError(values[0]); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+func (c *Conn) readYAML() ([]interface{}, error) {
+	var buf bytes.Buffer
+	for {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("failed to read a reply: %w", err)
+		}
+		buf.WriteString(line)
+		if line == yamlEnd {
+			break
+		}
+	}
+
+	var values []interface{}
+	if err := yaml.Unmarshal(buf.Bytes(), &values); err != nil {
+		return nil, fmt.Errorf("failed to decode a reply: %w", err)
+	}
+	if values == nil {
+		values = []interface{}{}
+	}
+	return values, nil
+}
+
+func (c *Conn) readLua() ([]interface{}, error) {
+	var buf strings.Builder
+	for {
+		// The terminator could be a part of a string, so a reply is read
+		// until it could be parsed.
+		part, err := c.reader.ReadString(luaEnd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read a reply: %w", err)
+		}
+		buf.WriteString(part)
+
+		values, err := parseLua(buf.String())
+		if err == errLuaIncomplete {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to decode a reply: %w", err)
+		}
+		return values, nil
+	}
+}
+
+// replyError returns an error if the value is an error raised by a chunk:
+// a table with the single "error" key.
+func replyError(value interface{}) error {
+	var msg interface{}
+	switch table := value.(type) {
+	case map[string]interface{}:
+		if len(table) != 1 {
+			return nil
+		}
+		var ok bool
+		if msg, ok = table["error"]; !ok {
+			return nil
+		}
+	case map[interface{}]interface{}:
+		if len(table) != 1 {
+			return nil
+		}
+		var ok bool
+		if msg, ok = table["error"]; !ok {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	if str, ok := msg.(string); ok {
+		return &Error{Message: str}
+	}
+	return &Error{Message: fmt.Sprint(msg)}
+}
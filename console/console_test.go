@@ -0,0 +1,331 @@
+package console_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tarantool/go-tarantool/v2/console"
+)
+
+const consoleGreeting = "Tarantool 2.11.1-0-g96877bd (Lua console)"
+
+var yamlReplies = map[string]string{
+	"\\set output yaml":     "---\n...\n",
+	"\\set delimiter $EOF$": "---\n...\n",
+	"return 1, 'a;b', {b = 2}, {1, 2}, nil": "---\n- 1\n- a;b\n- b: 2\n" +
+		"- [1, 2]\n- null\n...\n",
+	"local a = 1\nreturn a + 1": "---\n- 2\n...\n",
+	"box.cfg{}":                 "---\n...\n",
+	"error('boom')":             "---\n- error: 'boom'\n...\n",
+}
+
+var luaReplies = map[string]string{
+	"\\set output lua":                      ";",
+	"\\set delimiter $EOF$":                 ";",
+	"return 1, 'a;b', {b = 2}, {1, 2}, nil": "1, \"a;b\", {b = 2}, {1, 2}, nil;",
+	"box.cfg{}":                             ";",
+	"error('boom')":                         "{error = \"boom\"};",
+}
+
+// fakeConsole is a server of the admin console protocol with predefined
+// replies. It does not reply to unknown commands.
+type fakeConsole struct {
+	listener net.Listener
+	greeting string
+	replies  map[string]string
+
+	mutex    sync.Mutex
+	commands []string
+}
+
+func newFakeConsole(t *testing.T, network, address string,
+	replies map[string]string) *fakeConsole {
+	t.Helper()
+
+	return newFakeConsoleGreeting(t, network, address, consoleGreeting, replies)
+}
+
+// newFakeConsoleGreeting starts a fake console with a custom greeting.
+func newFakeConsoleGreeting(t *testing.T, network, address, greeting string,
+	replies map[string]string) *fakeConsole {
+	t.Helper()
+
+	listener, err := net.Listen(network, address)
+	require.NoError(t, err)
+
+	srv := &fakeConsole{
+		listener: listener,
+		greeting: greeting,
+		replies:  replies,
+	}
+	go srv.serve()
+	return srv
+}
+
+func (srv *fakeConsole) addr() string {
+	if srv.listener.Addr().Network() == "unix" {
+		return "unix:" + srv.listener.Addr().String()
+	}
+	return srv.listener.Addr().String()
+}
+
+func (srv *fakeConsole) close() {
+	srv.listener.Close()
+}
+
+func (srv *fakeConsole) received() []string {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+
+	return append([]string(nil), srv.commands...)
+}
+
+func (srv *fakeConsole) serve() {
+	for {
+		conn, err := srv.listener.Accept()
+		if err != nil {
+			return
+		}
+		go srv.handle(conn)
+	}
+}
+
+func (srv *fakeConsole) handle(conn net.Conn) {
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "%-63s\n%-63s\n", srv.greeting,
+		"type 'help' for interactive help")
+
+	reader := bufio.NewReader(conn)
+	delimiter := ""
+	for {
+		var command string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command += line
+			if strings.HasSuffix(command, delimiter+"\n") {
+				command = strings.TrimSuffix(command, delimiter+"\n")
+				break
+			}
+		}
+
+		srv.mutex.Lock()
+		srv.commands = append(srv.commands, command)
+		srv.mutex.Unlock()
+
+		reply, ok := srv.replies[command]
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(command, "\\set delimiter ") {
+			delimiter = strings.TrimPrefix(command, "\\set delimiter ")
+		}
+		// Split the reply to check reading by parts.
+		for _, part := range []string{reply[:len(reply)/2], reply[len(reply)/2:]} {
+			if _, err := conn.Write([]byte(part)); err != nil {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+func TestConnect_yaml(t *testing.T) {
+	srv := newFakeConsole(t, "tcp", "127.0.0.1:0", yamlReplies)
+	defer srv.close()
+
+	conn, err := console.Connect(context.Background(), srv.addr(), console.Opts{
+		Timeout: time.Second,
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	assert.Equal(t, consoleGreeting, conn.Greeting().Version)
+
+	values, err := conn.Eval("return 1, 'a;b', {b = 2}, {1, 2}, nil")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		1,
+		"a;b",
+		map[string]interface{}{"b": 2},
+		[]interface{}{1, 2},
+		nil,
+	}, values)
+
+	values, err = conn.Eval("local a = 1\nreturn a + 1")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{2}, values)
+
+	values, err = conn.Eval("box.cfg{}")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{}, values)
+
+	assert.Equal(t, []string{
+		"\\set output yaml",
+		"\\set delimiter $EOF$",
+		"return 1, 'a;b', {b = 2}, {1, 2}, nil",
+		"local a = 1\nreturn a + 1",
+		"box.cfg{}",
+	}, srv.received())
+}
+
+func TestConnect_lua(t *testing.T) {
+	srv := newFakeConsole(t, "tcp", "127.0.0.1:0", luaReplies)
+	defer srv.close()
+
+	conn, err := console.Connect(context.Background(), srv.addr(), console.Opts{
+		Timeout: time.Second,
+		Format:  console.LuaFormat,
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	values, err := conn.Eval("return 1, 'a;b', {b = 2}, {1, 2}, nil")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		1,
+		"a;b",
+		map[string]interface{}{"b": 2},
+		[]interface{}{1, 2},
+		nil,
+	}, values)
+
+	values, err = conn.Eval("box.cfg{}")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{}, values)
+}
+
+func TestConnect_unix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "console")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	srv := newFakeConsole(t, "unix", filepath.Join(dir, "console.sock"), yamlReplies)
+	defer srv.close()
+
+	conn, err := console.Connect(context.Background(), srv.addr(), console.Opts{})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	values, err := conn.Eval("local a = 1\nreturn a + 1")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{2}, values)
+}
+
+func TestConnect_error(t *testing.T) {
+	srv := newFakeConsole(t, "tcp", "127.0.0.1:0", yamlReplies)
+	defer srv.close()
+
+	_, err := console.Connect(context.Background(), srv.addr(), console.Opts{
+		Format: console.Format("json"),
+	})
+	assert.EqualError(t, err, "unsupported output format: json")
+
+	srv.close()
+	_, err = console.Connect(context.Background(), srv.addr(), console.Opts{})
+	assert.Error(t, err)
+}
+
+func TestConnect_binaryPort(t *testing.T) {
+	srv := newFakeConsoleGreeting(t, "tcp", "127.0.0.1:0",
+		"Tarantool 2.11.1 (Binary) 0d5bd431-7f3e-4695-a5c2-82de0a9cbc95", yamlReplies)
+	defer srv.close()
+
+	_, err := console.Connect(context.Background(), srv.addr(), console.Opts{
+		Timeout: time.Second,
+	})
+	assert.True(t, errors.Is(err, console.ErrNotConsole), err)
+}
+
+func TestConn_Eval_error(t *testing.T) {
+	for _, format := range []console.Format{console.YAMLFormat, console.LuaFormat} {
+		t.Run(string(format), func(t *testing.T) {
+			replies := yamlReplies
+			if format == console.LuaFormat {
+				replies = luaReplies
+			}
+			srv := newFakeConsole(t, "tcp", "127.0.0.1:0", replies)
+			defer srv.close()
+
+			conn, err := console.Connect(context.Background(), srv.addr(),
+				console.Opts{Timeout: time.Second, Format: format})
+			require.NoError(t, err)
+			defer conn.Close()
+
+			_, err = conn.Eval("error('boom')")
+			require.Error(t, err)
+			var consoleErr *console.Error
+			require.True(t, errors.As(err, &consoleErr), err)
+			assert.Equal(t, "boom", consoleErr.Message)
+
+			// The connection is still usable.
+			_, err = conn.Eval("box.cfg{}")
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestConn_Eval_delimiter(t *testing.T) {
+	srv := newFakeConsole(t, "tcp", "127.0.0.1:0", yamlReplies)
+	defer srv.close()
+
+	conn, err := console.Connect(context.Background(), srv.addr(), console.Opts{
+		Timeout: time.Second,
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Eval("return 1$EOF$\nreturn 2")
+	assert.Equal(t, console.ErrDelimiter, err)
+}
+
+func TestConn_Eval_timeout(t *testing.T) {
+	srv := newFakeConsole(t, "tcp", "127.0.0.1:0", yamlReplies)
+	defer srv.close()
+
+	conn, err := console.Connect(context.Background(), srv.addr(), console.Opts{
+		Timeout: 100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Eval("require('fiber').sleep(1)")
+	require.Error(t, err)
+	var netErr net.Error
+	require.True(t, errors.As(err, &netErr), err)
+	assert.True(t, netErr.Timeout())
+
+	// The connection is closed after a timeout.
+	_, err = conn.Eval("box.cfg{}")
+	assert.Error(t, err)
+}
+
+func TestConn_Close(t *testing.T) {
+	srv := newFakeConsole(t, "tcp", "127.0.0.1:0", yamlReplies)
+	defer srv.close()
+
+	conn, err := console.Connect(context.Background(), srv.addr(), console.Opts{})
+	require.NoError(t, err)
+
+	require.NoError(t, conn.Close())
+	assert.Equal(t, console.ErrClosed, conn.Close())
+
+	_, err = conn.Eval("box.cfg{}")
+	assert.Equal(t, console.ErrClosed, err)
+}
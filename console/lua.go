@@ -0,0 +1,330 @@
+package console
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// errLuaIncomplete is returned by parseLua() if a reply is not read
+// completely: a terminator is found inside a string or a table.
+var errLuaIncomplete = errors.New("incomplete Lua reply")
+
+// luaParser parses values in the Lua output format of the console.
+type luaParser struct {
+	data string
+	pos  int
+}
+
+// parseLua parses a reply in the Lua output format: values separated by
+// commas and terminated by a semicolon.
+func parseLua(data string) ([]interface{}, error) {
+	p := luaParser{data: data}
+
+	values := []interface{}{}
+	p.skipSpaces()
+	if p.consume(';') {
+		return values, nil
+	}
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		p.skipSpaces()
+		if p.consume(';') {
+			return values, nil
+		}
+		if !p.consume(',') {
+			return nil, p.unexpected()
+		}
+	}
+}
+
+func (p *luaParser) skipSpaces() {
+	for p.pos < len(p.data) && strings.IndexByte(" \t\r\n", p.data[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *luaParser) consume(c byte) bool {
+	if p.pos < len(p.data) && p.data[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *luaParser) unexpected() error {
+	if p.pos >= len(p.data) {
+		return errLuaIncomplete
+	}
+	return fmt.Errorf("unexpected symbol %q at position %d of Lua reply",
+		p.data[p.pos], p.pos)
+}
+
+func (p *luaParser) parseValue() (interface{}, error) {
+	p.skipSpaces()
+	if p.pos >= len(p.data) {
+		return nil, errLuaIncomplete
+	}
+
+	c := p.data[p.pos]
+	switch {
+	case c == '{':
+		return p.parseTable()
+	case c == '"' || c == '\'':
+		return p.parseString()
+	case c == '-' || c == '.' || isDigit(c):
+		return p.parseNumber()
+	case isIdentStart(c):
+		ident := p.parseIdent()
+		switch ident {
+		case "nil", "null", "box.NULL":
+			return nil, nil
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "inf":
+			return math.Inf(1), nil
+		case "nan":
+			return math.NaN(), nil
+		}
+		return nil, fmt.Errorf("unexpected identifier %q in Lua reply", ident)
+	}
+	return nil, p.unexpected()
+}
+
+func (p *luaParser) parseIdent() string {
+	start := p.pos
+	for p.pos < len(p.data) && (isIdentStart(p.data[p.pos]) ||
+		isDigit(p.data[p.pos]) || p.data[p.pos] == '.') {
+		p.pos++
+	}
+	return p.data[start:p.pos]
+}
+
+func (p *luaParser) parseNumber() (interface{}, error) {
+	start := p.pos
+	if p.consume('-') && p.pos < len(p.data) && isIdentStart(p.data[p.pos]) {
+		switch ident := p.parseIdent(); ident {
+		case "inf":
+			return math.Inf(-1), nil
+		case "nan":
+			return math.NaN(), nil
+		default:
+			return nil, fmt.Errorf("unexpected identifier %q in Lua reply", ident)
+		}
+	}
+	for p.pos < len(p.data) && (isDigit(p.data[p.pos]) ||
+		isIdentStart(p.data[p.pos]) || strings.IndexByte(".+-", p.data[p.pos]) >= 0) {
+		// Exponent signs are allowed only after an exponent marker.
+		if (p.data[p.pos] == '+' || p.data[p.pos] == '-') &&
+			strings.IndexByte("eEpP", p.data[p.pos-1]) < 0 {
+			break
+		}
+		p.pos++
+	}
+	if p.pos >= len(p.data) {
+		return nil, errLuaIncomplete
+	}
+
+	str := p.data[start:p.pos]
+	// 64-bit integers are printed with LL and ULL suffixes.
+	if strings.HasSuffix(str, "ULL") {
+		if val, err := strconv.ParseUint(str[:len(str)-3], 0, 64); err == nil {
+			return integer(val), nil
+		}
+	} else if strings.HasSuffix(str, "LL") {
+		if val, err := strconv.ParseInt(str[:len(str)-2], 0, 64); err == nil {
+			return int(val), nil
+		}
+	} else if val, err := strconv.ParseInt(str, 0, 64); err == nil {
+		return int(val), nil
+	} else if val, err := strconv.ParseUint(str, 0, 64); err == nil {
+		return integer(val), nil
+	} else if val, err := strconv.ParseFloat(str, 64); err == nil {
+		return val, nil
+	}
+	return nil, fmt.Errorf("invalid number %q in Lua reply", str)
+}
+
+// integer converts an unsigned integer to int if it fits like YAML decoder
+// does.
+func integer(val uint64) interface{} {
+	if val <= math.MaxInt64 {
+		return int(val)
+	}
+	return val
+}
+
+func (p *luaParser) parseString() (string, error) {
+	quote := p.data[p.pos]
+	p.pos++
+
+	var sb strings.Builder
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		p.pos++
+		if c == quote {
+			return sb.String(), nil
+		}
+		if c != '\\' {
+			sb.WriteByte(c)
+			continue
+		}
+
+		if p.pos >= len(p.data) {
+			break
+		}
+		c = p.data[p.pos]
+		p.pos++
+		switch c {
+		case 'a':
+			sb.WriteByte('\a')
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'n', '\n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case 'v':
+			sb.WriteByte('\v')
+		case 'x':
+			if p.pos+2 > len(p.data) {
+				return "", errLuaIncomplete
+			}
+			val, err := strconv.ParseUint(p.data[p.pos:p.pos+2], 16, 8)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape sequence in Lua reply: %w", err)
+			}
+			sb.WriteByte(byte(val))
+			p.pos += 2
+		default:
+			if !isDigit(c) {
+				// \\, \", \' and other escaped symbols.
+				sb.WriteByte(c)
+				continue
+			}
+			// A decimal escape sequence: \ddd.
+			start := p.pos - 1
+			for p.pos < len(p.data) && p.pos-start < 3 && isDigit(p.data[p.pos]) {
+				p.pos++
+			}
+			val, err := strconv.ParseUint(p.data[start:p.pos], 10, 8)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape sequence in Lua reply: %w", err)
+			}
+			sb.WriteByte(byte(val))
+		}
+	}
+	return "", errLuaIncomplete
+}
+
+// parseTable parses a table. A table with keys 1..n is converted to
+// []interface{}, a table with string keys to map[string]interface{} and
+// other tables to map[interface{}]interface{}.
+func (p *luaParser) parseTable() (interface{}, error) {
+	p.pos++
+
+	keys, values := []interface{}{}, []interface{}{}
+	next := 1
+	for {
+		p.skipSpaces()
+		if p.consume('}') {
+			break
+		}
+
+		var key interface{}
+		if p.consume('[') {
+			var err error
+			if key, err = p.parseValue(); err != nil {
+				return nil, err
+			}
+			p.skipSpaces()
+			if !p.consume(']') {
+				return nil, p.unexpected()
+			}
+			p.skipSpaces()
+			if !p.consume('=') {
+				return nil, p.unexpected()
+			}
+		} else if p.pos < len(p.data) && isIdentStart(p.data[p.pos]) {
+			start := p.pos
+			ident := p.parseIdent()
+			p.skipSpaces()
+			if p.consume('=') {
+				key = ident
+			} else {
+				// It is a value: nil, true, false and etc.
+				p.pos = start
+			}
+		}
+		if key == nil {
+			key = next
+			next++
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+
+		p.skipSpaces()
+		if !p.consume(',') && !p.consume(';') {
+			if p.consume('}') {
+				break
+			}
+			return nil, p.unexpected()
+		}
+	}
+
+	return makeTable(keys, values), nil
+}
+
+func makeTable(keys, values []interface{}) interface{} {
+	isArray, isMap := true, true
+	for i, key := range keys {
+		if idx, ok := key.(int); !ok || idx != i+1 {
+			isArray = false
+		}
+		if _, ok := key.(string); !ok {
+			isMap = false
+		}
+	}
+
+	if isArray {
+		return values
+	}
+	if isMap {
+		table := make(map[string]interface{}, len(keys))
+		for i, key := range keys {
+			table[key.(string)] = values[i]
+		}
+		return table
+	}
+	table := make(map[interface{}]interface{}, len(keys))
+	for i, key := range keys {
+		table[key] = values[i]
+	}
+	return table
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,99 @@
+package console
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLua(t *testing.T) {
+	cases := []struct {
+		reply    string
+		expected []interface{}
+	}{
+		{";", []interface{}{}},
+		{"\n;", []interface{}{}},
+		{"nil, null, box.NULL, true, false;",
+			[]interface{}{nil, nil, nil, true, false}},
+		{"1, -2, 1.5, 1e3, 0x10, 9223372036854775807LL, 18446744073709551615ULL;",
+			[]interface{}{1, -2, 1.5, 1000.0, 16, math.MaxInt64, uint64(math.MaxUint64)}},
+		{"inf, -inf;", []interface{}{math.Inf(1), math.Inf(-1)}},
+		{`"a;b", 'c"d', "e\"f\\g\n\t\65\x42";`,
+			[]interface{}{"a;b", "c\"d", "e\"f\\g\n\tAB"}},
+		{"{};", []interface{}{[]interface{}{}}},
+		{"{1, 2, {3}};",
+			[]interface{}{[]interface{}{1, 2, []interface{}{3}}}},
+		{"{a = 1, b = {c = 'd'}, [\"e f\"] = true};",
+			[]interface{}{map[string]interface{}{
+				"a":   1,
+				"b":   map[string]interface{}{"c": "d"},
+				"e f": true,
+			}}},
+		{"{1, a = 2, [3] = 4, [true] = false};",
+			[]interface{}{map[interface{}]interface{}{
+				1:    1,
+				"a":  2,
+				3:    4,
+				true: false,
+			}}},
+		{"{[1] = 'a', [2] = 'b',};", []interface{}{[]interface{}{"a", "b"}}},
+		{"{nil, true};", []interface{}{[]interface{}{nil, true}}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.reply, func(t *testing.T) {
+			values, err := parseLua(tc.reply)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, values)
+		})
+	}
+}
+
+func TestParseLua_nan(t *testing.T) {
+	values, err := parseLua("nan, -nan;")
+	require.NoError(t, err)
+	require.Len(t, values, 2)
+	for _, value := range values {
+		assert.True(t, math.IsNaN(value.(float64)))
+	}
+}
+
+func TestParseLua_incomplete(t *testing.T) {
+	cases := []string{
+		"",
+		"1",
+		"1,",
+		"\"a;",
+		"'a\\",
+		"{1;",
+		"{a = 'x;",
+	}
+
+	for _, reply := range cases {
+		t.Run(reply, func(t *testing.T) {
+			_, err := parseLua(reply)
+			assert.Equal(t, errLuaIncomplete, err)
+		})
+	}
+}
+
+func TestParseLua_error(t *testing.T) {
+	cases := []string{
+		"1 2;",
+		"foo;",
+		"{1 2};",
+		"{[1 = 2};",
+		"'\\300';",
+		"1.2.3;",
+	}
+
+	for _, reply := range cases {
+		t.Run(reply, func(t *testing.T) {
+			_, err := parseLua(reply)
+			assert.Error(t, err)
+			assert.NotEqual(t, errLuaIncomplete, err)
+		})
+	}
+}
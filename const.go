@@ -8,6 +8,16 @@ const (
 	packetLengthBytes = 5
 )
 
+const (
+	// iprotoSpaceName is the IPROTO_SPACE_NAME key of a request body.
+	iprotoSpaceName = iproto.Key(0x5e)
+	// iprotoIndexName is the IPROTO_INDEX_NAME key of a request body.
+	iprotoIndexName = iproto.Key(0x5f)
+	// iprotoTupleFormats is the IPROTO_TUPLE_FORMATS key of a response body
+	// with formats of MP_TUPLE values.
+	iprotoTupleFormats = iproto.Key(0x60)
+)
+
 const (
 	OkCode   = uint32(iproto.IPROTO_OK)
 	PushCode = uint32(iproto.IPROTO_CHUNK)
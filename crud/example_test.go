@@ -81,6 +81,30 @@ func ExampleResult_rowsCustomType() {
 	// [{{} 2010 45 bla}]
 }
 
+// ExampleMakeMappedResult demonstrates how to decode rows into structs by
+// field names from the result metadata.
+func ExampleMakeMappedResult() {
+	conn := exampleConnect()
+	req := crud.MakeReplaceRequest(exampleSpace).
+		Tuple([]interface{}{uint(2010), nil, "bla"})
+
+	type User struct {
+		Name string `tarantool:"name"`
+		Id   uint64 `tarantool:"id"`
+	}
+	ret := crud.MakeMappedResult(reflect.TypeOf(User{}))
+
+	if err := conn.Do(req).GetTyped(&ret); err != nil {
+		fmt.Printf("Failed to execute request: %s", err)
+		return
+	}
+
+	rows := ret.Rows.([]User)
+	fmt.Println(rows)
+	// Output:
+	// [{bla 2010}]
+}
+
 // ExampleResult_operationData demonstrates how to obtain information
 // about erroneous objects from crud.Error using `OperationData` field.
 func ExampleResult_operationData() {
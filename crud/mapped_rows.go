@@ -0,0 +1,149 @@
+package crud
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// rowFieldTags are struct tags with a field name in priority order.
+var rowFieldTags = []string{"tarantool", "msgpack"}
+
+// MakeMappedResult creates a Result object that decodes rows into structs of
+// the row type by field names from the result metadata. The row type must be
+// a struct or a pointer to a struct.
+//
+// A struct field is matched with a field from metadata by a name from
+// the `tarantool` tag, the `msgpack` tag or by the struct field name if there
+// are no tags:
+//
+//	type User struct {
+//		Id       uint64 `tarantool:"id"`
+//		BucketId uint64 `tarantool:"bucket_id"`
+//		Name     string `msgpack:"name"`
+//	}
+//
+// Fields with the "-" tag are ignored. Tuple fields without a matching struct
+// field are skipped and struct fields without a matching tuple field are
+// left as is, so the struct does not depend on an order of fields and new
+// fields of the space.
+//
+// Rows are decoded as a slice of the row type. Use FetchLatestMetadata option
+// to get rows with an actual format after a schema migration. OperationData
+// of a crud.Error is decoded as interface{}.
+func MakeMappedResult(rowType reflect.Type) Result {
+	return Result{
+		rowType: rowType,
+		mapRows: true,
+	}
+}
+
+// decodeMappedRows decodes raw rows into a slice of the row type by metadata names.
+func (r *Result) decodeMappedRows() error {
+	structType := r.rowType
+	if structType == nil {
+		return errors.New("unable to map rows without a row type")
+	}
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("unable to map rows to %s, a struct is expected", r.rowType)
+	}
+	if r.Metadata == nil {
+		return errors.New("unable to map rows to a struct without metadata")
+	}
+
+	fields, err := rowFields(structType, r.Metadata)
+	if err != nil {
+		return err
+	}
+
+	d := msgpack.NewDecoder(nil)
+	rows := reflect.MakeSlice(reflect.SliceOf(r.rowType), len(r.rawRows), len(r.rawRows))
+	for i, raw := range r.rawRows {
+		row := rows.Index(i)
+		if r.rowType.Kind() == reflect.Ptr {
+			row.Set(reflect.New(structType))
+			row = row.Elem()
+		}
+
+		d.Reset(bytes.NewReader(raw))
+		if err := decodeMappedRow(d, row, fields); err != nil {
+			return err
+		}
+	}
+	r.Rows = rows.Interface()
+	return nil
+}
+
+// rowFields returns indexes of struct fields by tuple field numbers or -1
+// for tuple fields without a matching struct field.
+func rowFields(structType reflect.Type, metadata []FieldFormat) ([]int, error) {
+	byName := make(map[string]int)
+	for i := 0; i < structType.NumField(); i++ {
+		sf := structType.Field(i)
+		name, ok := rowFieldName(sf)
+		if !ok {
+			continue
+		}
+		if prev, ok := byName[name]; ok {
+			return nil, fmt.Errorf("fields %s and %s of %s have the same name %q",
+				structType.Field(prev).Name, sf.Name, structType, name)
+		}
+		byName[name] = i
+	}
+
+	fields := make([]int, len(metadata))
+	for i, format := range metadata {
+		if index, ok := byName[format.Name]; ok {
+			fields[i] = index
+		} else {
+			fields[i] = -1
+		}
+	}
+	return fields, nil
+}
+
+// rowFieldName returns a tuple field name of the struct field or false if
+// the struct field is ignored.
+func rowFieldName(sf reflect.StructField) (string, bool) {
+	if sf.PkgPath != "" {
+		// Unexported field.
+		return "", false
+	}
+	for _, tagName := range rowFieldTags {
+		if tag, ok := sf.Tag.Lookup(tagName); ok {
+			name := strings.Split(tag, ",")[0]
+			if name == "-" {
+				return "", false
+			}
+			if name != "" {
+				return name, true
+			}
+		}
+	}
+	return sf.Name, true
+}
+
+func decodeMappedRow(d *msgpack.Decoder, row reflect.Value, fields []int) error {
+	l, err := d.DecodeArrayLen()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < l; i++ {
+		if i >= len(fields) || fields[i] < 0 {
+			err = d.Skip()
+		} else {
+			err = d.DecodeValue(row.Field(fields[i]))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
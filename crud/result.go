@@ -54,6 +54,10 @@ type Result struct {
 	Metadata []FieldFormat
 	Rows     interface{}
 	rowType  reflect.Type
+	// mapRows is true if rows are decoded into structs by metadata names.
+	mapRows bool
+	// rawRows contains rows to map after the metadata is decoded.
+	rawRows []msgpack.RawMessage
 }
 
 // MakeResult create a Result object with a custom row type for decoding.
@@ -113,7 +117,12 @@ func (r *Result) DecodeMsgpack(d *msgpack.Decoder) error {
 
 			r.Metadata = metadata
 		case "rows":
-			if r.rowType != nil {
+			if r.mapRows {
+				// The metadata could follow the rows.
+				if err = d.Decode(&r.rawRows); err != nil {
+					return err
+				}
+			} else if r.rowType != nil {
 				tuples := reflect.New(reflect.SliceOf(r.rowType))
 				if err = d.DecodeValue(tuples); err != nil {
 					return err
@@ -133,6 +142,18 @@ func (r *Result) DecodeMsgpack(d *msgpack.Decoder) error {
 		}
 	}
 
+	errorRowType := r.rowType
+	if r.mapRows {
+		errorRowType = nil
+		if r.rawRows != nil {
+			err := r.decodeMappedRows()
+			r.rawRows = nil
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	if arrLen > 1 {
 		code, err := d.PeekCode()
 		if err != nil {
@@ -140,7 +161,7 @@ func (r *Result) DecodeMsgpack(d *msgpack.Decoder) error {
 		}
 
 		if msgpackIsArray(code) {
-			crudErr := newErrorMany(r.rowType)
+			crudErr := newErrorMany(errorRowType)
 			if err := d.Decode(&crudErr); err != nil {
 				return err
 			}
@@ -148,7 +169,7 @@ func (r *Result) DecodeMsgpack(d *msgpack.Decoder) error {
 				return *crudErr
 			}
 		} else if code != msgpcode.Nil {
-			crudErr := newError(r.rowType)
+			crudErr := newError(errorRowType)
 			if err := d.Decode(&crudErr); err != nil {
 				return err
 			}
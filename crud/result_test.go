@@ -31,3 +31,121 @@ func TestResult_DecodeMsgpack(t *testing.T) {
 	require.Equal(t, results[0].Rows, []interface{}{"1", "2", "3"})
 	require.Equal(t, results[1].Rows, []interface{}{"1", "2", "3"})
 }
+
+type mappedRow struct {
+	Id       uint64 `tarantool:"id"`
+	BucketId uint64 `msgpack:"bucket_id"`
+	Name     string
+	Skipped  string `tarantool:"-"`
+}
+
+func decodeMappedResult(t *testing.T, rowType reflect.Type,
+	response interface{}) (crud.Result, error) {
+	t.Helper()
+
+	data, err := msgpack.Marshal(response)
+	require.NoError(t, err)
+
+	result := crud.MakeMappedResult(rowType)
+	err = msgpack.Unmarshal(data, &result)
+	return result, err
+}
+
+func TestMakeMappedResult(t *testing.T) {
+	metadata := []interface{}{
+		map[string]interface{}{"name": "bucket_id", "type": "unsigned"},
+		map[string]interface{}{"name": "Name", "type": "string"},
+		map[string]interface{}{"name": "id", "type": "unsigned"},
+		map[string]interface{}{"name": "age", "type": "unsigned", "is_nullable": true},
+	}
+	rows := []interface{}{
+		[]interface{}{10, "Alice", 1, nil},
+		[]interface{}{20, "Bob", 2, 30},
+	}
+	expected := []mappedRow{
+		{Id: 1, BucketId: 10, Name: "Alice"},
+		{Id: 2, BucketId: 20, Name: "Bob"},
+	}
+
+	// Keys order of a map is not defined, so rows could be decoded before
+	// metadata.
+	responses := map[string]interface{}{
+		"metadata first": []interface{}{
+			msgpack.RawMessage(encodeMap(t, "metadata", metadata, "rows", rows)), nil,
+		},
+		"rows first": []interface{}{
+			msgpack.RawMessage(encodeMap(t, "rows", rows, "metadata", metadata)), nil,
+		},
+	}
+	for name, response := range responses {
+		t.Run(name, func(t *testing.T) {
+			result, err := decodeMappedResult(t, reflect.TypeOf(mappedRow{}), response)
+			require.NoError(t, err)
+			require.Equal(t, expected, result.Rows)
+			require.Len(t, result.Metadata, 4)
+
+			result, err = decodeMappedResult(t, reflect.TypeOf(&mappedRow{}), response)
+			require.NoError(t, err)
+			require.Equal(t, []*mappedRow{&expected[0], &expected[1]}, result.Rows)
+		})
+	}
+}
+
+// encodeMap encodes a map with the order of keys.
+func encodeMap(t *testing.T, keysAndValues ...interface{}) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	require.NoError(t, enc.EncodeMapLen(len(keysAndValues)/2))
+	for _, value := range keysAndValues {
+		require.NoError(t, enc.Encode(value))
+	}
+	return buf.Bytes()
+}
+
+func TestMakeMappedResult_error(t *testing.T) {
+	withoutMetadata := []interface{}{
+		map[string]interface{}{"rows": []interface{}{[]interface{}{1}}}, nil,
+	}
+	_, err := decodeMappedResult(t, reflect.TypeOf(mappedRow{}), withoutMetadata)
+	require.Error(t, err)
+
+	withMetadata := []interface{}{
+		map[string]interface{}{
+			"metadata": []interface{}{map[string]interface{}{"name": "id"}},
+			"rows":     []interface{}{[]interface{}{1}},
+		}, nil,
+	}
+	_, err = decodeMappedResult(t, reflect.TypeOf(1), withMetadata)
+	require.Error(t, err)
+
+	_, err = decodeMappedResult(t, reflect.TypeOf(struct {
+		Id    uint64 `tarantool:"id"`
+		Other uint64 `msgpack:"id"`
+	}{}), withMetadata)
+	require.Error(t, err)
+}
+
+func TestMakeMappedResult_crudError(t *testing.T) {
+	response := []interface{}{
+		map[string]interface{}{
+			"metadata": []interface{}{map[string]interface{}{"name": "id"}},
+			"rows":     []interface{}{},
+		},
+		[]interface{}{
+			map[string]interface{}{
+				"class_name":     "InsertManyError",
+				"err":            "Duplicate key exists",
+				"operation_data": []interface{}{1},
+			},
+		},
+	}
+	_, err := decodeMappedResult(t, reflect.TypeOf(mappedRow{}), response)
+	require.Error(t, err)
+
+	var crudErr crud.ErrorMany
+	require.ErrorAs(t, err, &crudErr)
+	require.Len(t, crudErr.Errors, 1)
+	require.Equal(t, []interface{}{int8(1)}, crudErr.Errors[0].OperationData)
+}
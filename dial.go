@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
@@ -18,6 +19,7 @@ import (
 const (
 	dialTransportNone = ""
 	dialTransportSsl  = "ssl"
+	dialTransportTls  = "tls"
 )
 
 // Greeting is a message sent by Tarantool on connect.
@@ -63,6 +65,8 @@ type DialOpts struct {
 	Transport string
 	// Ssl configures "ssl" transport.
 	Ssl SslOpts
+	// Tls configures "tls" transport.
+	Tls *tls.Config
 	// RequiredProtocol contains minimal protocol version and
 	// list of protocol features that should be supported by
 	// Tarantool server. By default there are no restrictions.
@@ -199,20 +203,23 @@ func (c *tntConn) ProtocolInfo() ProtocolInfo {
 
 // dial connects to a Tarantool instance.
 func dial(ctx context.Context, address string, opts DialOpts) (net.Conn, error) {
-	network, address := parseAddress(address)
+	network, address := ParseAddress(address)
 	switch opts.Transport {
 	case dialTransportNone:
 		dialer := net.Dialer{}
 		return dialer.DialContext(ctx, network, address)
 	case dialTransportSsl:
 		return sslDialContext(ctx, network, address, opts.Ssl)
+	case dialTransportTls:
+		return tlsDialContext(ctx, network, address, opts.Tls)
 	default:
 		return nil, fmt.Errorf("unsupported transport type: %s", opts.Transport)
 	}
 }
 
-// parseAddress split address into network and address parts.
-func parseAddress(address string) (string, string) {
+// ParseAddress splits an address into network and address parts. The
+// address formats are described in Connect().
+func ParseAddress(address string) (string, string) {
 	network := "tcp"
 	addrLen := len(address)
 
@@ -353,7 +360,8 @@ func authenticate(c Conn, opts DialOpts, salt string) error {
 // writeRequest writes a request to the writer.
 func writeRequest(w writeFlusher, req Request) error {
 	var packet smallWBuf
-	err := pack(&packet, msgpack.NewEncoder(&packet), 0, req, ignoreStreamId, nil)
+	err := pack(&packet, msgpack.NewEncoder(&packet), 0, req, ignoreStreamId,
+		ignoreSchemaVersion, nil)
 
 	if err != nil {
 		return fmt.Errorf("pack error: %w", err)
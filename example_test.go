@@ -2,7 +2,10 @@ package tarantool_test
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
 	"time"
 
 	"github.com/tarantool/go-tarantool/v2"
@@ -48,6 +51,33 @@ func ExampleSslOpts() {
 	}
 }
 
+// Example demonstrates how to use TLS transport based on crypto/tls. It does
+// not require cgo and OpenSSL.
+func ExampleOpts_tls() {
+	ca, err := ioutil.ReadFile("testdata/ca.crt")
+	if err != nil {
+		panic("Unable to read CA file: " + err.Error())
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(ca)
+
+	var opts = tarantool.Opts{
+		User:      "test",
+		Pass:      "test",
+		Transport: "tls",
+		Tls: &tls.Config{
+			RootCAs:    roots,
+			ServerName: "localhost",
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	_, err = tarantool.Connect(ctx, "127.0.0.1:3013", opts)
+	if err != nil {
+		panic("Connection is not established: " + err.Error())
+	}
+}
+
 func ExampleIntKey() {
 	conn := exampleConnect(opts)
 	defer conn.Close()
@@ -626,12 +656,15 @@ func ExampleProtocolVersion() {
 		fmt.Println("Connector client protocol feature:", f)
 	}
 	// Output:
-	// Connector client protocol version: 4
+	// Connector client protocol version: 6
 	// Connector client protocol feature: StreamsFeature
 	// Connector client protocol feature: TransactionsFeature
 	// Connector client protocol feature: ErrorExtensionFeature
 	// Connector client protocol feature: WatchersFeature
 	// Connector client protocol feature: PaginationFeature
+	// Connector client protocol feature: SpaceAndIndexNamesFeature
+	// Connector client protocol feature: DMLTupleExtensionFeature
+	// Connector client protocol feature: CallRetTupleExtensionFeature
 }
 
 func getTestTxnOpts() tarantool.Opts {
@@ -882,6 +915,51 @@ func ExampleBeginRequest_TxnIsolation() {
 	fmt.Printf("Select after Rollback: response is %#v\n", resp.Data)
 }
 
+func ExampleRunInTransaction() {
+	// Tarantool supports streams and interactive transactions since version 2.10.0
+	isLess, err := test_helpers.IsTarantoolVersionLess(2, 10, 0)
+	if err != nil || isLess {
+		return
+	}
+
+	txnOpts := getTestTxnOpts()
+	conn := exampleConnect(txnOpts)
+	defer conn.Close()
+
+	opts := tarantool.TxnOpts{
+		Isolation: tarantool.ReadCommittedLevel,
+		Timeout:   500 * time.Millisecond,
+		Retries:   3,
+	}
+	err = tarantool.RunInTransaction(context.Background(), conn, opts,
+		func(stream *tarantool.Stream) error {
+			req := tarantool.NewInsertRequest(spaceName).
+				Tuple([]interface{}{uint(3001), "txn_hello", "txn_world"})
+			_, err := stream.Do(req).Get()
+			return err
+		})
+	if err != nil {
+		fmt.Printf("Failed to run a transaction: %s", err.Error())
+		return
+	}
+	defer conn.Do(tarantool.NewDeleteRequest(spaceName).
+		Key([]interface{}{uint(3001)})).Get()
+
+	selectReq := tarantool.NewSelectRequest(spaceNo).
+		Index(indexNo).
+		Limit(1).
+		Iterator(tarantool.IterEq).
+		Key([]interface{}{uint(3001)})
+	resp, err := conn.Do(selectReq).Get()
+	if err != nil {
+		fmt.Printf("Failed to Select: %s", err.Error())
+		return
+	}
+	fmt.Printf("Select after commit: %v\n", resp.Data)
+	// Output:
+	// Select after commit: [[3001 txn_hello txn_world]]
+}
+
 func ExampleFuture_GetIterator() {
 	conn := exampleConnect(opts)
 	defer conn.Close()
@@ -1230,3 +1308,43 @@ func ExampleConnection_CloseGraceful_force() {
 	// Result:
 	// <nil> connection closed by client (0x4001)
 }
+
+// ExampleTupleMapper demonstrates how to map structs to tuples by a space
+// format.
+func ExampleTupleMapper() {
+	conn := exampleConnect(opts)
+	defer conn.Close()
+
+	type Row struct {
+		Id    uint   `tarantool:"NAME0"`
+		Value string `tarantool:"NAME2"`
+		Name  string `tarantool:"NAME1"`
+	}
+
+	mapper, err := tarantool.NewTupleMapper(conn.Schema.Spaces["SQL_TEST"])
+	if err != nil {
+		fmt.Printf("Unable to create a mapper: %s\n", err)
+		return
+	}
+
+	row := Row{Id: 1111, Name: "hello", Value: "world"}
+	_, err = conn.Do(tarantool.NewReplaceRequest("SQL_TEST").
+		Tuple(mapper.Tuple(&row))).Get()
+	if err != nil {
+		fmt.Printf("Unable to replace: %s\n", err)
+		return
+	}
+
+	var rows []Row
+	err = conn.Do(tarantool.NewSelectRequest("SQL_TEST").
+		Key([]interface{}{uint(1111)})).GetTyped(mapper.Tuples(&rows))
+	if err != nil {
+		fmt.Printf("Unable to select: %s\n", err)
+		return
+	}
+	fmt.Printf("%+v\n", rows)
+
+	conn.Do(tarantool.NewDeleteRequest("SQL_TEST").Key([]interface{}{uint(1111)})).Get()
+	// Output:
+	// [{Id:1111 Value:world Name:hello}]
+}
@@ -2,6 +2,7 @@ package tarantool
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"time"
 
@@ -27,37 +28,38 @@ func RefImplPingBody(enc *msgpack.Encoder) error {
 // request's body.
 func RefImplSelectBody(enc *msgpack.Encoder, space, index, offset, limit uint32, iterator Iter,
 	key, after interface{}, fetchPos bool) error {
-	return fillSelect(enc, space, index, offset, limit, iterator, key, after, fetchPos)
+	return fillSelect(enc, spaceEncoder{id: space}, indexEncoder{id: index},
+		offset, limit, iterator, key, after, fetchPos)
 }
 
 // RefImplInsertBody is reference implementation for filling of an insert
 // request's body.
 func RefImplInsertBody(enc *msgpack.Encoder, space uint32, tuple interface{}) error {
-	return fillInsert(enc, space, tuple)
+	return fillInsert(enc, spaceEncoder{id: space}, tuple)
 }
 
 // RefImplReplaceBody is reference implementation for filling of a replace
 // request's body.
 func RefImplReplaceBody(enc *msgpack.Encoder, space uint32, tuple interface{}) error {
-	return fillInsert(enc, space, tuple)
+	return fillInsert(enc, spaceEncoder{id: space}, tuple)
 }
 
 // RefImplDeleteBody is reference implementation for filling of a delete
 // request's body.
 func RefImplDeleteBody(enc *msgpack.Encoder, space, index uint32, key interface{}) error {
-	return fillDelete(enc, space, index, key)
+	return fillDelete(enc, spaceEncoder{id: space}, indexEncoder{id: index}, key)
 }
 
 // RefImplUpdateBody is reference implementation for filling of an update
 // request's body.
 func RefImplUpdateBody(enc *msgpack.Encoder, space, index uint32, key, ops interface{}) error {
-	return fillUpdate(enc, space, index, key, ops)
+	return fillUpdate(enc, spaceEncoder{id: space}, indexEncoder{id: index}, key, ops)
 }
 
 // RefImplUpsertBody is reference implementation for filling of an upsert
 // request's body.
 func RefImplUpsertBody(enc *msgpack.Encoder, space uint32, tuple, ops interface{}) error {
-	return fillUpsert(enc, space, tuple, ops)
+	return fillUpsert(enc, spaceEncoder{id: space}, tuple, ops)
 }
 
 // RefImplCallBody is reference implementation for filling of a call or call17
@@ -120,3 +122,48 @@ func RefImplRollbackBody(enc *msgpack.Encoder) error {
 func RefImplIdBody(enc *msgpack.Encoder, protocolInfo ProtocolInfo) error {
 	return fillId(enc, protocolInfo)
 }
+
+// ResolveTuples replaces format identifiers of MP_TUPLE values with formats.
+func ResolveTuples(data []byte, formats map[uint64][]byte, unwrap bool) ([]byte, error) {
+	return resolveTuples(data, formats, unwrap)
+}
+
+// ExpireTimeouts finishes timed out requests of the connection as the timeouts
+// goroutine does on wake up.
+func ExpireTimeouts(conn *Connection) {
+	conn.expireTimeouts()
+}
+
+// DoneWaitersLen returns a count of waiters of the future added by WaitAny()
+// or WaitFirstSuccess().
+func DoneWaitersLen(fut *Future) int {
+	fut.mutex.Lock()
+	defer fut.mutex.Unlock()
+	return len(fut.doneWaiters)
+}
+
+// ScanTimeouts finishes timed out requests of the connection with a walk over
+// all request lists as the timeouts goroutine did before per-shard heaps. It
+// is kept to compare the approaches in benchmarks.
+func ScanTimeouts(conn *Connection) {
+	for i := range conn.shard {
+		nowepoch := time.Since(epoch)
+		shard := &conn.shard[i]
+		for pos := range shard.requests {
+			shard.rmut.Lock()
+			pair := &shard.requests[pos]
+			for pair.first != nil && pair.first.timeout > 0 &&
+				pair.first.timeout < nowepoch {
+				fut := conn.getFutureImp(pair.first.requestId, true)
+				err := ClientError{
+					Code: ErrTimeouted,
+					Msg:  fmt.Sprintf("client timeout for request %d", fut.requestId),
+				}
+				conn.observe(RequestTimedOut, fut, nil, err)
+				fut.SetError(err)
+				conn.markDone(fut)
+			}
+			shard.rmut.Unlock()
+		}
+	}
+}
@@ -1,21 +1,53 @@
 package tarantool
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
 	"sync"
 	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
 )
 
+// PushesBuffer is a size of a buffer of a channel returned by
+// Future.Pushes().
+const PushesBuffer = 16
+
+var errNoFutures = errors.New("no futures to wait")
+
 // Future is a handle for asynchronous request.
 type Future struct {
 	requestId uint32
 	next      *Future
-	timeout   time.Duration
+	// timeout is a time since epoch when the request is timed out.
+	timeout time.Duration
+	// respTimeout is a timeout for a response, the request has no timeout
+	// if it is zero.
+	respTimeout time.Duration
+	// heapIndex is an index of the future in the shard timeouts heap.
+	heapIndex int
 	mutex     sync.Mutex
 	pushes    []*Response
 	resp      *Response
 	err       error
 	ready     chan struct{}
 	done      chan struct{}
+	// req, streamId and schemaVersion are used to send the request again
+	// if it was packed with an outdated schema.
+	req           Request
+	streamId      uint64
+	schemaVersion uint64
+	// start is a time when the request was passed to the connection. It is
+	// set only if Opts.Observer is set.
+	start time.Time
+	// callbacks are called when the future is done.
+	callbacks []func()
+	// doneWaiters receive indexes of the future when it is done.
+	doneWaiters []doneWaiter
+	// pushWaiters are notified about new push messages.
+	pushWaiters []chan struct{}
 }
 
 func (fut *Future) wait() {
@@ -139,34 +171,143 @@ func (fut *Future) AppendPush(resp *Response) {
 	fut.pushes = append(fut.pushes, resp)
 
 	fut.ready <- struct{}{}
+	for _, waiter := range fut.pushWaiters {
+		select {
+		case waiter <- struct{}{}:
+		default:
+		}
+	}
 }
 
 // SetResponse sets a response for the future and finishes the future.
 func (fut *Future) SetResponse(resp *Response) {
-	fut.mutex.Lock()
-	defer fut.mutex.Unlock()
+	fut.setResponse(resp).call()
+}
 
+// SetError sets an error for the future and finishes the future.
+func (fut *Future) SetError(err error) {
+	fut.setError(err).call()
+}
+
+// setResponse sets a response for the future, finishes the future and
+// returns its callbacks.
+func (fut *Future) setResponse(resp *Response) doneCallbacks {
+	fut.mutex.Lock()
 	if fut.isDone() {
-		return
+		fut.mutex.Unlock()
+		return nil
 	}
 	fut.resp = resp
+	return fut.finish()
+}
+
+// setError sets an error for the future, finishes the future and returns
+// its callbacks.
+func (fut *Future) setError(err error) doneCallbacks {
+	fut.mutex.Lock()
+	if fut.isDone() {
+		fut.mutex.Unlock()
+		return nil
+	}
+	fut.err = err
+	return fut.finish()
+}
 
+// finish closes the channels, unlocks the future, notifies waiters and
+// returns callbacks.
+func (fut *Future) finish() doneCallbacks {
 	close(fut.ready)
 	close(fut.done)
+	callbacks, waiters := fut.callbacks, fut.doneWaiters
+	fut.callbacks, fut.doneWaiters = nil, nil
+	fut.mutex.Unlock()
+
+	for _, waiter := range waiters {
+		waiter.ch <- waiter.index
+	}
+	return callbacks
 }
 
-// SetError sets an error for the future and finishes the future.
-func (fut *Future) SetError(err error) {
+// doneCallbacks are callbacks of a done future.
+type doneCallbacks []func()
+
+// call calls the callbacks in order.
+func (callbacks doneCallbacks) call() {
+	for _, callback := range callbacks {
+		callback()
+	}
+}
+
+// OnDone sets a callback that is called with a result of Get() when
+// the future is done. The callback is called at once by the current
+// goroutine if the future is done already. Otherwise it is called by
+// a goroutine that finishes the future: usually the connection reader
+// goroutine, so the callback must not block. It could send new requests,
+// but it must not wait for a rate limit (see RLimitWait) or responses.
+//
+// Callbacks are called in order of the calls. Push messages do not trigger
+// the callback.
+func (fut *Future) OnDone(callback func(*Response, error)) {
+	fut.onDone(func() {
+		callback(fut.Get())
+	})
+}
+
+// onDone calls the function when the future is done.
+func (fut *Future) onDone(fn func()) {
 	fut.mutex.Lock()
-	defer fut.mutex.Unlock()
+	if !fut.isDone() {
+		fut.callbacks = append(fut.callbacks, fn)
+		fut.mutex.Unlock()
+		return
+	}
+	fut.mutex.Unlock()
+	fn()
+}
 
-	if fut.isDone() {
+// doneWaiter receives an index of a future when it is done.
+type doneWaiter struct {
+	ch    chan<- int
+	index int
+}
+
+// addDoneWaiter sends the index into the channel when the future is done.
+// The channel must have a space for the index.
+func (fut *Future) addDoneWaiter(ch chan<- int, index int) {
+	fut.mutex.Lock()
+	if !fut.isDone() {
+		fut.doneWaiters = append(fut.doneWaiters, doneWaiter{ch: ch, index: index})
+		fut.mutex.Unlock()
 		return
 	}
-	fut.err = err
+	fut.mutex.Unlock()
+	ch <- index
+}
 
-	close(fut.ready)
-	close(fut.done)
+// removeDoneWaiter removes waiters with the channel.
+func (fut *Future) removeDoneWaiter(ch chan<- int) {
+	fut.mutex.Lock()
+	defer fut.mutex.Unlock()
+	for i := 0; i < len(fut.doneWaiters); {
+		if fut.doneWaiters[i].ch == ch {
+			fut.doneWaiters = append(fut.doneWaiters[:i], fut.doneWaiters[i+1:]...)
+		} else {
+			i++
+		}
+	}
+}
+
+// failure returns an error of the done future. A successful response is not
+// decoded.
+func (fut *Future) failure() error {
+	if fut.err != nil {
+		return fut.err
+	}
+	if fut.resp != nil && fut.resp.Code != OkCode {
+		_, err := fut.Get()
+		return err
+	}
+	return nil
 }
 
 // Get waits for Future to be filled and returns Response and error.
@@ -200,6 +341,161 @@ func (fut *Future) GetTyped(result interface{}) error {
 	return err
 }
 
+// GetTupleIterator waits for Future and returns an iterator over tuples of
+// the response. The tuples are not decoded and copied in advance, so it is
+// the way to stream large responses. An error of the request is returned
+// by the iterator's Err().
+func (fut *Future) GetTupleIterator() *TupleIterator {
+	fut.wait()
+	if fut.err != nil {
+		return newTupleIterator(nil, fut.err)
+	}
+	return newTupleIterator(fut.resp.rawData())
+}
+
+// DecodeEach waits for Future and calls the function for each tuple of
+// the response with a decoder positioned at the beginning of the tuple.
+// The decoder is valid only during the call. It stops on the first error
+// and returns it.
+func (fut *Future) DecodeEach(fn func(d *msgpack.Decoder) error) error {
+	it := fut.GetTupleIterator()
+	for it.Next() {
+		if err := fn(it.Decoder()); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
+// Pushes returns a channel of push messages of the request. Push messages
+// contain deserialized result in Data field as for the Get() function.
+// The channel is closed when the future is done and all push messages are
+// received, when the context is done or if a push message could not be
+// decoded. Use Get() to get the response after the channel is closed.
+//
+// The connection reader never waits for a consumer of the channel: push
+// messages are stored in the future and sent into the channel by
+// a separate goroutine. So a slow consumer does not block other requests,
+// but unread push messages are kept in memory. The channel buffers up to
+// PushesBuffer messages. Each call returns a new channel with all push
+// messages from the beginning, but push messages are decoded in place, so
+// channels and iterators of the same future must not be used concurrently.
+//
+// # See also
+//
+//   - box.session.push():
+//     https://www.tarantool.io/en/doc/latest/reference/reference_lua/box_session/push/
+func (fut *Future) Pushes(ctx context.Context) <-chan *Response {
+	pushes := make(chan *Response, PushesBuffer)
+	go func() {
+		defer close(pushes)
+		fut.forwardPushes(ctx, func(push *Response) error {
+			if err := push.decodeBody(); err != nil {
+				return err
+			}
+			select {
+			case pushes <- push:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+	return pushes
+}
+
+// PushesTyped is like Pushes(), but decodes each push message into a new
+// value of the element type of the channel as GetTyped() does and sends
+// the value into the channel. The channel must be a bidirectional or
+// a send-only channel, it is closed by the future. The capacity of
+// the channel sets the buffer size.
+//
+// The returned channel receives an error if the argument is not a channel,
+// a push message could not be decoded or the context is done. It is closed
+// after the last push message.
+func (fut *Future) PushesTyped(ctx context.Context, channel interface{}) <-chan error {
+	errs := make(chan error, 1)
+
+	ch := reflect.ValueOf(channel)
+	if ch.Kind() != reflect.Chan || ch.Type().ChanDir()&reflect.SendDir == 0 {
+		errs <- fmt.Errorf("unable to send push messages into %T", channel)
+		close(errs)
+		return errs
+	}
+
+	elemType := ch.Type().Elem()
+	go func() {
+		defer close(errs)
+		defer ch.Close()
+		err := fut.forwardPushes(ctx, func(push *Response) error {
+			value := reflect.New(elemType)
+			if err := push.decodeBodyTyped(value.Interface()); err != nil {
+				return err
+			}
+			chosen, _, _ := reflect.Select([]reflect.SelectCase{
+				{Dir: reflect.SelectSend, Chan: ch, Send: value.Elem()},
+				{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+			})
+			if chosen != 0 {
+				return ctx.Err()
+			}
+			return nil
+		})
+		if err != nil {
+			errs <- err
+		}
+	}()
+	return errs
+}
+
+// forwardPushes calls the function for each push message of the future
+// until the future is done, the context is done or the function returns
+// an error.
+func (fut *Future) forwardPushes(ctx context.Context, fn func(*Response) error) error {
+	waiter := make(chan struct{}, 1)
+	fut.mutex.Lock()
+	fut.pushWaiters = append(fut.pushWaiters, waiter)
+	fut.mutex.Unlock()
+
+	defer func() {
+		fut.mutex.Lock()
+		for i, w := range fut.pushWaiters {
+			if w == waiter {
+				fut.pushWaiters = append(fut.pushWaiters[:i], fut.pushWaiters[i+1:]...)
+				break
+			}
+		}
+		fut.mutex.Unlock()
+	}()
+
+	for pos := 0; ; {
+		var push *Response
+		fut.mutex.Lock()
+		if pos < len(fut.pushes) {
+			push = fut.pushes[pos]
+			pos++
+		}
+		done := fut.isDone()
+		fut.mutex.Unlock()
+
+		if push != nil {
+			if err := fn(push); err != nil {
+				return err
+			}
+			continue
+		}
+		if done {
+			return nil
+		}
+		select {
+		case <-waiter:
+		case <-fut.WaitChan():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
 // GetIterator returns an iterator for iterating through push messages
 // and a response. Push messages and the response will contain deserialized
 // result in Data field as for the Get() function.
@@ -236,3 +532,85 @@ func (fut *Future) Err() error {
 	fut.wait()
 	return fut.err
 }
+
+// WaitAll waits for all the futures to be done. It returns an error of
+// the context if the context is done before.
+//
+// Errors of the futures are not checked, use Get() or GetTyped() to get
+// results.
+func WaitAll(ctx context.Context, futs []*Future) error {
+	for _, fut := range futs {
+		select {
+		case <-fut.WaitChan():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// WaitAny waits for any of the futures to be done and returns its index.
+// It returns -1 and an error of the context if the context is done before
+// or an error if there are no futures.
+//
+// It does not start a goroutine per future, but adds waiters to them. The
+// waiters are removed on return.
+func WaitAny(ctx context.Context, futs []*Future) (int, error) {
+	if len(futs) == 0 {
+		return -1, errNoFutures
+	}
+	for i, fut := range futs {
+		if fut.isDone() {
+			return i, nil
+		}
+	}
+
+	done, stop := waitDone(futs)
+	defer stop()
+	select {
+	case i := <-done:
+		return i, nil
+	case <-ctx.Done():
+		return -1, ctx.Err()
+	}
+}
+
+// WaitFirstSuccess waits for the first future done without an error and
+// returns its index. An error is a client error or an error returned by
+// Tarantool. If all the futures fail, it returns -1 and the error of
+// the last failed future. It returns -1 and an error of the context if
+// the context is done before or an error if there are no futures.
+func WaitFirstSuccess(ctx context.Context, futs []*Future) (int, error) {
+	if len(futs) == 0 {
+		return -1, errNoFutures
+	}
+
+	done, stop := waitDone(futs)
+	defer stop()
+	var err error
+	for range futs {
+		select {
+		case i := <-done:
+			if err = futs[i].failure(); err == nil {
+				return i, nil
+			}
+		case <-ctx.Done():
+			return -1, ctx.Err()
+		}
+	}
+	return -1, err
+}
+
+// waitDone returns a channel that receives indexes of the futures when they
+// are done and a function that stops the waiting.
+func waitDone(futs []*Future) (<-chan int, func()) {
+	done := make(chan int, len(futs))
+	for i, fut := range futs {
+		fut.addDoneWaiter(done, i)
+	}
+	return done, func() {
+		for _, fut := range futs {
+			fut.removeDoneWaiter(done)
+		}
+	}
+}
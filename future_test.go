@@ -1,11 +1,14 @@
 package tarantool_test
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
+
 	. "github.com/tarantool/go-tarantool/v2"
 )
 
@@ -256,3 +259,114 @@ func TestFutureSetStateRaceCondition(t *testing.T) {
 	// It may be false-positive, but very rarely - it's ok for such very
 	// simple race conditions tests.
 }
+
+func TestFuture_OnDone(t *testing.T) {
+	resp := &Response{}
+	fut := NewFuture()
+
+	var calls []string
+	fut.OnDone(func(r *Response, err error) {
+		assert.Equal(t, resp, r)
+		assert.NoError(t, err)
+		calls = append(calls, "first")
+	})
+	fut.OnDone(func(*Response, error) {
+		calls = append(calls, "second")
+	})
+	fut.AppendPush(&Response{})
+	assert.Empty(t, calls)
+
+	fut.SetResponse(resp)
+	assert.Equal(t, []string{"first", "second"}, calls)
+
+	// The future is done already.
+	fut.OnDone(func(r *Response, err error) {
+		assert.Equal(t, resp, r)
+		calls = append(calls, "third")
+	})
+	assert.Equal(t, []string{"first", "second", "third"}, calls)
+
+	// Callbacks are called once.
+	fut.SetError(errors.New("any error"))
+	assert.Len(t, calls, 3)
+}
+
+func TestFuture_OnDone_error(t *testing.T) {
+	fut := NewFuture()
+
+	done := make(chan error, 1)
+	fut.OnDone(func(r *Response, err error) {
+		done <- err
+	})
+	go fut.SetError(errors.New("any error"))
+
+	select {
+	case err := <-done:
+		assert.EqualError(t, err, "any error")
+	case <-time.After(5 * time.Second):
+		t.Fatalf("a callback is not called")
+	}
+}
+
+func TestWaitAll(t *testing.T) {
+	futs := []*Future{NewFuture(), NewFuture(), NewFuture()}
+	futs[0].SetResponse(&Response{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, WaitAll(ctx, futs))
+
+	go futs[1].SetError(errors.New("any error"))
+	go futs[2].SetResponse(&Response{})
+	assert.NoError(t, WaitAll(context.Background(), futs))
+	assert.NoError(t, WaitAll(context.Background(), nil))
+}
+
+func TestWaitAny(t *testing.T) {
+	futs := []*Future{NewFuture(), NewFuture(), NewFuture()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	i, err := WaitAny(ctx, futs)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, -1, i)
+	// Waiters are removed on return.
+	for _, fut := range futs {
+		assert.Zero(t, DoneWaitersLen(fut))
+	}
+
+	go futs[1].SetError(errors.New("any error"))
+	i, err = WaitAny(context.Background(), futs)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, i)
+	for _, fut := range futs {
+		assert.Zero(t, DoneWaitersLen(fut))
+	}
+
+	_, err = WaitAny(context.Background(), nil)
+	assert.Error(t, err)
+}
+
+func TestWaitFirstSuccess(t *testing.T) {
+	futs := []*Future{NewFuture(), NewFuture(), NewFuture()}
+
+	go func() {
+		futs[0].SetError(errors.New("first error"))
+		futs[2].SetResponse(&Response{})
+	}()
+	i, err := WaitFirstSuccess(context.Background(), futs)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, i)
+
+	futs[1].SetError(errors.New("second error"))
+	futs = futs[:2]
+	i, err = WaitFirstSuccess(context.Background(), futs)
+	assert.Equal(t, -1, i)
+	assert.Error(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	i, err = WaitFirstSuccess(ctx, []*Future{NewFuture()})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, -1, i)
+}
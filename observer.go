@@ -0,0 +1,127 @@
+package tarantool
+
+import (
+	"time"
+)
+
+// RequestEventKind is a kind of a request lifecycle event.
+type RequestEventKind int
+
+const (
+	// RequestSent signals that a request is packed into a connection buffer
+	// and will be written to the network. It could be signaled several times
+	// for the same request if the request is sent again after a schema
+	// reload.
+	RequestSent RequestEventKind = iota + 1
+	// ResponseReceived signals that a response for a request is received. It
+	// is the last event for a request.
+	ResponseReceived
+	// PushReceived signals that a push message for a request is received.
+	PushReceived
+	// RequestTimedOut signals that a request is finished by Opts.Timeout. It
+	// is the last event for a request.
+	RequestTimedOut
+	// RequestCanceled signals that a request is finished because its context
+	// is done. It is the last event for a request.
+	RequestCanceled
+	// RequestFailed signals that a request is finished with a client error:
+	// a connection is not ready or broken, the request is rate limited or
+	// could not be packed. It is the last event for a request.
+	RequestFailed
+)
+
+// String returns a name of the request event kind.
+func (kind RequestEventKind) String() string {
+	switch kind {
+	case RequestSent:
+		return "RequestSent"
+	case ResponseReceived:
+		return "ResponseReceived"
+	case PushReceived:
+		return "PushReceived"
+	case RequestTimedOut:
+		return "RequestTimedOut"
+	case RequestCanceled:
+		return "RequestCanceled"
+	case RequestFailed:
+		return "RequestFailed"
+	default:
+		return "Unknown"
+	}
+}
+
+// RequestEvent describes an event in a request lifecycle.
+type RequestEvent struct {
+	// Kind is a kind of the event.
+	Kind RequestEventKind
+	// Conn is a connection that processes the request.
+	Conn *Connection
+	// Request is the request object passed to the connection.
+	Request Request
+	// RequestId is an IPROTO_SYNC value of the request. It is zero if the
+	// request is rejected before an identifier is assigned.
+	RequestId uint32
+	// StreamId is a stream identifier of the request or zero if the request
+	// is sent out of a stream.
+	StreamId uint64
+	// Space is a space number or name of the request or nil if the request
+	// is not a space request.
+	Space interface{}
+	// Response is a received response for ResponseReceived or a push
+	// message for PushReceived events.
+	Response *Response
+	// Err is an error of the request. For ResponseReceived it contains an
+	// error returned by Tarantool.
+	Err error
+	// Start is a time when the request was passed to the connection.
+	Start time.Time
+	// When is a time of the event.
+	When time.Time
+}
+
+// Observer is an interface to observe requests lifecycle, it could be used
+// to collect metrics or to trace requests. It is expected to be passed in
+// options.
+//
+// Observe is called synchronously from the connection goroutines, sometimes
+// with internal locks held. So it should be fast and it must not send
+// requests through the connection.
+type Observer interface {
+	// Observe is called on each event in a request lifecycle.
+	Observe(event RequestEvent)
+}
+
+// spaceGetter is an interface for requests to a space.
+type spaceGetter interface {
+	getSpace() interface{}
+}
+
+// observe reports the request event if Opts.Observer is set.
+func (conn *Connection) observe(kind RequestEventKind, fut *Future,
+	resp *Response, err error) {
+	if conn.opts.Observer == nil {
+		return
+	}
+
+	event := RequestEvent{
+		Kind:      kind,
+		Conn:      conn,
+		Request:   fut.req,
+		RequestId: fut.requestId,
+		StreamId:  fut.streamId,
+		Response:  resp,
+		Err:       err,
+		Start:     fut.start,
+		When:      time.Now(),
+	}
+	if req, ok := fut.req.(spaceGetter); ok {
+		event.Space = req.getSpace()
+	}
+	if kind == ResponseReceived && resp.Code != OkCode {
+		// Decode a copy to avoid changes of the response.
+		errResp := *resp
+		event.Err = errResp.decodeBody()
+	}
+
+	conn.opts.Observer.Observe(event)
+}
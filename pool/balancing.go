@@ -0,0 +1,169 @@
+package pool
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/tarantool/go-tarantool/v2"
+)
+
+// latencyEwmaWeight is a weight of a new latency sample in an exponentially
+// weighted moving average.
+const latencyEwmaWeight = 0.3
+
+// BalancingStrategy is a set of connections to instances with the same role
+// that selects a connection for a next request.
+//
+// A ConnectionPool creates a separate strategy for RW, RO and all instances
+// with a BalancingStrategyFactory from Opts. Implementations must be safe for
+// concurrent use.
+type BalancingStrategy interface {
+	// AddConn adds a connection to the set or replaces a connection to
+	// the instance with the same name. The name is the address of the
+	// instance if Instance.Name is empty.
+	AddConn(name string, conn *tarantool.Connection)
+	// DeleteConnByName removes a connection to the instance from the set and
+	// returns it or nil if the connection is not found.
+	DeleteConnByName(name string) *tarantool.Connection
+	// GetConnByName returns a connection to the instance with the name or
+	// nil.
+	GetConnByName(name string) *tarantool.Connection
+	// GetConnections returns all connections from the set.
+	GetConnections() []*tarantool.Connection
+	// GetNextConnection returns a connection for a next request or nil if
+	// the set is empty.
+	GetNextConnection() *tarantool.Connection
+	// IsEmpty returns true if the set has no connections.
+	IsEmpty() bool
+}
+
+// LatencyObserver is an optional interface of a BalancingStrategy. The
+// ConnectionPool reports to it a round-trip time of a role check request
+// to an instance on every check.
+type LatencyObserver interface {
+	// ObserveLatency is called with a new round-trip time sample of the
+	// instance with the name.
+	ObserveLatency(name string, rtt time.Duration)
+}
+
+// BalancingStrategyFactory creates a new BalancingStrategy with an expected
+// number of connections.
+type BalancingStrategyFactory func(size int) BalancingStrategy
+
+// NewRoundRobinStrategy creates a strategy that selects connections one by
+// one in a loop. It is a default strategy.
+func NewRoundRobinStrategy(size int) BalancingStrategy {
+	return newRoundRobinStrategy(size)
+}
+
+// randomStrategy selects a random connection.
+type randomStrategy struct {
+	*roundRobinStrategy
+}
+
+// NewRandomStrategy creates a strategy that selects a random connection for
+// each request.
+func NewRandomStrategy(size int) BalancingStrategy {
+	return &randomStrategy{newRoundRobinStrategy(size)}
+}
+
+func (r *randomStrategy) GetNextConnection() *tarantool.Connection {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if r.size == 0 {
+		return nil
+	}
+	return r.conns[rand.Int63n(int64(r.size))]
+}
+
+// leastInFlightStrategy selects a connection with a minimal number of
+// requests in flight.
+type leastInFlightStrategy struct {
+	*roundRobinStrategy
+}
+
+// NewLeastInFlightStrategy creates a strategy that selects a connection with
+// a minimal number of requests in flight (see
+// tarantool.Connection.RequestsInFlight()). Connections with the same number
+// of requests are selected in a round-robin manner.
+func NewLeastInFlightStrategy(size int) BalancingStrategy {
+	return &leastInFlightStrategy{newRoundRobinStrategy(size)}
+}
+
+func (l *leastInFlightStrategy) GetNextConnection() *tarantool.Connection {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	if l.size == 0 {
+		return nil
+	}
+
+	var next *tarantool.Connection
+	var min int64
+	start := l.nextIndex()
+	for i := uint64(0); i < l.size; i++ {
+		conn := l.conns[(start+i)%l.size]
+		if cnt := conn.RequestsInFlight(); next == nil || cnt < min {
+			next, min = conn, cnt
+		}
+	}
+	return next
+}
+
+// latencyEwmaStrategy selects a connection with a minimal expected latency.
+type latencyEwmaStrategy struct {
+	*roundRobinStrategy
+	// latencies contains moving averages of round-trip times by names.
+	// They are not removed with connections since the pool moves connections
+	// between strategies on a role change.
+	latencies map[string]float64
+}
+
+// NewLatencyEwmaStrategy creates a strategy that selects a connection with
+// a minimal expected latency. The latency of an instance is an exponentially
+// weighted moving average of round-trip times of role check requests (see
+// Opts.CheckTimeout) multiplied by a number of requests in flight plus one,
+// so a fast instance does not get all the load. Connections without latency
+// samples are preferred.
+func NewLatencyEwmaStrategy(size int) BalancingStrategy {
+	return &latencyEwmaStrategy{
+		roundRobinStrategy: newRoundRobinStrategy(size),
+		latencies:          make(map[string]float64),
+	}
+}
+
+// ObserveLatency updates the moving average for the instance.
+func (l *latencyEwmaStrategy) ObserveLatency(name string, rtt time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	sample := float64(rtt)
+	if avg, ok := l.latencies[name]; ok {
+		l.latencies[name] = avg + latencyEwmaWeight*(sample-avg)
+	} else {
+		l.latencies[name] = sample
+	}
+}
+
+func (l *latencyEwmaStrategy) GetNextConnection() *tarantool.Connection {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	if l.size == 0 {
+		return nil
+	}
+
+	var next *tarantool.Connection
+	var min float64
+	start := l.nextIndex()
+	for i := uint64(0); i < l.size; i++ {
+		index := (start + i) % l.size
+		conn := l.conns[index]
+		cost := l.latencies[l.names[index]] * float64(conn.RequestsInFlight()+1)
+		if next == nil || cost < min {
+			next, min = conn, cost
+		}
+	}
+	return next
+}
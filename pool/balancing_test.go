@@ -0,0 +1,121 @@
+package pool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tarantool/go-tarantool/v2"
+)
+
+func newTestConns(strategy BalancingStrategy,
+	addrs ...string) map[string]*tarantool.Connection {
+	conns := make(map[string]*tarantool.Connection)
+	for _, addr := range addrs {
+		conns[addr] = &tarantool.Connection{}
+		strategy.AddConn(addr, conns[addr])
+	}
+	return conns
+}
+
+func TestBalancingStrategies_empty(t *testing.T) {
+	factories := map[string]BalancingStrategyFactory{
+		"round-robin":     NewRoundRobinStrategy,
+		"random":          NewRandomStrategy,
+		"least-in-flight": NewLeastInFlightStrategy,
+		"latency-ewma":    NewLatencyEwmaStrategy,
+	}
+
+	for name, factory := range factories {
+		t.Run(name, func(t *testing.T) {
+			strategy := factory(2)
+			assert.True(t, strategy.IsEmpty())
+			assert.Nil(t, strategy.GetNextConnection())
+
+			conns := newTestConns(strategy, validAddr1, validAddr2)
+			assert.False(t, strategy.IsEmpty())
+			assert.Len(t, strategy.GetConnections(), 2)
+			assert.Same(t, conns[validAddr1], strategy.GetConnByName(validAddr1))
+
+			for i := 0; i < 10; i++ {
+				next := strategy.GetNextConnection()
+				assert.True(t, next == conns[validAddr1] || next == conns[validAddr2])
+			}
+
+			assert.Same(t, conns[validAddr1], strategy.DeleteConnByName(validAddr1))
+			assert.Same(t, conns[validAddr2], strategy.DeleteConnByName(validAddr2))
+			assert.True(t, strategy.IsEmpty())
+			assert.Nil(t, strategy.GetNextConnection())
+		})
+	}
+}
+
+func TestRandomStrategy(t *testing.T) {
+	strategy := NewRandomStrategy(2)
+	conns := newTestConns(strategy, validAddr1, validAddr2)
+
+	selected := make(map[*tarantool.Connection]bool)
+	for i := 0; i < 1000; i++ {
+		selected[strategy.GetNextConnection()] = true
+	}
+	assert.Equal(t, map[*tarantool.Connection]bool{
+		conns[validAddr1]: true,
+		conns[validAddr2]: true,
+	}, selected)
+}
+
+func TestLeastInFlightStrategy_equal(t *testing.T) {
+	strategy := NewLeastInFlightStrategy(2)
+	conns := newTestConns(strategy, validAddr1, validAddr2)
+
+	// Connections with the same number of requests are selected one by one.
+	first := strategy.GetNextConnection()
+	second := strategy.GetNextConnection()
+	assert.NotSame(t, first, second)
+	assert.Same(t, first, strategy.GetNextConnection())
+	assert.Contains(t, []*tarantool.Connection{conns[validAddr1], conns[validAddr2]}, first)
+}
+
+func TestLatencyEwmaStrategy(t *testing.T) {
+	strategy := NewLatencyEwmaStrategy(3)
+	conns := newTestConns(strategy, "a", "b", "c")
+	observer, ok := strategy.(LatencyObserver)
+	require.True(t, ok)
+
+	observer.ObserveLatency("a", 30*time.Millisecond)
+	observer.ObserveLatency("b", 10*time.Millisecond)
+	observer.ObserveLatency("c", 20*time.Millisecond)
+	for i := 0; i < 5; i++ {
+		assert.Same(t, conns["b"], strategy.GetNextConnection())
+	}
+
+	// The average is moved to new samples.
+	observer.ObserveLatency("b", 100*time.Millisecond)
+	observer.ObserveLatency("b", 100*time.Millisecond)
+	assert.Same(t, conns["c"], strategy.GetNextConnection())
+
+	// The samples are kept after a connection is removed from the strategy,
+	// the pool moves connections between strategies on a role change.
+	strategy.DeleteConnByName("c")
+	strategy.AddConn("c", conns["c"])
+	assert.Same(t, conns["c"], strategy.GetNextConnection())
+
+	// Connections are shifted after a removal.
+	strategy.DeleteConnByName("a")
+	for i := 0; i < 20; i++ {
+		observer.ObserveLatency("b", time.Millisecond)
+	}
+	assert.Same(t, conns["b"], strategy.GetNextConnection())
+	observer.ObserveLatency("a", time.Nanosecond)
+	assert.Same(t, conns["b"], strategy.GetNextConnection())
+}
+
+func TestLatencyEwmaStrategy_withoutSamples(t *testing.T) {
+	strategy := NewLatencyEwmaStrategy(2)
+	conns := newTestConns(strategy, validAddr1, validAddr2)
+
+	strategy.(LatencyObserver).ObserveLatency(validAddr1, time.Millisecond)
+	assert.Same(t, conns[validAddr2], strategy.GetNextConnection())
+}
@@ -3,7 +3,8 @@
 //
 // Main features:
 //
-// - Return available connection from pool according to round-robin strategy.
+// - Return available connection from pool according to a balancing strategy
+// (round-robin by default).
 //
 // - Automatic master discovery by mode parameter.
 //
@@ -13,6 +14,7 @@ package pool
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"sync"
 	"time"
@@ -21,7 +23,8 @@ import (
 )
 
 var (
-	ErrEmptyAddrs        = errors.New("addrs (first argument) should not be empty")
+	ErrEmptyInstances    = errors.New("instances (second argument) should not be empty")
+	ErrEmptyAddr         = errors.New("address of an instance should not be empty")
 	ErrWrongCheckTimeout = errors.New("wrong check timeout, must be greater than 0")
 	ErrNoConnection      = errors.New("no active connections")
 	ErrTooManyArgs       = errors.New("too many arguments")
@@ -37,6 +40,9 @@ var (
 	ErrContextCanceled = errors.New("operation was canceled")
 )
 
+// boxStatusKey is a key of built-in events with an instance status.
+const boxStatusKey = "box.status"
+
 // ConnectionHandler provides callbacks for components interested in handling
 // changes of connections in a ConnectionPool.
 type ConnectionHandler interface {
@@ -62,14 +68,65 @@ type ConnectionHandler interface {
 	Deactivated(conn *tarantool.Connection, role Role) error
 }
 
+// Instance describes an instance in a ConnectionPool.
+type Instance struct {
+	// Name is a unique name of the instance in the pool. The address is used
+	// as the name if it is empty.
+	Name string
+	// Addr is an address of the instance.
+	Addr string
+	// Opts are options of connections to the instance.
+	Opts tarantool.Opts
+	// Labels are arbitrary attributes of the instance. The pool does not
+	// use them, they are returned by GetPoolInfo().
+	Labels map[string]string
+}
+
+// name returns the name of the instance in a pool.
+func (i Instance) name() string {
+	if i.Name != "" {
+		return i.Name
+	}
+	return i.Addr
+}
+
+// clone returns a copy of the instance that does not share options and
+// labels with the original one.
+func (i Instance) clone() Instance {
+	i.Opts = i.Opts.Clone()
+	if i.Labels != nil {
+		labels := make(map[string]string, len(i.Labels))
+		for k, v := range i.Labels {
+			labels[k] = v
+		}
+		i.Labels = labels
+	}
+	return i
+}
+
 // Opts provides additional options (configurable via ConnectWithOpts).
 type Opts struct {
 	// Timeout for timer to reopen connections that have been closed by some
 	// events and to relocate connection between subpools if ro/rw role has
 	// been updated.
+	//
+	// If an instance supports WatchersFeature, the pool subscribes to
+	// box.status events of the instance and relocates the connection right
+	// after a role change instead of polling box.info every CheckTimeout.
 	CheckTimeout time.Duration
 	// ConnectionHandler provides an ability to handle connection updates.
 	ConnectionHandler ConnectionHandler
+	// ReconnectPolicy controls pauses between reconnect attempts to an
+	// unavailable instance. If ReconnectPolicy is nil, an attempt is made
+	// every CheckTimeout. Otherwise, the first attempt is made right after a
+	// connection is closed (or on a check) and next attempts are made after
+	// pauses returned by the policy. If the policy gives it up, the pool
+	// stops to reconnect to the instance.
+	ReconnectPolicy tarantool.ReconnectPolicy
+	// BalancingStrategy creates strategies to select a connection for
+	// a request among instances with a requested role. If BalancingStrategy
+	// is nil, NewRoundRobinStrategy is used.
+	BalancingStrategy BalancingStrategyFactory
 }
 
 /*
@@ -78,53 +135,76 @@ ConnectionInfo structure for information about connection statuses:
 - ConnectedNow reports if connection is established at the moment.
 
 - ConnRole reports master/replica role of instance.
+
+- Instance is a description of the instance.
 */
 type ConnectionInfo struct {
 	ConnectedNow bool
 	ConnRole     Role
+	Instance     Instance
 }
 
 /*
 Main features:
 
-- Return available connection from pool according to round-robin strategy.
+- Return available connection from pool according to a balancing strategy
+(round-robin by default).
 
 - Automatic master discovery by mode parameter.
 */
 type ConnectionPool struct {
-	addrs      map[string]*endpoint
-	addrsMutex sync.RWMutex
+	// ends are endpoints of instances by names.
+	ends      map[string]*endpoint
+	endsMutex sync.RWMutex
 
-	connOpts tarantool.Opts
-	opts     Opts
+	opts Opts
 
 	state            state
 	done             chan struct{}
-	roPool           *roundRobinStrategy
-	rwPool           *roundRobinStrategy
-	anyPool          *roundRobinStrategy
+	roPool           BalancingStrategy
+	rwPool           BalancingStrategy
+	anyPool          BalancingStrategy
 	poolsMutex       sync.RWMutex
 	watcherContainer watcherContainer
 }
 
-var _ Pooler = (*ConnectionPool)(nil)
+var _ BatchPooler = (*ConnectionPool)(nil)
 
 type endpoint struct {
-	addr   string
-	notify chan tarantool.ConnEvent
-	conn   *tarantool.Connection
-	role   Role
+	name     string
+	instance Instance
+	notify   chan tarantool.ConnEvent
+	conn     *tarantool.Connection
+	role     Role
 	// This is used to switch a connection states.
 	shutdown chan struct{}
 	close    chan struct{}
 	closed   chan struct{}
 	cancel   context.CancelFunc
 	closeErr error
-}
-
-func newEndpoint(addr string) *endpoint {
+	// This is used to schedule reconnects with Opts.ReconnectPolicy.
+	reconnectAttempt uint
+	reconnectDelay   time.Duration
+	reconnectTimer   *time.Timer
+	reconnectStopped bool
+	// This is used to detect a role with box.status events.
+	status        chan statusEvent
+	statusConn    *tarantool.Connection
+	statusWatcher tarantool.Watcher
+}
+
+// statusEvent is a role of a connection from a box.status event.
+type statusEvent struct {
+	conn *tarantool.Connection
+	role Role
+	err  error
+}
+
+func newEndpoint(instance Instance) *endpoint {
+	instance = instance.clone()
 	return &endpoint{
-		addr:     addr,
+		name:     instance.name(),
+		instance: instance,
 		notify:   make(chan tarantool.ConnEvent, 100),
 		conn:     nil,
 		role:     UnknownRole,
@@ -132,38 +212,91 @@ func newEndpoint(addr string) *endpoint {
 		close:    make(chan struct{}),
 		closed:   make(chan struct{}),
 		cancel:   nil,
+		status:   make(chan statusEvent, 1),
 	}
 }
 
-// ConnectWithOpts creates pool for instances with addresses addrs
-// with options opts.
-func ConnectWithOpts(ctx context.Context, addrs []string,
-	connOpts tarantool.Opts, opts Opts) (*ConnectionPool, error) {
-	if len(addrs) == 0 {
-		return nil, ErrEmptyAddrs
+// pushStatus replaces a not yet processed status event with the new one.
+func (e *endpoint) pushStatus(event statusEvent) {
+	for {
+		select {
+		case e.status <- event:
+			return
+		default:
+		}
+		select {
+		case <-e.status:
+		default:
+		}
+	}
+}
+
+// connOpts returns options of a new connection to the instance.
+func (e *endpoint) connOpts() tarantool.Opts {
+	opts := e.instance.Opts
+	opts.Notify = e.notify
+	return opts
+}
+
+// watchersRequired returns true if WatchersFeature is required by connection
+// options of the instance.
+func (e *endpoint) watchersRequired() bool {
+	for _, feature := range e.instance.Opts.RequiredProtocolInfo.Features {
+		if tarantool.WatchersFeature == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// reconnectC returns a channel to wait for a scheduled reconnect attempt or
+// nil if there is no scheduled attempt.
+func (e *endpoint) reconnectC() <-chan time.Time {
+	if e.reconnectTimer == nil {
+		return nil
+	}
+	return e.reconnectTimer.C
+}
+
+// ConnectWithOpts creates pool for instances with options opts. Names of
+// the instances must be unique.
+func ConnectWithOpts(ctx context.Context, instances []Instance,
+	opts Opts) (*ConnectionPool, error) {
+	if len(instances) == 0 {
+		return nil, ErrEmptyInstances
 	}
 	if opts.CheckTimeout <= 0 {
 		return nil, ErrWrongCheckTimeout
 	}
+	ends := make(map[string]*endpoint, len(instances))
+	for _, instance := range instances {
+		if instance.Addr == "" {
+			return nil, ErrEmptyAddr
+		}
+		if _, ok := ends[instance.name()]; ok {
+			return nil, fmt.Errorf("duplicate instance name %q", instance.name())
+		}
+		ends[instance.name()] = newEndpoint(instance)
+	}
 
-	size := len(addrs)
-	rwPool := newRoundRobinStrategy(size)
-	roPool := newRoundRobinStrategy(size)
-	anyPool := newRoundRobinStrategy(size)
-
-	connPool := &ConnectionPool{
-		addrs:    make(map[string]*endpoint),
-		connOpts: connOpts.Clone(),
-		opts:     opts,
-		state:    unknownState,
-		done:     make(chan struct{}),
-		rwPool:   rwPool,
-		roPool:   roPool,
-		anyPool:  anyPool,
+	newStrategy := opts.BalancingStrategy
+	if newStrategy == nil {
+		newStrategy = NewRoundRobinStrategy
 	}
 
-	for _, addr := range addrs {
-		connPool.addrs[addr] = nil
+	size := len(instances)
+	rwPool := newStrategy(size)
+	roPool := newStrategy(size)
+	anyPool := newStrategy(size)
+
+	connPool := &ConnectionPool{
+		ends:    ends,
+		opts:    opts,
+		state:   unknownState,
+		done:    make(chan struct{}),
+		rwPool:  rwPool,
+		roPool:  roPool,
+		anyPool: anyPool,
 	}
 
 	somebodyAlive, ctxCanceled := connPool.fillPools(ctx)
@@ -177,7 +310,7 @@ func ConnectWithOpts(ctx context.Context, addrs []string,
 
 	connPool.state.set(connectedState)
 
-	for _, s := range connPool.addrs {
+	for _, s := range connPool.ends {
 		endpointCtx, cancel := context.WithCancel(context.Background())
 		s.cancel = cancel
 		go connPool.controller(endpointCtx, s)
@@ -186,17 +319,18 @@ func ConnectWithOpts(ctx context.Context, addrs []string,
 	return connPool, nil
 }
 
-// ConnectWithOpts creates pool for instances with addresses addrs.
+// Connect creates pool for instances. Names of the instances must be
+// unique.
 //
-// It is useless to set up tarantool.Opts.Reconnect value for a connection.
-// The connection pool has its own reconnection logic. See
-// Opts.CheckTimeout description.
-func Connect(ctx context.Context, addrs []string,
-	connOpts tarantool.Opts) (*ConnectionPool, error) {
+// It is useless to set up tarantool.Opts.Reconnect or
+// tarantool.Opts.ReconnectPolicy value for a connection. The connection pool
+// has its own reconnection logic. See Opts.CheckTimeout and
+// Opts.ReconnectPolicy description.
+func Connect(ctx context.Context, instances []Instance) (*ConnectionPool, error) {
 	opts := Opts{
 		CheckTimeout: 1 * time.Second,
 	}
-	return ConnectWithOpts(ctx, addrs, connOpts, opts)
+	return ConnectWithOpts(ctx, instances, opts)
 }
 
 // ConnectedNow gets connected status of pool.
@@ -233,32 +367,36 @@ func (p *ConnectionPool) ConfiguredTimeout(mode Mode) (time.Duration, error) {
 	return conn.ConfiguredTimeout(), nil
 }
 
-// Add adds a new endpoint with the address into the pool. This function
-// adds the endpoint only after successful connection.
-func (p *ConnectionPool) Add(ctx context.Context, addr string) error {
-	e := newEndpoint(addr)
+// Add adds a new instance into the pool. This function adds the instance
+// only after successful connection. It returns ErrExists if an instance with
+// the same name is already in the pool.
+func (p *ConnectionPool) Add(ctx context.Context, instance Instance) error {
+	if instance.Addr == "" {
+		return ErrEmptyAddr
+	}
+	e := newEndpoint(instance)
 
-	p.addrsMutex.Lock()
+	p.endsMutex.Lock()
 	// Ensure that Close()/CloseGraceful() not in progress/done.
 	if p.state.get() != connectedState {
-		p.addrsMutex.Unlock()
+		p.endsMutex.Unlock()
 		return ErrClosed
 	}
-	if _, ok := p.addrs[addr]; ok {
-		p.addrsMutex.Unlock()
+	if _, ok := p.ends[e.name]; ok {
+		p.endsMutex.Unlock()
 		return ErrExists
 	}
 
 	endpointCtx, cancel := context.WithCancel(context.Background())
 	e.cancel = cancel
 
-	p.addrs[addr] = e
-	p.addrsMutex.Unlock()
+	p.ends[e.name] = e
+	p.endsMutex.Unlock()
 
 	if err := p.tryConnect(ctx, e); err != nil {
-		p.addrsMutex.Lock()
-		delete(p.addrs, addr)
-		p.addrsMutex.Unlock()
+		p.endsMutex.Lock()
+		delete(p.ends, e.name)
+		p.endsMutex.Unlock()
 		e.cancel()
 		close(e.closed)
 		return err
@@ -268,13 +406,13 @@ func (p *ConnectionPool) Add(ctx context.Context, addr string) error {
 	return nil
 }
 
-// Remove removes an endpoint with the address from the pool. The call
-// closes an active connection gracefully.
-func (p *ConnectionPool) Remove(addr string) error {
-	p.addrsMutex.Lock()
-	endpoint, ok := p.addrs[addr]
+// Remove removes an instance with the name from the pool. The call closes
+// an active connection gracefully.
+func (p *ConnectionPool) Remove(name string) error {
+	p.endsMutex.Lock()
+	endpoint, ok := p.ends[name]
 	if !ok {
-		p.addrsMutex.Unlock()
+		p.endsMutex.Unlock()
 		return errors.New("endpoint not exist")
 	}
 
@@ -288,20 +426,20 @@ func (p *ConnectionPool) Remove(addr string) error {
 		close(endpoint.shutdown)
 	}
 
-	delete(p.addrs, addr)
-	p.addrsMutex.Unlock()
+	delete(p.ends, name)
+	p.endsMutex.Unlock()
 
 	<-endpoint.closed
 	return nil
 }
 
 func (p *ConnectionPool) waitClose() []error {
-	p.addrsMutex.RLock()
-	endpoints := make([]*endpoint, 0, len(p.addrs))
-	for _, e := range p.addrs {
+	p.endsMutex.RLock()
+	endpoints := make([]*endpoint, 0, len(p.ends))
+	for _, e := range p.ends {
 		endpoints = append(endpoints, e)
 	}
-	p.addrsMutex.RUnlock()
+	p.endsMutex.RUnlock()
 
 	errs := make([]error, 0, len(endpoints))
 	for _, e := range endpoints {
@@ -317,12 +455,12 @@ func (p *ConnectionPool) waitClose() []error {
 func (p *ConnectionPool) Close() []error {
 	if p.state.cas(connectedState, closedState) ||
 		p.state.cas(shutdownState, closedState) {
-		p.addrsMutex.RLock()
-		for _, s := range p.addrs {
+		p.endsMutex.RLock()
+		for _, s := range p.ends {
 			s.cancel()
 			close(s.close)
 		}
-		p.addrsMutex.RUnlock()
+		p.endsMutex.RUnlock()
 	}
 
 	return p.waitClose()
@@ -332,39 +470,40 @@ func (p *ConnectionPool) Close() []error {
 // for all requests to complete.
 func (p *ConnectionPool) CloseGraceful() []error {
 	if p.state.cas(connectedState, shutdownState) {
-		p.addrsMutex.RLock()
-		for _, s := range p.addrs {
+		p.endsMutex.RLock()
+		for _, s := range p.ends {
 			s.cancel()
 			close(s.shutdown)
 		}
-		p.addrsMutex.RUnlock()
+		p.endsMutex.RUnlock()
 	}
 
 	return p.waitClose()
 }
 
-// GetAddrs gets addresses of connections in pool.
+// GetAddrs gets addresses of instances in pool.
 func (p *ConnectionPool) GetAddrs() []string {
-	p.addrsMutex.RLock()
-	defer p.addrsMutex.RUnlock()
+	p.endsMutex.RLock()
+	defer p.endsMutex.RUnlock()
 
-	cpy := make([]string, len(p.addrs))
+	cpy := make([]string, len(p.ends))
 
 	i := 0
-	for addr := range p.addrs {
-		cpy[i] = addr
+	for _, e := range p.ends {
+		cpy[i] = e.instance.Addr
 		i++
 	}
 
 	return cpy
 }
 
-// GetPoolInfo gets information of connections (connected status, ro/rw role).
+// GetPoolInfo gets information of connections (connected status, ro/rw role)
+// by names of instances.
 func (p *ConnectionPool) GetPoolInfo() map[string]*ConnectionInfo {
 	info := make(map[string]*ConnectionInfo)
 
-	p.addrsMutex.RLock()
-	defer p.addrsMutex.RUnlock()
+	p.endsMutex.RLock()
+	defer p.endsMutex.RUnlock()
 	p.poolsMutex.RLock()
 	defer p.poolsMutex.RUnlock()
 
@@ -372,10 +511,14 @@ func (p *ConnectionPool) GetPoolInfo() map[string]*ConnectionInfo {
 		return info
 	}
 
-	for addr := range p.addrs {
-		conn, role := p.getConnectionFromPool(addr)
+	for name, e := range p.ends {
+		conn, role := p.getConnectionFromPool(name)
 		if conn != nil {
-			info[addr] = &ConnectionInfo{ConnectedNow: conn.ConnectedNow(), ConnRole: role}
+			info[name] = &ConnectionInfo{
+				ConnectedNow: conn.ConnectedNow(),
+				ConnRole:     role,
+				Instance:     e.instance.clone(),
+			}
 		}
 	}
 
@@ -930,14 +1073,7 @@ func (p *ConnectionPool) NewPrepared(expr string, userMode Mode) (*tarantool.Pre
 // Since 1.10.0
 func (p *ConnectionPool) NewWatcher(key string,
 	callback tarantool.WatchCallback, mode Mode) (tarantool.Watcher, error) {
-	watchersRequired := false
-	for _, feature := range p.connOpts.RequiredProtocolInfo.Features {
-		if tarantool.WatchersFeature == feature {
-			watchersRequired = true
-			break
-		}
-	}
-	if !watchersRequired {
+	if !p.watchersRequired() {
 		return nil, errors.New("the feature WatchersFeature must be " +
 			"required by connection options to create a watcher")
 	}
@@ -960,10 +1096,18 @@ func (p *ConnectionPool) NewWatcher(key string,
 		rr = p.roPool
 	}
 
-	conns := rr.GetConnections()
-	for _, conn := range conns {
-		if err := watcher.watch(conn); err != nil {
-			conn.Close()
+	p.endsMutex.RLock()
+	names := make([]string, 0, len(p.ends))
+	for name := range p.ends {
+		names = append(names, name)
+	}
+	p.endsMutex.RUnlock()
+
+	for _, name := range names {
+		if conn := rr.GetConnByName(name); conn != nil {
+			if err := watcher.watch(name, conn); err != nil {
+				conn.Close()
+			}
 		}
 	}
 
@@ -975,8 +1119,7 @@ func (p *ConnectionPool) NewWatcher(key string,
 // the argument of type Mode is unused.
 func (p *ConnectionPool) Do(req tarantool.Request, userMode Mode) *tarantool.Future {
 	if connectedReq, ok := req.(tarantool.ConnectedRequest); ok {
-		conn, _ := p.getConnectionFromPool(connectedReq.Conn().Addr())
-		if conn == nil {
+		if !p.hasConnection(connectedReq.Conn()) {
 			return newErrorFuture(ErrUnknownRequest)
 		}
 		return connectedReq.Conn().Do(req)
@@ -989,15 +1132,56 @@ func (p *ConnectionPool) Do(req tarantool.Request, userMode Mode) *tarantool.Fut
 	return conn.Do(req)
 }
 
+// DoBatch sends the requests through a single connection selected by the
+// mode and returns their futures in the order of the requests. See
+// tarantool.Connection.DoBatch(). Requests that belong to the only one
+// connection (e.g. Unprepare or ExecutePrepared) are sent through their
+// connections one by one.
+func (p *ConnectionPool) DoBatch(reqs []tarantool.Request,
+	userMode Mode) []*tarantool.Future {
+	futs := make([]*tarantool.Future, len(reqs))
+	batch := make([]tarantool.Request, 0, len(reqs))
+	for i, req := range reqs {
+		if _, ok := req.(tarantool.ConnectedRequest); ok {
+			futs[i] = p.Do(req, userMode)
+		} else {
+			batch = append(batch, req)
+		}
+	}
+	if len(batch) == 0 {
+		return futs
+	}
+
+	var batchFuts []*tarantool.Future
+	conn, err := p.getNextConnection(userMode)
+	if err == nil {
+		batchFuts = conn.DoBatch(batch)
+	}
+	for i := range futs {
+		if futs[i] != nil {
+			continue
+		}
+		if err != nil {
+			futs[i] = newErrorFuture(err)
+		} else {
+			futs[i], batchFuts = batchFuts[0], batchFuts[1:]
+		}
+	}
+	return futs
+}
+
 //
 // private
 //
 
-func (p *ConnectionPool) getConnectionRole(conn *tarantool.Connection) (Role, error) {
+func (p *ConnectionPool) getConnectionRole(name string,
+	conn *tarantool.Connection) (Role, error) {
+	start := time.Now()
 	resp, err := conn.Do(tarantool.NewCallRequest("box.info")).Get()
 	if err != nil {
 		return UnknownRole, err
 	}
+	p.observeLatency(name, time.Since(start))
 	if resp == nil {
 		return UnknownRole, ErrIncorrectResponse
 	}
@@ -1028,35 +1212,161 @@ func (p *ConnectionPool) getConnectionRole(conn *tarantool.Connection) (Role, er
 	return UnknownRole, nil
 }
 
-func (p *ConnectionPool) getConnectionFromPool(addr string) (*tarantool.Connection, Role) {
-	if conn := p.rwPool.GetConnByAddr(addr); conn != nil {
+// getStatusRole returns a role from a box.status event value.
+func getStatusRole(value interface{}) (Role, error) {
+	var status, isRo interface{}
+	switch value := value.(type) {
+	case map[string]interface{}:
+		status, isRo = value["status"], value["is_ro"]
+	case map[interface{}]interface{}:
+		status, isRo = value["status"], value["is_ro"]
+	default:
+		return UnknownRole, ErrIncorrectResponse
+	}
+	if status != "running" {
+		return UnknownRole, ErrIncorrectStatus
+	}
+
+	switch isRo {
+	case false:
+		return MasterRole, nil
+	case true:
+		return ReplicaRole, nil
+	}
+	return UnknownRole, ErrIncorrectResponse
+}
+
+// watchersRequired returns true if WatchersFeature is required by connection
+// options of all instances.
+func (p *ConnectionPool) watchersRequired() bool {
+	p.endsMutex.RLock()
+	defer p.endsMutex.RUnlock()
+
+	for _, e := range p.ends {
+		if !e.watchersRequired() {
+			return false
+		}
+	}
+	return true
+}
+
+// watchStatus subscribes to box.status events of the current endpoint
+// connection if it is not subscribed yet. The role of the connection is
+// polled with box.info if the instance does not support WatchersFeature or
+// the subscription fails.
+func (p *ConnectionPool) watchStatus(e *endpoint) {
+	if e.statusConn == e.conn {
+		return
+	}
+	p.unwatchStatus(e)
+
+	conn := e.conn
+	if conn == nil || conn.ClosedNow() || !watchersSupported(conn) {
+		return
+	}
+	e.statusConn = conn
+
+	watcher, err := conn.NewWatcher(boxStatusKey, func(event tarantool.WatchEvent) {
+		role, err := getStatusRole(event.Value)
+		e.pushStatus(statusEvent{conn: conn, role: role, err: err})
+	})
+	if err != nil {
+		log.Printf("tarantool: failed to watch %s on %s: %s\n", boxStatusKey, e.name, err)
+		return
+	}
+	e.statusWatcher = watcher
+}
+
+// watchersSupported returns true if the connected instance supports
+// WatchersFeature.
+func watchersSupported(conn *tarantool.Connection) bool {
+	for _, feature := range conn.ServerProtocolInfo().Features {
+		if tarantool.WatchersFeature == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// unwatchStatus unsubscribes from box.status events.
+func (p *ConnectionPool) unwatchStatus(e *endpoint) {
+	if e.statusWatcher != nil {
+		e.statusWatcher.Unregister()
+	}
+	e.statusConn = nil
+	e.statusWatcher = nil
+}
+
+func (p *ConnectionPool) observeLatency(addr string, rtt time.Duration) {
+	for _, strategy := range []BalancingStrategy{p.anyPool, p.rwPool, p.roPool} {
+		if observer, ok := strategy.(LatencyObserver); ok {
+			observer.ObserveLatency(addr, rtt)
+		}
+	}
+}
+
+// pingConnection measures a latency of the connection with a ping request if
+// a balancing strategy observes latencies. It is used instead of box.info
+// calls when a role is updated with box.status events.
+func (p *ConnectionPool) pingConnection(e *endpoint) {
+	observed := false
+	for _, strategy := range []BalancingStrategy{p.anyPool, p.rwPool, p.roPool} {
+		if _, ok := strategy.(LatencyObserver); ok {
+			observed = true
+			break
+		}
+	}
+	if !observed {
+		return
+	}
+
+	start := time.Now()
+	if _, err := e.conn.Do(tarantool.NewPingRequest()).Get(); err == nil {
+		p.observeLatency(e.name, time.Since(start))
+	}
+}
+
+// hasConnection returns true if the connection is in the pool. Connections
+// are compared instead of addresses since several instances could share
+// an address.
+func (p *ConnectionPool) hasConnection(conn *tarantool.Connection) bool {
+	for _, c := range p.anyPool.GetConnections() {
+		if c == conn {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *ConnectionPool) getConnectionFromPool(name string) (*tarantool.Connection, Role) {
+	if conn := p.rwPool.GetConnByName(name); conn != nil {
 		return conn, MasterRole
 	}
 
-	if conn := p.roPool.GetConnByAddr(addr); conn != nil {
+	if conn := p.roPool.GetConnByName(name); conn != nil {
 		return conn, ReplicaRole
 	}
 
-	return p.anyPool.GetConnByAddr(addr), UnknownRole
+	return p.anyPool.GetConnByName(name), UnknownRole
 }
 
-func (p *ConnectionPool) deleteConnection(addr string) {
-	if conn := p.anyPool.DeleteConnByAddr(addr); conn != nil {
-		if conn := p.rwPool.DeleteConnByAddr(addr); conn == nil {
-			p.roPool.DeleteConnByAddr(addr)
+func (p *ConnectionPool) deleteConnection(name string) {
+	if conn := p.anyPool.DeleteConnByName(name); conn != nil {
+		if conn := p.rwPool.DeleteConnByName(name); conn == nil {
+			p.roPool.DeleteConnByName(name)
 		}
 		// The internal connection deinitialization.
 		p.watcherContainer.mutex.RLock()
 		defer p.watcherContainer.mutex.RUnlock()
 
 		p.watcherContainer.foreach(func(watcher *poolWatcher) error {
-			watcher.unwatch(conn)
+			watcher.unwatch(name)
 			return nil
 		})
 	}
 }
 
-func (p *ConnectionPool) addConnection(addr string,
+func (p *ConnectionPool) addConnection(name string,
 	conn *tarantool.Connection, role Role) error {
 	// The internal connection initialization.
 	p.watcherContainer.mutex.RLock()
@@ -1074,7 +1384,7 @@ func (p *ConnectionPool) addConnection(addr string,
 			watch = true
 		}
 		if watch {
-			if err := watcher.watch(conn); err != nil {
+			if err := watcher.watch(name, conn); err != nil {
 				return err
 			}
 			watched = append(watched, watcher)
@@ -1083,19 +1393,19 @@ func (p *ConnectionPool) addConnection(addr string,
 	})
 	if err != nil {
 		for _, watcher := range watched {
-			watcher.unwatch(conn)
+			watcher.unwatch(name)
 		}
-		log.Printf("tarantool: failed initialize watchers for %s: %s", addr, err)
+		log.Printf("tarantool: failed initialize watchers for %s: %s", name, err)
 		return err
 	}
 
-	p.anyPool.AddConn(addr, conn)
+	p.anyPool.AddConn(name, conn)
 
 	switch role {
 	case MasterRole:
-		p.rwPool.AddConn(addr, conn)
+		p.rwPool.AddConn(name, conn)
 	case ReplicaRole:
-		p.roPool.AddConn(addr, conn)
+		p.roPool.AddConn(name, conn)
 	}
 	return nil
 }
@@ -1128,27 +1438,27 @@ func (p *ConnectionPool) handlerDeactivated(conn *tarantool.Connection,
 	}
 }
 
-func (p *ConnectionPool) deactivateConnection(addr string,
+func (p *ConnectionPool) deactivateConnection(name string,
 	conn *tarantool.Connection, role Role) {
-	p.deleteConnection(addr)
+	p.deleteConnection(name)
 	conn.Close()
 	p.handlerDeactivated(conn, role)
 }
 
 func (p *ConnectionPool) deactivateConnections() {
-	for address, endpoint := range p.addrs {
-		if endpoint != nil && endpoint.conn != nil {
-			p.deactivateConnection(address, endpoint.conn, endpoint.role)
+	for name, endpoint := range p.ends {
+		if endpoint.conn != nil {
+			p.deactivateConnection(name, endpoint.conn, endpoint.role)
 		}
 	}
 }
 
 func (p *ConnectionPool) processConnection(conn *tarantool.Connection,
-	addr string, end *endpoint) bool {
-	role, err := p.getConnectionRole(conn)
+	end *endpoint) bool {
+	role, err := p.getConnectionRole(end.name, conn)
 	if err != nil {
 		conn.Close()
-		log.Printf("tarantool: storing connection to %s failed: %s\n", addr, err)
+		log.Printf("tarantool: storing connection to %s failed: %s\n", end.name, err)
 		return false
 	}
 
@@ -1156,7 +1466,7 @@ func (p *ConnectionPool) processConnection(conn *tarantool.Connection,
 		conn.Close()
 		return false
 	}
-	if p.addConnection(addr, conn, role) != nil {
+	if p.addConnection(end.name, conn, role) != nil {
 		conn.Close()
 		p.handlerDeactivated(conn, role)
 		return false
@@ -1173,20 +1483,14 @@ func (p *ConnectionPool) fillPools(ctx context.Context) (bool, bool) {
 
 	// It is called before controller() goroutines so we don't expect
 	// concurrency issues here.
-	for addr := range p.addrs {
-		end := newEndpoint(addr)
-		p.addrs[addr] = end
-
-		connOpts := p.connOpts
-		connOpts.Notify = end.notify
-		conn, err := tarantool.Connect(ctx, addr, connOpts)
+	for _, end := range p.ends {
+		conn, err := tarantool.Connect(ctx, end.instance.Addr, end.connOpts())
 		if err != nil {
-			log.Printf("tarantool: connect to %s failed: %s\n", addr, err.Error())
+			log.Printf("tarantool: connect to %s failed: %s\n", end.name, err.Error())
 			select {
 			case <-ctx.Done():
 				ctxCanceled = true
 
-				p.addrs[addr] = nil
 				log.Printf("tarantool: operation was canceled")
 
 				p.deactivateConnections()
@@ -1194,7 +1498,7 @@ func (p *ConnectionPool) fillPools(ctx context.Context) (bool, bool) {
 				return false, ctxCanceled
 			default:
 			}
-		} else if p.processConnection(conn, addr, end) {
+		} else if p.processConnection(conn, end) {
 			somebodyAlive = true
 		}
 	}
@@ -1203,6 +1507,13 @@ func (p *ConnectionPool) fillPools(ctx context.Context) (bool, bool) {
 }
 
 func (p *ConnectionPool) updateConnection(e *endpoint) {
+	role, err := p.getConnectionRole(e.name, e.conn)
+	p.updateConnectionRole(e, role, err)
+}
+
+// updateConnectionRole relocates the connection between subpools if the role
+// has been updated or closes it on the role detection error.
+func (p *ConnectionPool) updateConnectionRole(e *endpoint, role Role, err error) {
 	p.poolsMutex.Lock()
 
 	if p.state.get() != connectedState {
@@ -1210,9 +1521,9 @@ func (p *ConnectionPool) updateConnection(e *endpoint) {
 		return
 	}
 
-	if role, err := p.getConnectionRole(e.conn); err == nil {
+	if err == nil {
 		if e.role != role {
-			p.deleteConnection(e.addr)
+			p.deleteConnection(e.name)
 			p.poolsMutex.Unlock()
 
 			p.handlerDeactivated(e.conn, e.role)
@@ -1235,7 +1546,7 @@ func (p *ConnectionPool) updateConnection(e *endpoint) {
 				return
 			}
 
-			if p.addConnection(e.addr, e.conn, role) != nil {
+			if p.addConnection(e.name, e.conn, role) != nil {
 				p.poolsMutex.Unlock()
 
 				e.conn.Close()
@@ -1249,7 +1560,7 @@ func (p *ConnectionPool) updateConnection(e *endpoint) {
 		p.poolsMutex.Unlock()
 		return
 	} else {
-		p.deleteConnection(e.addr)
+		p.deleteConnection(e.name)
 		p.poolsMutex.Unlock()
 
 		e.conn.Close()
@@ -1271,16 +1582,14 @@ func (p *ConnectionPool) tryConnect(ctx context.Context, e *endpoint) error {
 	e.conn = nil
 	e.role = UnknownRole
 
-	connOpts := p.connOpts
-	connOpts.Notify = e.notify
-	conn, err := tarantool.Connect(ctx, e.addr, connOpts)
+	conn, err := tarantool.Connect(ctx, e.instance.Addr, e.connOpts())
 	if err == nil {
-		role, err := p.getConnectionRole(conn)
+		role, err := p.getConnectionRole(e.name, conn)
 		p.poolsMutex.Unlock()
 
 		if err != nil {
 			conn.Close()
-			log.Printf("tarantool: storing connection to %s failed: %s\n", e.addr, err)
+			log.Printf("tarantool: storing connection to %s failed: %s\n", e.name, err)
 			return err
 		}
 
@@ -1298,7 +1607,7 @@ func (p *ConnectionPool) tryConnect(ctx context.Context, e *endpoint) error {
 			return ErrClosed
 		}
 
-		if err = p.addConnection(e.addr, conn, role); err != nil {
+		if err = p.addConnection(e.name, conn, role); err != nil {
 			p.poolsMutex.Unlock()
 			conn.Close()
 			p.handlerDeactivated(conn, role)
@@ -1312,27 +1621,65 @@ func (p *ConnectionPool) tryConnect(ctx context.Context, e *endpoint) error {
 	return err
 }
 
-func (p *ConnectionPool) reconnect(ctx context.Context, e *endpoint) {
+func (p *ConnectionPool) reconnect(ctx context.Context, e *endpoint) error {
 	p.poolsMutex.Lock()
 
 	if p.state.get() != connectedState {
 		p.poolsMutex.Unlock()
-		return
+		return ErrClosed
 	}
 
-	p.deleteConnection(e.addr)
+	p.deleteConnection(e.name)
 	p.poolsMutex.Unlock()
 
 	p.handlerDeactivated(e.conn, e.role)
 	e.conn = nil
 	e.role = UnknownRole
 
-	p.tryConnect(ctx, e)
+	return p.tryConnect(ctx, e)
+}
+
+// connect opens a connection to the endpoint. It schedules a next attempt
+// with Opts.ReconnectPolicy if the attempt failed.
+func (p *ConnectionPool) connect(ctx context.Context, e *endpoint) {
+	var err error
+	if e.conn == nil {
+		err = p.tryConnect(ctx, e)
+	} else {
+		err = p.reconnect(ctx, e)
+	}
+
+	if p.opts.ReconnectPolicy == nil {
+		return
+	}
+	if err == nil {
+		e.reconnectAttempt = 0
+		e.reconnectDelay = 0
+		return
+	}
+
+	e.reconnectAttempt++
+	delay, ok := p.opts.ReconnectPolicy.NextDelay(e.reconnectAttempt,
+		e.reconnectDelay, err)
+	if !ok {
+		e.reconnectStopped = true
+		log.Printf("tarantool: last reconnect to %s failed: %s, giving it up\n",
+			e.name, err)
+		return
+	}
+	e.reconnectDelay = delay
+	e.reconnectTimer = time.NewTimer(delay)
 }
 
 func (p *ConnectionPool) controller(ctx context.Context, e *endpoint) {
 	timer := time.NewTicker(p.opts.CheckTimeout)
 	defer timer.Stop()
+	defer func() {
+		if e.reconnectTimer != nil {
+			e.reconnectTimer.Stop()
+		}
+		p.unwatchStatus(e)
+	}()
 
 	shutdown := false
 	for {
@@ -1351,12 +1698,16 @@ func (p *ConnectionPool) controller(ctx context.Context, e *endpoint) {
 		default:
 		}
 
+		if !shutdown {
+			p.watchStatus(e)
+		}
+
 		select {
 		// e.close has priority to avoid concurrency with e.shutdown.
 		case <-e.close:
 			if e.conn != nil {
 				p.poolsMutex.Lock()
-				p.deleteConnection(e.addr)
+				p.deleteConnection(e.name)
 				p.poolsMutex.Unlock()
 
 				if !shutdown {
@@ -1378,7 +1729,7 @@ func (p *ConnectionPool) controller(ctx context.Context, e *endpoint) {
 				shutdown = true
 				if e.conn != nil {
 					p.poolsMutex.Lock()
-					p.deleteConnection(e.addr)
+					p.deleteConnection(e.name)
 					p.poolsMutex.Unlock()
 
 					// We need to catch s.close in the current goroutine, so
@@ -1400,11 +1751,15 @@ func (p *ConnectionPool) controller(ctx context.Context, e *endpoint) {
 					if e.conn != nil && e.conn.ClosedNow() {
 						p.poolsMutex.Lock()
 						if p.state.get() == connectedState {
-							p.deleteConnection(e.addr)
+							p.deleteConnection(e.name)
 							p.poolsMutex.Unlock()
 							p.handlerDeactivated(e.conn, e.role)
 							e.conn = nil
 							e.role = UnknownRole
+							if p.opts.ReconnectPolicy != nil &&
+								e.reconnectTimer == nil && !e.reconnectStopped {
+								p.connect(ctx, e)
+							}
 						} else {
 							p.poolsMutex.Unlock()
 						}
@@ -1413,12 +1768,24 @@ func (p *ConnectionPool) controller(ctx context.Context, e *endpoint) {
 					// Reopen connection.
 					// Relocate connection between subpools
 					// if ro/rw was updated.
-					if e.conn == nil {
-						p.tryConnect(ctx, e)
-					} else if !e.conn.ClosedNow() {
-						p.updateConnection(e)
-					} else {
-						p.reconnect(ctx, e)
+					if e.conn != nil && !e.conn.ClosedNow() {
+						if e.statusWatcher == nil {
+							p.updateConnection(e)
+						} else {
+							p.pingConnection(e)
+						}
+					} else if e.reconnectTimer == nil && !e.reconnectStopped {
+						p.connect(ctx, e)
+					}
+				case <-e.reconnectC():
+					e.reconnectTimer = nil
+					if e.conn == nil || e.conn.ClosedNow() {
+						p.connect(ctx, e)
+					}
+				case event := <-e.status:
+					// Events from previous connections are ignored.
+					if e.conn != nil && e.conn == event.conn && !e.conn.ClosedNow() {
+						p.updateConnectionRole(e, event.role, event.err)
 					}
 				}
 			}
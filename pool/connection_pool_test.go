@@ -19,6 +19,7 @@ import (
 	"github.com/tarantool/go-tarantool/v2"
 	"github.com/tarantool/go-tarantool/v2/pool"
 	"github.com/tarantool/go-tarantool/v2/test_helpers"
+	"github.com/tarantool/go-tarantool/v2/test_helpers/fakeserver"
 )
 
 var spaceNo = uint32(520)
@@ -46,23 +47,31 @@ var defaultTimeoutRetry = 500 * time.Millisecond
 
 var instances []test_helpers.TarantoolInstance
 
+func makeInstances(addrs []string, opts tarantool.Opts) []pool.Instance {
+	var instances []pool.Instance
+	for _, addr := range addrs {
+		instances = append(instances, pool.Instance{Addr: addr, Opts: opts})
+	}
+	return instances
+}
+
 func TestConnError_IncorrectParams(t *testing.T) {
 	ctx, cancel := test_helpers.GetPoolConnectContext()
-	connPool, err := pool.Connect(ctx, []string{}, tarantool.Opts{})
+	connPool, err := pool.Connect(ctx, makeInstances([]string{}, tarantool.Opts{}))
 	cancel()
 	require.Nilf(t, connPool, "conn is not nil with incorrect param")
 	require.NotNilf(t, err, "err is nil with incorrect params")
-	require.Equal(t, "addrs (first argument) should not be empty", err.Error())
+	require.Equal(t, "instances (second argument) should not be empty", err.Error())
 
 	ctx, cancel = test_helpers.GetPoolConnectContext()
-	connPool, err = pool.Connect(ctx, []string{"err1", "err2"}, connOpts)
+	connPool, err = pool.Connect(ctx, makeInstances([]string{"err1", "err2"}, connOpts))
 	cancel()
 	require.Nilf(t, connPool, "conn is not nil with incorrect param")
 	require.NotNilf(t, err, "err is nil with incorrect params")
 	require.Equal(t, "no active connections", err.Error())
 
 	ctx, cancel = test_helpers.GetPoolConnectContext()
-	connPool, err = pool.ConnectWithOpts(ctx, servers, tarantool.Opts{}, pool.Opts{})
+	connPool, err = pool.ConnectWithOpts(ctx, makeInstances(servers, tarantool.Opts{}), pool.Opts{})
 	cancel()
 	require.Nilf(t, connPool, "conn is not nil with incorrect param")
 	require.NotNilf(t, err, "err is nil with incorrect params")
@@ -73,7 +82,7 @@ func TestConnSuccessfully(t *testing.T) {
 	server := servers[0]
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, []string{"err", server}, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances([]string{"err", server}, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -108,7 +117,7 @@ func TestConnErrorAfterCtxCancel(t *testing.T) {
 	var err error
 
 	cancel()
-	connPool, err = pool.Connect(ctx, servers, connLongReconnectOpts)
+	connPool, err = pool.Connect(ctx, makeInstances(servers, connLongReconnectOpts))
 
 	if connPool != nil || err == nil {
 		t.Fatalf("ConnectionPool was created after cancel")
@@ -148,9 +157,9 @@ func TestContextCancelInProgress(t *testing.T) {
 
 	dialer := &mockClosingDialer{0, ctx, cancel}
 
-	connPool, err := pool.Connect(ctx, servers, tarantool.Opts{
+	connPool, err := pool.Connect(ctx, makeInstances(servers, tarantool.Opts{
 		Dialer: dialer,
-	})
+	}))
 	require.NotNilf(t, err, "expected err after ctx cancel")
 	assert.Truef(t, strings.Contains(err.Error(), "operation was canceled"),
 		fmt.Sprintf("unexpected error, expected to contain %s, got %v",
@@ -158,40 +167,73 @@ func TestContextCancelInProgress(t *testing.T) {
 	require.Nilf(t, connPool, "conn is not nil after ctx cancel")
 }
 
-func TestConnSuccessfullyDuplicates(t *testing.T) {
+func TestConnErrorDuplicates(t *testing.T) {
+	server := servers[0]
+	ctx, cancel := test_helpers.GetPoolConnectContext()
+	defer cancel()
+	connPool, err := pool.Connect(ctx, makeInstances([]string{server, server, server, server},
+		connOpts))
+	require.Nilf(t, connPool, "conn is not nil with duplicate instances")
+	require.EqualError(t, err, fmt.Sprintf("duplicate instance name %q", server))
+}
+
+func TestReconnect(t *testing.T) {
 	server := servers[0]
+
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, []string{server, server, server, server},
-		connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
 	defer connPool.Close()
 
+	test_helpers.StopTarantoolWithCleanup(instances[0])
+
 	args := test_helpers.CheckStatusesArgs{
 		ConnPool:           connPool,
 		Mode:               pool.ANY,
 		Servers:            []string{server},
 		ExpectedPoolStatus: true,
 		ExpectedStatuses: map[string]bool{
-			server: true,
+			server: false,
 		},
 	}
 
-	err = test_helpers.CheckPoolStatuses(args)
+	err = test_helpers.Retry(test_helpers.CheckPoolStatuses, args,
+		defaultCountRetry, defaultTimeoutRetry)
 	require.Nil(t, err)
 
-	addrs := connPool.GetAddrs()
-	require.Equalf(t, []string{server}, addrs, "should be only one address")
+	err = test_helpers.RestartTarantool(&instances[0])
+	require.Nilf(t, err, "failed to restart tarantool")
+
+	args = test_helpers.CheckStatusesArgs{
+		ConnPool:           connPool,
+		Mode:               pool.ANY,
+		Servers:            []string{server},
+		ExpectedPoolStatus: true,
+		ExpectedStatuses: map[string]bool{
+			server: true,
+		},
+	}
+
+	err = test_helpers.Retry(test_helpers.CheckPoolStatuses, args,
+		defaultCountRetry, defaultTimeoutRetry)
+	require.Nil(t, err)
 }
 
-func TestReconnect(t *testing.T) {
+func TestReconnect_withPolicy(t *testing.T) {
 	server := servers[0]
 
+	poolOpts := pool.Opts{
+		CheckTimeout: time.Hour,
+		ReconnectPolicy: tarantool.ConstantReconnectPolicy{
+			Delay: 100 * time.Millisecond,
+		},
+	}
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.ConnectWithOpts(ctx, makeInstances(servers, connOpts), poolOpts)
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -213,6 +255,7 @@ func TestReconnect(t *testing.T) {
 		defaultCountRetry, defaultTimeoutRetry)
 	require.Nil(t, err)
 
+	// CheckTimeout is too long, so the pool reconnects by the policy.
 	err = test_helpers.RestartTarantool(&instances[0])
 	require.Nilf(t, err, "failed to restart tarantool")
 
@@ -239,7 +282,7 @@ func TestDisconnect_withReconnect(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, []string{servers[serverId]}, opts)
+	connPool, err := pool.Connect(ctx, makeInstances([]string{servers[serverId]}, opts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -285,7 +328,7 @@ func TestDisconnectAll(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, []string{server1, server2}, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances([]string{server1, server2}, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -334,7 +377,7 @@ func TestDisconnectAll(t *testing.T) {
 func TestAdd(t *testing.T) {
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, []string{servers[0]}, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances([]string{servers[0]}, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -342,7 +385,7 @@ func TestAdd(t *testing.T) {
 
 	for _, server := range servers[1:] {
 		ctx, cancel := test_helpers.GetConnectContext()
-		err = connPool.Add(ctx, server)
+		err = connPool.Add(ctx, pool.Instance{Addr: server, Opts: connOpts})
 		cancel()
 		require.Nil(t, err)
 	}
@@ -368,7 +411,7 @@ func TestAdd(t *testing.T) {
 
 func TestAdd_exist(t *testing.T) {
 	ctx, cancel := test_helpers.GetPoolConnectContext()
-	connPool, err := pool.Connect(ctx, []string{servers[0]}, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances([]string{servers[0]}, connOpts))
 	cancel()
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
@@ -376,7 +419,7 @@ func TestAdd_exist(t *testing.T) {
 	defer connPool.Close()
 
 	ctx, cancel = test_helpers.GetConnectContext()
-	err = connPool.Add(ctx, servers[0])
+	err = connPool.Add(ctx, pool.Instance{Addr: servers[0], Opts: connOpts})
 	cancel()
 	require.Equal(t, pool.ErrExists, err)
 
@@ -397,7 +440,7 @@ func TestAdd_exist(t *testing.T) {
 
 func TestAdd_unreachable(t *testing.T) {
 	ctx, cancel := test_helpers.GetPoolConnectContext()
-	connPool, err := pool.Connect(ctx, []string{servers[0]}, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances([]string{servers[0]}, connOpts))
 	cancel()
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
@@ -405,7 +448,7 @@ func TestAdd_unreachable(t *testing.T) {
 	defer connPool.Close()
 
 	ctx, cancel = test_helpers.GetConnectContext()
-	err = connPool.Add(ctx, "127.0.0.2:6667")
+	err = connPool.Add(ctx, pool.Instance{Addr: "127.0.0.2:6667", Opts: connOpts})
 	cancel()
 	// The OS-dependent error so we just check for existence.
 	require.NotNil(t, err)
@@ -427,21 +470,21 @@ func TestAdd_unreachable(t *testing.T) {
 
 func TestAdd_afterClose(t *testing.T) {
 	ctx, cancel := test_helpers.GetPoolConnectContext()
-	connPool, err := pool.Connect(ctx, []string{servers[0]}, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances([]string{servers[0]}, connOpts))
 	cancel()
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
 	connPool.Close()
 	ctx, cancel = test_helpers.GetConnectContext()
-	err = connPool.Add(ctx, servers[0])
+	err = connPool.Add(ctx, pool.Instance{Addr: servers[0], Opts: connOpts})
 	cancel()
 	assert.Equal(t, err, pool.ErrClosed)
 }
 
 func TestAdd_Close_concurrent(t *testing.T) {
 	ctx, cancel := test_helpers.GetPoolConnectContext()
-	connPool, err := pool.Connect(ctx, []string{servers[0]}, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances([]string{servers[0]}, connOpts))
 	cancel()
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
@@ -452,7 +495,7 @@ func TestAdd_Close_concurrent(t *testing.T) {
 		defer wg.Done()
 
 		ctx, cancel := test_helpers.GetConnectContext()
-		err = connPool.Add(ctx, servers[1])
+		err = connPool.Add(ctx, pool.Instance{Addr: servers[1], Opts: connOpts})
 		cancel()
 		if err != nil {
 			assert.Equal(t, pool.ErrClosed, err)
@@ -466,7 +509,7 @@ func TestAdd_Close_concurrent(t *testing.T) {
 
 func TestAdd_CloseGraceful_concurrent(t *testing.T) {
 	ctx, cancel := test_helpers.GetPoolConnectContext()
-	connPool, err := pool.Connect(ctx, []string{servers[0]}, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances([]string{servers[0]}, connOpts))
 	cancel()
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
@@ -477,7 +520,7 @@ func TestAdd_CloseGraceful_concurrent(t *testing.T) {
 		defer wg.Done()
 
 		ctx, cancel := test_helpers.GetConnectContext()
-		err = connPool.Add(ctx, servers[1])
+		err = connPool.Add(ctx, pool.Instance{Addr: servers[1], Opts: connOpts})
 		cancel()
 		if err != nil {
 			assert.Equal(t, pool.ErrClosed, err)
@@ -492,7 +535,7 @@ func TestAdd_CloseGraceful_concurrent(t *testing.T) {
 func TestRemove(t *testing.T) {
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -521,7 +564,7 @@ func TestRemove(t *testing.T) {
 func TestRemove_double(t *testing.T) {
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, []string{servers[0], servers[1]}, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances([]string{servers[0], servers[1]}, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -550,7 +593,7 @@ func TestRemove_double(t *testing.T) {
 func TestRemove_unknown(t *testing.T) {
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, []string{servers[0], servers[1]}, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances([]string{servers[0], servers[1]}, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -578,7 +621,7 @@ func TestRemove_unknown(t *testing.T) {
 func TestRemove_concurrent(t *testing.T) {
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, []string{servers[0], servers[1]}, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances([]string{servers[0], servers[1]}, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -627,7 +670,7 @@ func TestRemove_concurrent(t *testing.T) {
 func TestRemove_Close_concurrent(t *testing.T) {
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, []string{servers[0], servers[1]}, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances([]string{servers[0], servers[1]}, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -648,7 +691,7 @@ func TestRemove_Close_concurrent(t *testing.T) {
 func TestRemove_CloseGraceful_concurrent(t *testing.T) {
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, []string{servers[0], servers[1]}, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances([]string{servers[0], servers[1]}, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -672,7 +715,7 @@ func TestClose(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, []string{server1, server2}, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances([]string{server1, server2}, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -714,7 +757,7 @@ func TestCloseGraceful(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, []string{server1, server2}, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances([]string{server1, server2}, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -855,7 +898,7 @@ func TestConnectionHandlerOpenUpdateClose(t *testing.T) {
 	}
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.ConnectWithOpts(ctx, poolServers, connOpts, poolOpts)
+	connPool, err := pool.ConnectWithOpts(ctx, makeInstances(poolServers, connOpts), poolOpts)
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -931,7 +974,7 @@ func TestConnectionHandlerOpenError(t *testing.T) {
 	}
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.ConnectWithOpts(ctx, poolServers, connOpts, poolOpts)
+	connPool, err := pool.ConnectWithOpts(ctx, makeInstances(poolServers, connOpts), poolOpts)
 	if err == nil {
 		defer connPool.Close()
 	}
@@ -975,7 +1018,7 @@ func TestConnectionHandlerUpdateError(t *testing.T) {
 	}
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.ConnectWithOpts(ctx, poolServers, connOpts, poolOpts)
+	connPool, err := pool.ConnectWithOpts(ctx, makeInstances(poolServers, connOpts), poolOpts)
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 	defer connPool.Close()
@@ -1022,7 +1065,7 @@ func TestRequestOnClosed(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, []string{server1, server2}, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances([]string{server1, server2}, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -1063,7 +1106,7 @@ func TestGetPoolInfo(t *testing.T) {
 	expected := []string{server1, server2}
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, srvs, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(srvs, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -1083,7 +1126,7 @@ func TestCall(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -1142,7 +1185,7 @@ func TestCall16(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -1201,7 +1244,7 @@ func TestCall17(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -1260,7 +1303,7 @@ func TestEval(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -1340,7 +1383,7 @@ func TestExecute(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -1399,7 +1442,7 @@ func TestRoundRobinStrategy(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -1478,7 +1521,7 @@ func TestRoundRobinStrategy_NoReplica(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -1551,7 +1594,7 @@ func TestRoundRobinStrategy_NoMaster(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -1636,7 +1679,7 @@ func TestUpdateInstancesRoles(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -1782,7 +1825,7 @@ func TestInsert(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -1883,7 +1926,7 @@ func TestDelete(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -1949,7 +1992,7 @@ func TestUpsert(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -2023,7 +2066,7 @@ func TestUpdate(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -2115,7 +2158,7 @@ func TestReplace(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -2203,7 +2246,7 @@ func TestSelect(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -2325,7 +2368,7 @@ func TestPing(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -2365,7 +2408,7 @@ func TestDo(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -2406,7 +2449,7 @@ func TestDo_concurrent(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -2439,7 +2482,7 @@ func TestNewPrepared(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -2509,7 +2552,7 @@ func TestDoWithStrangerConn(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 
@@ -2540,7 +2583,7 @@ func TestStream_Commit(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 	defer connPool.Close()
@@ -2641,7 +2684,7 @@ func TestStream_Rollback(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 	defer connPool.Close()
@@ -2742,7 +2785,7 @@ func TestStream_TxnIsolationLevel(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, connOpts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, connOpts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 	defer connPool.Close()
@@ -2838,7 +2881,7 @@ func TestConnectionPool_NewWatcher_noWatchersFeature(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, opts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, opts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 	defer connPool.Close()
@@ -2868,7 +2911,7 @@ func TestConnectionPool_NewWatcher_modes(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, opts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, opts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 	defer connPool.Close()
@@ -2952,7 +2995,7 @@ func TestConnectionPool_NewWatcher_update(t *testing.T) {
 	}
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	pool, err := pool.ConnectWithOpts(ctx, servers, opts, poolOpts)
+	pool, err := pool.ConnectWithOpts(ctx, makeInstances(servers, opts), poolOpts)
 
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, pool, "conn is nil after Connect")
@@ -3038,7 +3081,7 @@ func TestWatcher_Unregister(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	pool, err := pool.Connect(ctx, servers, opts)
+	pool, err := pool.Connect(ctx, makeInstances(servers, opts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, pool, "conn is nil after Connect")
 	defer pool.Close()
@@ -3082,6 +3125,45 @@ func TestWatcher_Unregister(t *testing.T) {
 	}
 }
 
+func TestConnectionPool_NewWatcher_sameAddr(t *testing.T) {
+	srv, err := fakeserver.Start(fakeserver.Opts{})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	const key = "TestConnectionPool_NewWatcher_sameAddr"
+
+	opts := connOpts.Clone()
+	opts.RequiredProtocolInfo.Features = []tarantool.ProtocolFeature{
+		tarantool.WatchersFeature,
+	}
+	ctx, cancel := test_helpers.GetPoolConnectContext()
+	defer cancel()
+	connPool, err := pool.Connect(ctx, []pool.Instance{
+		{Name: "first", Addr: srv.Addr(), Opts: opts},
+		{Name: "second", Addr: srv.Addr(), Opts: opts},
+	})
+	require.NoError(t, err)
+	defer connPool.Close()
+
+	events := make(chan tarantool.WatchEvent, 16)
+	watcher, err := connPool.NewWatcher(key, func(event tarantool.WatchEvent) {
+		events <- event
+	}, pool.ANY)
+	require.NoError(t, err)
+	defer watcher.Unregister()
+
+	// Instances with the same address are watched separately.
+	conns := map[*tarantool.Connection]bool{}
+	for len(conns) < 2 {
+		select {
+		case event := <-events:
+			conns[event.Conn] = true
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got events from %d connections", len(conns))
+		}
+	}
+}
+
 func TestConnectionPool_NewWatcher_concurrent(t *testing.T) {
 	test_helpers.SkipIfWatchersUnsupported(t)
 
@@ -3099,7 +3181,7 @@ func TestConnectionPool_NewWatcher_concurrent(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, opts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, opts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 	defer connPool.Close()
@@ -3141,7 +3223,7 @@ func TestWatcher_Unregister_concurrent(t *testing.T) {
 
 	ctx, cancel := test_helpers.GetPoolConnectContext()
 	defer cancel()
-	connPool, err := pool.Connect(ctx, servers, opts)
+	connPool, err := pool.Connect(ctx, makeInstances(servers, opts))
 	require.Nilf(t, err, "failed to connect")
 	require.NotNilf(t, connPool, "conn is nil after Connect")
 	defer connPool.Close()
@@ -3163,6 +3245,321 @@ func TestWatcher_Unregister_concurrent(t *testing.T) {
 	wg.Wait()
 }
 
+func TestNewSelectIterator(t *testing.T) {
+	srv, err := fakeserver.StartWithSpace(fakeserver.Opts{}, fakeserver.Space{
+		Id:   1000,
+		Name: "iterator",
+		Indexes: []fakeserver.Index{
+			{Name: "primary", Parts: []fakeserver.IndexPart{{Field: 0, Type: "unsigned"}}},
+		},
+	})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	ctx, cancel := test_helpers.GetPoolConnectContext()
+	defer cancel()
+	connPool, err := pool.Connect(ctx, makeInstances([]string{srv.Addr()}, connOpts))
+	require.NoError(t, err)
+	defer connPool.Close()
+
+	for i := 1; i <= 10; i++ {
+		_, err := connPool.Do(tarantool.NewInsertRequest(uint(1000)).
+			Tuple([]interface{}{uint(i)}), pool.RW).Get()
+		require.NoError(t, err)
+	}
+
+	it := pool.NewSelectIterator(connPool, pool.RW,
+		tarantool.NewSelectRequest(uint(1000))).PageSize(3)
+	ids := []uint{}
+	for it.Next() {
+		var tuple []uint
+		require.NoError(t, it.Scan(&tuple))
+		ids = append(ids, tuple[0])
+	}
+	require.NoError(t, it.Err())
+	require.Equal(t, []uint{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, ids)
+}
+
+func TestConnectionPool_DoBatch(t *testing.T) {
+	srv, err := fakeserver.StartWithSpace(fakeserver.Opts{}, fakeserver.Space{
+		Id:   1000,
+		Name: "batch",
+		Indexes: []fakeserver.Index{
+			{Name: "primary", Parts: []fakeserver.IndexPart{{Field: 0, Type: "unsigned"}}},
+		},
+	})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	ctx, cancel := test_helpers.GetPoolConnectContext()
+	defer cancel()
+	connPool, err := pool.Connect(ctx, makeInstances([]string{srv.Addr()}, connOpts))
+	require.NoError(t, err)
+	defer connPool.Close()
+
+	reqs := []tarantool.Request{}
+	for i := 1; i <= 10; i++ {
+		reqs = append(reqs, tarantool.NewInsertRequest(uint(1000)).
+			Tuple([]interface{}{uint(i)}))
+	}
+	reqs = append(reqs, tarantool.NewSelectRequest(uint(1000)).Limit(100))
+
+	futs := connPool.DoBatch(reqs, pool.RW)
+	require.Len(t, futs, len(reqs))
+	for _, fut := range futs[:10] {
+		_, err := fut.Get()
+		require.NoError(t, err)
+	}
+	var tuples [][]uint
+	require.NoError(t, futs[10].GetTyped(&tuples))
+	require.Len(t, tuples, 10)
+
+	futs = connPool.DoBatch(reqs[:1], pool.RO)
+	require.Len(t, futs, 1)
+	_, err = futs[0].Get()
+	require.Error(t, err)
+}
+
+func TestConnectionPool_WaitFirstSuccess(t *testing.T) {
+	srv, err := fakeserver.Start(fakeserver.Opts{})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	ctx, cancel := test_helpers.GetPoolConnectContext()
+	defer cancel()
+	connPool, err := pool.Connect(ctx, makeInstances([]string{srv.Addr()}, connOpts))
+	require.NoError(t, err)
+	defer connPool.Close()
+
+	futs := []*tarantool.Future{
+		// There is no read-only instance.
+		connPool.Do(tarantool.NewPingRequest(), pool.RO),
+		connPool.Do(tarantool.NewPingRequest(), pool.RW),
+	}
+	i, err := tarantool.WaitFirstSuccess(context.Background(), futs)
+	require.NoError(t, err)
+	assert.Equal(t, 1, i)
+
+	done := make(chan error, 1)
+	connPool.Do(tarantool.NewPingRequest(), pool.ANY).OnDone(
+		func(resp *tarantool.Response, err error) {
+			done <- err
+		})
+	require.NoError(t, <-done)
+}
+
+func TestConnectWithOpts_balancingStrategy(t *testing.T) {
+	release := make(chan struct{})
+	blocked := make(chan string, 1)
+
+	addrs := []string{}
+	for _, name := range []string{"first", "second"} {
+		name := name
+		srv, err := fakeserver.Start(fakeserver.Opts{})
+		require.NoError(t, err)
+		defer srv.Close()
+
+		srv.RegisterFunction("name", func(call fakeserver.Call) ([]interface{}, error) {
+			return []interface{}{name}, nil
+		})
+		srv.RegisterFunction("block", func(call fakeserver.Call) ([]interface{}, error) {
+			blocked <- name
+			<-release
+			return nil, nil
+		})
+		addrs = append(addrs, srv.Addr())
+	}
+
+	ctx, cancel := test_helpers.GetPoolConnectContext()
+	defer cancel()
+	connPool, err := pool.ConnectWithOpts(ctx, makeInstances(addrs, connOpts), pool.Opts{
+		CheckTimeout:      time.Second,
+		BalancingStrategy: pool.NewLeastInFlightStrategy,
+	})
+	require.NoError(t, err)
+	defer connPool.Close()
+
+	fut := connPool.Do(tarantool.NewCallRequest("block"), pool.ANY)
+	busy := <-blocked
+	defer func() {
+		close(release)
+		_, err := fut.Get()
+		require.NoError(t, err)
+	}()
+
+	for i := 0; i < 5; i++ {
+		var name []string
+		err := connPool.Do(tarantool.NewCallRequest("name"), pool.ANY).GetTyped(&name)
+		require.NoError(t, err)
+		require.NotEqual(t, []string{busy}, name)
+	}
+}
+
+func waitConnRole(t *testing.T, connPool *pool.ConnectionPool, addr string,
+	role pool.Role) {
+	t.Helper()
+
+	for i := 0; i < 100; i++ {
+		if info, ok := connPool.GetPoolInfo()[addr]; ok && info.ConnRole == role {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Connection to %s has not got the role %v", addr, role)
+}
+
+func TestConnectWithOpts_statusWatcher(t *testing.T) {
+	srv, err := fakeserver.Start(fakeserver.Opts{})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	ctx, cancel := test_helpers.GetPoolConnectContext()
+	defer cancel()
+	// WatchersFeature is not required, but the server supports it, so the
+	// role is not polled with the check timeout.
+	instances := makeInstances([]string{srv.Addr()}, connOpts)
+	connPool, err := pool.ConnectWithOpts(ctx, instances, pool.Opts{
+		CheckTimeout: time.Hour,
+	})
+	require.NoError(t, err)
+	defer connPool.Close()
+
+	waitConnRole(t, connPool, srv.Addr(), pool.MasterRole)
+
+	srv.SetReadOnly(true)
+	waitConnRole(t, connPool, srv.Addr(), pool.ReplicaRole)
+	_, err = connPool.Do(tarantool.NewPingRequest(), pool.RO).Get()
+	require.NoError(t, err)
+
+	srv.SetReadOnly(false)
+	waitConnRole(t, connPool, srv.Addr(), pool.MasterRole)
+	_, err = connPool.Do(tarantool.NewPingRequest(), pool.RW).Get()
+	require.NoError(t, err)
+}
+
+func TestConnectWithOpts_statusPolling(t *testing.T) {
+	srv, err := fakeserver.Start(fakeserver.Opts{
+		ProtocolInfo: tarantool.ProtocolInfo{
+			Auth:     tarantool.ChapSha1Auth,
+			Version:  tarantool.ProtocolVersion(6),
+			Features: []tarantool.ProtocolFeature{tarantool.StreamsFeature},
+		},
+	})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	ctx, cancel := test_helpers.GetPoolConnectContext()
+	defer cancel()
+	// The server does not support WatchersFeature, so the role is polled.
+	instances := makeInstances([]string{srv.Addr()}, connOpts)
+	connPool, err := pool.ConnectWithOpts(ctx, instances, pool.Opts{
+		CheckTimeout: 50 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer connPool.Close()
+
+	waitConnRole(t, connPool, srv.Addr(), pool.MasterRole)
+
+	srv.SetReadOnly(true)
+	waitConnRole(t, connPool, srv.Addr(), pool.ReplicaRole)
+}
+
+func TestConnect_instances(t *testing.T) {
+	master, err := fakeserver.Start(fakeserver.Opts{User: "admin", Pass: "secret"})
+	require.NoError(t, err)
+	defer master.Close()
+
+	replica, err := fakeserver.Start(fakeserver.Opts{
+		User:     "reader",
+		Pass:     "reader",
+		ReadOnly: true,
+	})
+	require.NoError(t, err)
+	defer replica.Close()
+
+	ctx, cancel := test_helpers.GetPoolConnectContext()
+	defer cancel()
+	connPool, err := pool.Connect(ctx, []pool.Instance{
+		{
+			Name:   "master",
+			Addr:   master.Addr(),
+			Opts:   tarantool.Opts{Timeout: 5 * time.Second, User: "admin", Pass: "secret"},
+			Labels: map[string]string{"dc": "local"},
+		},
+		{
+			Addr: replica.Addr(),
+			Opts: tarantool.Opts{Timeout: 5 * time.Second, User: "reader", Pass: "reader"},
+		},
+	})
+	require.NoError(t, err)
+	defer connPool.Close()
+
+	info := connPool.GetPoolInfo()
+	require.Len(t, info, 2)
+	require.Contains(t, info, "master")
+	assert.True(t, info["master"].ConnectedNow)
+	assert.Equal(t, pool.MasterRole, info["master"].ConnRole)
+	assert.Equal(t, master.Addr(), info["master"].Instance.Addr)
+	assert.Equal(t, "admin", info["master"].Instance.Opts.User)
+	assert.Equal(t, map[string]string{"dc": "local"}, info["master"].Instance.Labels)
+	// The address is the name by default.
+	require.Contains(t, info, replica.Addr())
+	assert.Equal(t, pool.ReplicaRole, info[replica.Addr()].ConnRole)
+	assert.Equal(t, "reader", info[replica.Addr()].Instance.Opts.User)
+
+	// The returned description is a copy.
+	info["master"].Instance.Labels["dc"] = "remote"
+	assert.Equal(t, "local", connPool.GetPoolInfo()["master"].Instance.Labels["dc"])
+
+	// An instance could be added with the same address but another name.
+	err = connPool.Add(ctx, pool.Instance{
+		Name: "master-reader",
+		Addr: master.Addr(),
+		Opts: tarantool.Opts{Timeout: 5 * time.Second, User: "admin", Pass: "secret"},
+	})
+	require.NoError(t, err)
+	assert.Len(t, connPool.GetPoolInfo(), 3)
+
+	err = connPool.Add(ctx, pool.Instance{Name: "master-reader", Addr: master.Addr()})
+	assert.Equal(t, pool.ErrExists, err)
+	err = connPool.Add(ctx, pool.Instance{Name: "empty"})
+	assert.Equal(t, pool.ErrEmptyAddr, err)
+
+	// Invalid credentials of an instance.
+	err = connPool.Add(ctx, pool.Instance{
+		Name: "invalid",
+		Addr: replica.Addr(),
+		Opts: tarantool.Opts{Timeout: 5 * time.Second, User: "admin", Pass: "secret"},
+	})
+	assert.Error(t, err)
+
+	require.NoError(t, connPool.Remove("master-reader"))
+	info = connPool.GetPoolInfo()
+	assert.Len(t, info, 2)
+	assert.NotContains(t, info, "master-reader")
+
+	_, err = connPool.Do(tarantool.NewPingRequest(), pool.RO).Get()
+	assert.NoError(t, err)
+	_, err = connPool.Do(tarantool.NewPingRequest(), pool.RW).Get()
+	assert.NoError(t, err)
+}
+
+func TestConnect_instancesError(t *testing.T) {
+	ctx, cancel := test_helpers.GetPoolConnectContext()
+	defer cancel()
+
+	connPool, err := pool.Connect(ctx, []pool.Instance{{Name: "empty"}})
+	assert.Nil(t, connPool)
+	assert.Equal(t, pool.ErrEmptyAddr, err)
+
+	connPool, err = pool.Connect(ctx, []pool.Instance{
+		{Name: "instance", Addr: "127.0.0.1:3301"},
+		{Name: "instance", Addr: "127.0.0.1:3302"},
+	})
+	assert.Nil(t, connPool)
+	assert.EqualError(t, err, `duplicate instance name "instance"`)
+}
+
 // runTestMain is a body of TestMain function
 // (see https://pkg.go.dev/testing#hdr-Main).
 // Using defer + os.Exit is not works so TestMain body
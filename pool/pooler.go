@@ -6,6 +6,15 @@ import (
 	"github.com/tarantool/go-tarantool/v2"
 )
 
+// BatchPooler is the interface of a connection pool that could send many
+// requests at once. It is not a part of Pooler to keep existing
+// implementations of Pooler valid.
+type BatchPooler interface {
+	Pooler
+
+	DoBatch(reqs []tarantool.Request, mode Mode) []*tarantool.Future
+}
+
 // Pooler is the interface that must be implemented by a connection pool.
 type Pooler interface {
 	ConnectedNow(mode Mode) (bool, error)
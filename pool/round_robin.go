@@ -8,8 +8,11 @@ import (
 )
 
 type roundRobinStrategy struct {
-	conns       []*tarantool.Connection
-	indexByAddr map[string]uint
+	conns []*tarantool.Connection
+	// names contains names of instances of the connections with the same
+	// indexes.
+	names       []string
+	indexByName map[string]uint
 	mutex       sync.RWMutex
 	size        uint64
 	current     uint64
@@ -18,17 +21,18 @@ type roundRobinStrategy struct {
 func newRoundRobinStrategy(size int) *roundRobinStrategy {
 	return &roundRobinStrategy{
 		conns:       make([]*tarantool.Connection, 0, size),
-		indexByAddr: make(map[string]uint),
+		names:       make([]string, 0, size),
+		indexByName: make(map[string]uint),
 		size:        0,
 		current:     0,
 	}
 }
 
-func (r *roundRobinStrategy) GetConnByAddr(addr string) *tarantool.Connection {
+func (r *roundRobinStrategy) GetConnByName(name string) *tarantool.Connection {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	index, found := r.indexByAddr[addr]
+	index, found := r.indexByName[name]
 	if !found {
 		return nil
 	}
@@ -36,7 +40,7 @@ func (r *roundRobinStrategy) GetConnByAddr(addr string) *tarantool.Connection {
 	return r.conns[index]
 }
 
-func (r *roundRobinStrategy) DeleteConnByAddr(addr string) *tarantool.Connection {
+func (r *roundRobinStrategy) DeleteConnByName(name string) *tarantool.Connection {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
@@ -44,20 +48,21 @@ func (r *roundRobinStrategy) DeleteConnByAddr(addr string) *tarantool.Connection
 		return nil
 	}
 
-	index, found := r.indexByAddr[addr]
+	index, found := r.indexByName[name]
 	if !found {
 		return nil
 	}
 
-	delete(r.indexByAddr, addr)
+	delete(r.indexByName, name)
 
 	conn := r.conns[index]
 	r.conns = append(r.conns[:index], r.conns[index+1:]...)
+	r.names = append(r.names[:index], r.names[index+1:]...)
 	r.size -= 1
 
-	for k, v := range r.indexByAddr {
+	for k, v := range r.indexByName {
 		if v > index {
-			r.indexByAddr[k] = v - 1
+			r.indexByName[k] = v - 1
 		}
 	}
 
@@ -91,15 +96,16 @@ func (r *roundRobinStrategy) GetConnections() []*tarantool.Connection {
 	return ret
 }
 
-func (r *roundRobinStrategy) AddConn(addr string, conn *tarantool.Connection) {
+func (r *roundRobinStrategy) AddConn(name string, conn *tarantool.Connection) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if idx, ok := r.indexByAddr[addr]; ok {
+	if idx, ok := r.indexByName[name]; ok {
 		r.conns[idx] = conn
 	} else {
 		r.conns = append(r.conns, conn)
-		r.indexByAddr[addr] = uint(r.size)
+		r.names = append(r.names, name)
+		r.indexByName[name] = uint(r.size)
 		r.size += 1
 	}
 }
@@ -22,7 +22,7 @@ func TestRoundRobinAddDelete(t *testing.T) {
 	}
 
 	for i, addr := range addrs {
-		if conn := rr.DeleteConnByAddr(addr); conn != conns[i] {
+		if conn := rr.DeleteConnByName(addr); conn != conns[i] {
 			t.Errorf("Unexpected connection on address %s", addr)
 		}
 	}
@@ -40,13 +40,13 @@ func TestRoundRobinAddDuplicateDelete(t *testing.T) {
 	rr.AddConn(validAddr1, conn1)
 	rr.AddConn(validAddr1, conn2)
 
-	if rr.DeleteConnByAddr(validAddr1) != conn2 {
+	if rr.DeleteConnByName(validAddr1) != conn2 {
 		t.Errorf("Unexpected deleted connection")
 	}
 	if !rr.IsEmpty() {
 		t.Errorf("RoundRobin does not empty")
 	}
-	if rr.DeleteConnByAddr(validAddr1) != nil {
+	if rr.DeleteConnByName(validAddr1) != nil {
 		t.Errorf("Unexpected value after second deletion")
 	}
 }
@@ -0,0 +1,13 @@
+package pool
+
+import (
+	"github.com/tarantool/go-tarantool/v2"
+)
+
+// NewSelectIterator creates a new iterator over results of the select
+// request executed on instances of the pool with the mode. Pages could be
+// fetched from different instances with ANY, PREFER_RO and PREFER_RW modes.
+func NewSelectIterator(pooler Pooler, mode Mode,
+	req *tarantool.SelectRequest) *tarantool.SelectIterator {
+	return tarantool.NewSelectIterator(NewConnectorAdapter(pooler, mode), req)
+}
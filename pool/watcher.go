@@ -75,7 +75,7 @@ type poolWatcher struct {
 	mode     Mode
 	key      string
 	callback tarantool.WatchCallback
-	// watchers is a map connection -> connection watcher.
+	// watchers is a map instance name -> connection watcher.
 	watchers map[string]tarantool.Watcher
 	// unregistered is true if the watcher already unregistered.
 	unregistered bool
@@ -99,20 +99,18 @@ func (w *poolWatcher) Unregister() {
 	}
 }
 
-// watch adds a watcher for the connection.
-func (w *poolWatcher) watch(conn *tarantool.Connection) error {
-	addr := conn.Addr()
-
+// watch adds a watcher for the connection to the instance with the name.
+func (w *poolWatcher) watch(name string, conn *tarantool.Connection) error {
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
 
 	if !w.unregistered {
-		if _, ok := w.watchers[addr]; ok {
+		if _, ok := w.watchers[name]; ok {
 			return nil
 		}
 
 		if watcher, err := conn.NewWatcher(w.key, w.callback); err == nil {
-			w.watchers[addr] = watcher
+			w.watchers[name] = watcher
 			return nil
 		} else {
 			return err
@@ -121,17 +119,15 @@ func (w *poolWatcher) watch(conn *tarantool.Connection) error {
 	return nil
 }
 
-// unwatch removes a watcher for the connection.
-func (w *poolWatcher) unwatch(conn *tarantool.Connection) {
-	addr := conn.Addr()
-
+// unwatch removes a watcher for the connection to the instance with the name.
+func (w *poolWatcher) unwatch(name string) {
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
 
 	if !w.unregistered {
-		if watcher, ok := w.watchers[addr]; ok {
+		if watcher, ok := w.watchers[name]; ok {
 			watcher.Unregister()
-			delete(w.watchers, addr)
+			delete(w.watchers, name)
 		}
 	}
 }
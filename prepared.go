@@ -3,6 +3,7 @@ package tarantool
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/tarantool/go-iproto"
 	"github.com/vmihailenco/msgpack/v5"
@@ -91,6 +92,14 @@ func (req *PrepareRequest) Context(ctx context.Context) *PrepareRequest {
 	return req
 }
 
+// ResponseTimeout sets a timeout for a response to the request. It is used
+// instead of Opts.Timeout of the connection if it is positive, even if
+// the request has a context.
+func (req *PrepareRequest) ResponseTimeout(timeout time.Duration) *PrepareRequest {
+	req.respTimeout = timeout
+	return req
+}
+
 // UnprepareRequest helps you to create an unprepare request object for
 // execution by a Connection.
 type UnprepareRequest struct {
@@ -127,6 +136,14 @@ func (req *UnprepareRequest) Context(ctx context.Context) *UnprepareRequest {
 	return req
 }
 
+// ResponseTimeout sets a timeout for a response to the request. It is used
+// instead of Opts.Timeout of the connection if it is positive, even if
+// the request has a context.
+func (req *UnprepareRequest) ResponseTimeout(timeout time.Duration) *UnprepareRequest {
+	req.respTimeout = timeout
+	return req
+}
+
 // ExecutePreparedRequest helps you to create an execute prepared request
 // object for execution by a Connection.
 type ExecutePreparedRequest struct {
@@ -171,3 +188,11 @@ func (req *ExecutePreparedRequest) Context(ctx context.Context) *ExecutePrepared
 	req.ctx = ctx
 	return req
 }
+
+// ResponseTimeout sets a timeout for a response to the request. It is used
+// instead of Opts.Timeout of the connection if it is positive, even if
+// the request has a context.
+func (req *ExecutePreparedRequest) ResponseTimeout(timeout time.Duration) *ExecutePreparedRequest {
+	req.respTimeout = timeout
+	return req
+}
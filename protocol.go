@@ -3,6 +3,7 @@ package tarantool
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/tarantool/go-iproto"
 	"github.com/vmihailenco/msgpack/v5"
@@ -52,6 +53,17 @@ const (
 	// PaginationFeature represents support of pagination
 	// (supported by connector).
 	PaginationFeature ProtocolFeature = 4
+	// SpaceAndIndexNamesFeature represents support of space and index names
+	// in requests instead of numbers (supported by connector).
+	SpaceAndIndexNamesFeature ProtocolFeature = 5
+	// DMLTupleExtensionFeature represents support of MP_TUPLE objects with
+	// formats in responses to data manipulation requests
+	// (supported by connector).
+	DMLTupleExtensionFeature ProtocolFeature = 7
+	// CallRetTupleExtensionFeature represents support of MP_TUPLE objects
+	// with formats in results of call and eval requests
+	// (supported by connector).
+	CallRetTupleExtensionFeature ProtocolFeature = 8
 )
 
 // String returns the name of a Tarantool feature.
@@ -68,6 +80,12 @@ func (ftr ProtocolFeature) String() string {
 		return "WatchersFeature"
 	case PaginationFeature:
 		return "PaginationFeature"
+	case SpaceAndIndexNamesFeature:
+		return "SpaceAndIndexNamesFeature"
+	case DMLTupleExtensionFeature:
+		return "DMLTupleExtensionFeature"
+	case CallRetTupleExtensionFeature:
+		return "CallRetTupleExtensionFeature"
 	default:
 		return fmt.Sprintf("Unknown feature (code %d)", ftr)
 	}
@@ -76,10 +94,11 @@ func (ftr ProtocolFeature) String() string {
 var clientProtocolInfo ProtocolInfo = ProtocolInfo{
 	// Protocol version supported by connector. Version 3
 	// was introduced in Tarantool 2.10.0, version 4 was
-	// introduced in master 948e5cd (possible 2.10.5 or 2.11.0).
+	// introduced in master 948e5cd (possible 2.10.5 or 2.11.0),
+	// version 6 was introduced in Tarantool 3.0.0.
 	// Support of protocol version on connector side was introduced in
 	// 1.10.0.
-	Version: ProtocolVersion(4),
+	Version: ProtocolVersion(6),
 	// Streams and transactions were introduced in protocol version 1
 	// (Tarantool 2.10.0), in connector since 1.7.0.
 	// Error extension type was introduced in protocol
@@ -88,12 +107,19 @@ var clientProtocolInfo ProtocolInfo = ProtocolInfo{
 	// connector since 1.10.0.
 	// Pagination were introduced in protocol version 4 (Tarantool 2.11.0), in
 	// connector since 1.11.0.
+	// Space and index names were introduced in protocol version 5 (Tarantool
+	// 3.0.0), in connector since 2.0.0.
+	// Tuple extensions were introduced in protocol version 6 (Tarantool
+	// 3.0.0), in connector since 2.0.0.
 	Features: []ProtocolFeature{
 		StreamsFeature,
 		TransactionsFeature,
 		ErrorExtensionFeature,
 		WatchersFeature,
 		PaginationFeature,
+		SpaceAndIndexNamesFeature,
+		DMLTupleExtensionFeature,
+		CallRetTupleExtensionFeature,
 	},
 }
 
@@ -151,3 +177,11 @@ func (req *IdRequest) Context(ctx context.Context) *IdRequest {
 	req.ctx = ctx
 	return req
 }
+
+// ResponseTimeout sets a timeout for a response to the request. It is used
+// instead of Opts.Timeout of the connection if it is positive, even if
+// the request has a context.
+func (req *IdRequest) ResponseTimeout(timeout time.Duration) *IdRequest {
+	req.respTimeout = timeout
+	return req
+}
@@ -32,6 +32,9 @@ func TestFeatureStringRepresentation(t *testing.T) {
 	require.Equal(t, ErrorExtensionFeature.String(), "ErrorExtensionFeature")
 	require.Equal(t, WatchersFeature.String(), "WatchersFeature")
 	require.Equal(t, PaginationFeature.String(), "PaginationFeature")
+	require.Equal(t, SpaceAndIndexNamesFeature.String(), "SpaceAndIndexNamesFeature")
+	require.Equal(t, DMLTupleExtensionFeature.String(), "DMLTupleExtensionFeature")
+	require.Equal(t, CallRetTupleExtensionFeature.String(), "CallRetTupleExtensionFeature")
 
 	require.Equal(t, ProtocolFeature(15532).String(), "Unknown feature (code 15532)")
 }
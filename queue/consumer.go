@@ -0,0 +1,303 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultConsumerTakeTimeout is a default timeout of take requests of
+	// a Consumer.
+	DefaultConsumerTakeTimeout = time.Second
+	// DefaultConsumerFailuresTTL is a default time to keep failure counters
+	// of released tasks.
+	DefaultConsumerFailuresTTL = time.Minute
+)
+
+// TaskHandler processes a task taken by a Consumer. Task.Data() returns
+// a value created by ConsumerOpts.NewData with decoded task data.
+//
+// The context is canceled when the Consumer is shutting down. The handler
+// must not call Ack(), Release(), Bury() or Delete() of the task, the
+// Consumer does it according to the returned error.
+type TaskHandler func(ctx context.Context, task *Task) error
+
+// ConsumerOpts is options for a Consumer.
+type ConsumerOpts struct {
+	// Workers is a number of goroutines that take and process tasks. If
+	// Workers is zero, a single goroutine is used.
+	Workers int
+	// NewData creates a pointer to a value to decode task data into it. If
+	// NewData is nil, task data is decoded as interface{}.
+	NewData func() interface{}
+	// Handler processes tasks. It is required.
+	Handler TaskHandler
+	// TakeTimeout is a timeout of a take request. A worker waits for
+	// shutdown no longer than the timeout. The timeout is reduced to 0.9 of
+	// the connection timeout (see Queue.TakeTimeout()). If TakeTimeout is
+	// zero, DefaultConsumerTakeTimeout is used.
+	TakeTimeout time.Duration
+	// Retries is a number of task releases after handler failures. The task
+	// is buried after the next failure. Failures are counted by the Consumer
+	// and a counter is lost on a restart.
+	Retries int
+	// FailuresTTL is a time to keep a failure counter of a released task.
+	// The counter is dropped if the Consumer does not take the task again
+	// during ReleaseDelay + FailuresTTL, for example, if the task is taken
+	// by another consumer. If FailuresTTL is zero, DefaultConsumerFailuresTTL
+	// is used.
+	FailuresTTL time.Duration
+	// ReleaseDelay is a delay of a task release after a handler failure.
+	// A tube must support delayed tasks to use it.
+	ReleaseDelay time.Duration
+	// TouchInterval is an interval to touch a task while it is processed by
+	// the handler. If TouchInterval is zero, tasks are not touched. It
+	// should be less than the task TTR.
+	TouchInterval time.Duration
+	// TouchIncrement is an increment of the task TTR on each touch. If
+	// TouchIncrement is zero, TouchInterval is used.
+	TouchIncrement time.Duration
+	// ErrorHandler is called on take, ack, release, bury and touch errors and
+	// on handler failures. The task is nil for take errors. If ErrorHandler
+	// is nil, errors are logged.
+	ErrorHandler func(task *Task, err error)
+}
+
+// Consumer takes tasks from a tube with a number of workers and passes them
+// to a handler.
+//
+// A task is acked if the handler returns nil. Otherwise, it is released with
+// ConsumerOpts.ReleaseDelay up to ConsumerOpts.Retries times and then it is
+// buried. A panic in the handler is processed as a failure.
+type Consumer struct {
+	queue Queue
+	opts  ConsumerOpts
+
+	failuresMutex sync.Mutex
+	failures      map[uint64]taskFailures
+}
+
+// taskFailures is a failure counter of a released task.
+type taskFailures struct {
+	count  int
+	expire time.Time
+}
+
+// NewConsumer creates a new consumer of the queue tube.
+func NewConsumer(queue Queue, opts ConsumerOpts) (*Consumer, error) {
+	if queue == nil {
+		return nil, errors.New("queue is nil")
+	}
+	if opts.Handler == nil {
+		return nil, errors.New("handler is not set")
+	}
+	if opts.Workers < 0 || opts.Retries < 0 || opts.TakeTimeout < 0 ||
+		opts.ReleaseDelay < 0 || opts.TouchInterval < 0 || opts.TouchIncrement < 0 ||
+		opts.FailuresTTL < 0 {
+		return nil, errors.New("negative consumer options are not allowed")
+	}
+
+	if opts.Workers == 0 {
+		opts.Workers = 1
+	}
+	if opts.TakeTimeout == 0 {
+		opts.TakeTimeout = DefaultConsumerTakeTimeout
+	}
+	if opts.FailuresTTL == 0 {
+		opts.FailuresTTL = DefaultConsumerFailuresTTL
+	}
+	if opts.TouchIncrement == 0 {
+		opts.TouchIncrement = opts.TouchInterval
+	}
+	return &Consumer{
+		queue:    queue,
+		opts:     opts,
+		failures: make(map[uint64]taskFailures),
+	}, nil
+}
+
+// Run starts workers and blocks until the context is done. After that it
+// waits for processed tasks to be handled and returns the context error.
+func (c *Consumer) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for i := 0; i < c.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.work(ctx)
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (c *Consumer) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		var data interface{}
+		if c.opts.NewData != nil {
+			data = c.opts.NewData()
+		}
+
+		var task *Task
+		var err error
+		if data != nil {
+			task, err = c.queue.TakeTypedTimeout(c.opts.TakeTimeout, data)
+		} else {
+			task, err = c.queue.TakeTimeout(c.opts.TakeTimeout)
+		}
+
+		if err != nil {
+			c.onError(nil, fmt.Errorf("failed to take a task: %w", err))
+			// Do not retry immediately on a broken connection.
+			select {
+			case <-ctx.Done():
+			case <-time.After(c.opts.TakeTimeout):
+			}
+			continue
+		}
+		if task == nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			// The task is taken during the shutdown.
+			if err := task.Release(); err != nil {
+				c.onError(task, fmt.Errorf("failed to release a task: %w", err))
+			}
+			return
+		}
+		c.process(ctx, task)
+	}
+}
+
+func (c *Consumer) process(ctx context.Context, task *Task) {
+	// The counter is kept by the worker while the task is taken, so it is
+	// dropped on ack, bury or an interrupted processing.
+	failures := c.takeFailures(task.Id())
+
+	stopTouch := c.touch(task)
+	err := c.handle(ctx, task)
+	stopTouch()
+
+	if err == nil {
+		if err := task.Ack(); err != nil {
+			c.onError(task, fmt.Errorf("failed to ack a task: %w", err))
+		}
+		return
+	}
+	c.onError(task, err)
+
+	if ctx.Err() != nil {
+		// The handler is interrupted by the shutdown, it is not a failure
+		// of the task.
+		if err := task.Release(); err != nil {
+			c.onError(task, fmt.Errorf("failed to release a task: %w", err))
+		}
+		return
+	}
+
+	failures++
+	if failures > c.opts.Retries {
+		if err := task.Bury(); err != nil {
+			c.onError(task, fmt.Errorf("failed to bury a task: %w", err))
+		}
+		return
+	}
+	if err := task.ReleaseCfg(Opts{Delay: c.opts.ReleaseDelay}); err != nil {
+		c.onError(task, fmt.Errorf("failed to release a task: %w", err))
+		return
+	}
+	c.keepFailures(task.Id(), failures)
+}
+
+func (c *Consumer) handle(ctx context.Context, task *Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task handler panics: %v", r)
+		}
+	}()
+	return c.opts.Handler(ctx, task)
+}
+
+// touch starts touching of the task and returns a function to stop it.
+func (c *Consumer) touch(task *Task) func() {
+	if c.opts.TouchInterval == 0 {
+		return func() {}
+	}
+
+	// The task could not be used for the touch because the handler could
+	// access it concurrently.
+	toucher := &Task{id: task.id, q: task.q}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(c.opts.TouchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := toucher.Touch(c.opts.TouchIncrement); err != nil {
+					c.onError(task, fmt.Errorf("failed to touch a task: %w", err))
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// takeFailures removes a failure counter of the task and returns it.
+func (c *Consumer) takeFailures(id uint64) int {
+	c.failuresMutex.Lock()
+	defer c.failuresMutex.Unlock()
+
+	failures, ok := c.failures[id]
+	if !ok {
+		return 0
+	}
+	delete(c.failures, id)
+	if time.Now().After(failures.expire) {
+		return 0
+	}
+	return failures.count
+}
+
+// keepFailures saves a failure counter of the released task and drops
+// expired counters.
+func (c *Consumer) keepFailures(id uint64, count int) {
+	c.failuresMutex.Lock()
+	defer c.failuresMutex.Unlock()
+
+	now := time.Now()
+	for taskId, failures := range c.failures {
+		if now.After(failures.expire) {
+			delete(c.failures, taskId)
+		}
+	}
+	c.failures[id] = taskFailures{
+		count:  count,
+		expire: now.Add(c.opts.ReleaseDelay + c.opts.FailuresTTL),
+	}
+}
+
+func (c *Consumer) onError(task *Task, err error) {
+	if c.opts.ErrorHandler != nil {
+		c.opts.ErrorHandler(task, err)
+		return
+	}
+	if task != nil {
+		log.Printf("tarantool: queue consumer task %d: %s", task.Id(), err)
+	} else {
+		log.Printf("tarantool: queue consumer: %s", err)
+	}
+}
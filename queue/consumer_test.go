@@ -0,0 +1,423 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tarantool/go-tarantool/v2"
+	"github.com/tarantool/go-tarantool/v2/queue"
+	"github.com/tarantool/go-tarantool/v2/test_helpers"
+	"github.com/tarantool/go-tarantool/v2/test_helpers/fakeserver"
+)
+
+const consumerTube = "consumer_tube"
+
+type consumerTaskData struct {
+	Num  uint64 `msgpack:"num"`
+	Name string `msgpack:"name"`
+}
+
+// fakeTube is a minimal in-memory implementation of a queue tube API.
+type fakeTube struct {
+	mutex    sync.Mutex
+	nextId   uint64
+	ready    []uint64
+	data     map[uint64]interface{}
+	status   map[uint64]string
+	releases map[uint64][]float64
+	touches  map[uint64]int
+}
+
+func startFakeTube(t *testing.T) (*fakeserver.Server, *fakeTube) {
+	t.Helper()
+
+	srv, err := fakeserver.Start(fakeserver.Opts{})
+	require.NoError(t, err)
+
+	tube := &fakeTube{
+		data:     make(map[uint64]interface{}),
+		status:   make(map[uint64]string),
+		releases: make(map[uint64][]float64),
+		touches:  make(map[uint64]int),
+	}
+	prefix := "queue.tube." + consumerTube + ":"
+	srv.RegisterFunction(prefix+"take", tube.take)
+	srv.RegisterFunction(prefix+"ack", tube.setStatus(queue.DONE))
+	srv.RegisterFunction(prefix+"bury", tube.setStatus(queue.BURIED))
+	srv.RegisterFunction(prefix+"release", tube.release)
+	srv.RegisterFunction(prefix+"touch", tube.touch)
+	return srv, tube
+}
+
+func (tube *fakeTube) put(data interface{}) uint64 {
+	tube.mutex.Lock()
+	defer tube.mutex.Unlock()
+
+	tube.nextId++
+	tube.data[tube.nextId] = data
+	tube.status[tube.nextId] = queue.READY
+	tube.ready = append(tube.ready, tube.nextId)
+	return tube.nextId
+}
+
+func (tube *fakeTube) tuple(id uint64) []interface{} {
+	return []interface{}{[]interface{}{id, tube.status[id], tube.data[id]}}
+}
+
+func (tube *fakeTube) take(call fakeserver.Call) ([]interface{}, error) {
+	timeout, _ := call.Args[0].(float64)
+	deadline := time.Now().Add(time.Duration(timeout * float64(time.Second)))
+	for {
+		tube.mutex.Lock()
+		if len(tube.ready) > 0 {
+			id := tube.ready[0]
+			tube.ready = tube.ready[1:]
+			tube.status[id] = queue.TAKEN
+			defer tube.mutex.Unlock()
+			return tube.tuple(id), nil
+		}
+		tube.mutex.Unlock()
+
+		if time.Now().After(deadline) {
+			return nil, nil
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func (tube *fakeTube) setStatus(status string) fakeserver.Handler {
+	return func(call fakeserver.Call) ([]interface{}, error) {
+		tube.mutex.Lock()
+		defer tube.mutex.Unlock()
+
+		id := taskId(call)
+		tube.status[id] = status
+		return tube.tuple(id), nil
+	}
+}
+
+func (tube *fakeTube) release(call fakeserver.Call) ([]interface{}, error) {
+	tube.mutex.Lock()
+	defer tube.mutex.Unlock()
+
+	id := taskId(call)
+	opts, _ := call.Args[1].(map[interface{}]interface{})
+	delay, _ := opts["delay"].(float64)
+	tube.releases[id] = append(tube.releases[id], delay)
+	tube.status[id] = queue.READY
+	tube.ready = append(tube.ready, id)
+	return tube.tuple(id), nil
+}
+
+func (tube *fakeTube) touch(call fakeserver.Call) ([]interface{}, error) {
+	tube.mutex.Lock()
+	defer tube.mutex.Unlock()
+
+	id := taskId(call)
+	tube.touches[id]++
+	return tube.tuple(id), nil
+}
+
+func (tube *fakeTube) statuses() map[uint64]string {
+	tube.mutex.Lock()
+	defer tube.mutex.Unlock()
+
+	statuses := make(map[uint64]string)
+	for id, status := range tube.status {
+		statuses[id] = status
+	}
+	return statuses
+}
+
+func (tube *fakeTube) waitStatus(t *testing.T, id uint64, status string) {
+	t.Helper()
+
+	for i := 0; i < 200; i++ {
+		if tube.statuses()[id] == status {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Task %d status is %q, expected %q", id, tube.statuses()[id], status)
+}
+
+func taskId(call fakeserver.Call) uint64 {
+	switch id := call.Args[0].(type) {
+	case uint64:
+		return id
+	case int64:
+		return uint64(id)
+	}
+	panic(fmt.Sprintf("unexpected task id %v", call.Args[0]))
+}
+
+func connectFakeTube(t *testing.T, srv *fakeserver.Server) (*tarantool.Connection, queue.Queue) {
+	t.Helper()
+
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), tarantool.Opts{
+		Timeout: 5 * time.Second,
+	})
+	return conn, queue.New(conn, consumerTube)
+}
+
+// runConsumer starts the consumer and returns a function to stop it.
+func runConsumer(t *testing.T, q queue.Queue, opts queue.ConsumerOpts) func() {
+	t.Helper()
+
+	if opts.TakeTimeout == 0 {
+		opts.TakeTimeout = 50 * time.Millisecond
+	}
+	if opts.ErrorHandler == nil {
+		opts.ErrorHandler = func(task *queue.Task, err error) {}
+	}
+	consumer, err := queue.NewConsumer(q, opts)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- consumer.Run(ctx)
+	}()
+	return func() {
+		cancel()
+		assert.Equal(t, context.Canceled, <-done)
+	}
+}
+
+func TestNewConsumer_error(t *testing.T) {
+	handler := func(ctx context.Context, task *queue.Task) error { return nil }
+
+	cases := []struct {
+		name  string
+		queue queue.Queue
+		opts  queue.ConsumerOpts
+	}{
+		{"nil queue", nil, queue.ConsumerOpts{Handler: handler}},
+		{"nil handler", queue.New(nil, "tube"), queue.ConsumerOpts{}},
+		{"negative workers", queue.New(nil, "tube"),
+			queue.ConsumerOpts{Handler: handler, Workers: -1}},
+		{"negative retries", queue.New(nil, "tube"),
+			queue.ConsumerOpts{Handler: handler, Retries: -1}},
+		{"negative failures ttl", queue.New(nil, "tube"),
+			queue.ConsumerOpts{Handler: handler, FailuresTTL: -1}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			consumer, err := queue.NewConsumer(tc.queue, tc.opts)
+			assert.Error(t, err)
+			assert.Nil(t, consumer)
+		})
+	}
+}
+
+func TestConsumer_ack(t *testing.T) {
+	srv, tube := startFakeTube(t)
+	defer srv.Close()
+	conn, q := connectFakeTube(t, srv)
+	defer conn.Close()
+
+	const tasksCount = 20
+	for i := 1; i <= tasksCount; i++ {
+		tube.put(map[string]interface{}{"num": i, "name": fmt.Sprintf("task%d", i)})
+	}
+
+	var mutex sync.Mutex
+	handled := []uint64{}
+	stop := runConsumer(t, q, queue.ConsumerOpts{
+		Workers: 4,
+		NewData: func() interface{} { return &consumerTaskData{} },
+		Handler: func(ctx context.Context, task *queue.Task) error {
+			data, ok := task.Data().(*consumerTaskData)
+			require.True(t, ok)
+			assert.Equal(t, fmt.Sprintf("task%d", data.Num), data.Name)
+
+			mutex.Lock()
+			handled = append(handled, data.Num)
+			mutex.Unlock()
+			return nil
+		},
+	})
+
+	for id := uint64(1); id <= tasksCount; id++ {
+		tube.waitStatus(t, id, queue.DONE)
+	}
+	stop()
+
+	sort.Slice(handled, func(i, j int) bool { return handled[i] < handled[j] })
+	expected := []uint64{}
+	for i := uint64(1); i <= tasksCount; i++ {
+		expected = append(expected, i)
+	}
+	assert.Equal(t, expected, handled)
+}
+
+func TestConsumer_untyped(t *testing.T) {
+	srv, tube := startFakeTube(t)
+	defer srv.Close()
+	conn, q := connectFakeTube(t, srv)
+	defer conn.Close()
+
+	id := tube.put("data")
+	dataCh := make(chan interface{}, 1)
+	stop := runConsumer(t, q, queue.ConsumerOpts{
+		Handler: func(ctx context.Context, task *queue.Task) error {
+			dataCh <- task.Data()
+			return nil
+		},
+	})
+	defer stop()
+
+	assert.Equal(t, "data", <-dataCh)
+	tube.waitStatus(t, id, queue.DONE)
+}
+
+func TestConsumer_retries(t *testing.T) {
+	srv, tube := startFakeTube(t)
+	defer srv.Close()
+	conn, q := connectFakeTube(t, srv)
+	defer conn.Close()
+
+	id := tube.put("data")
+	handlerErr := errors.New("handler error")
+
+	var mutex sync.Mutex
+	calls := 0
+	errs := []error{}
+	stop := runConsumer(t, q, queue.ConsumerOpts{
+		Retries:      2,
+		ReleaseDelay: 2 * time.Second,
+		Handler: func(ctx context.Context, task *queue.Task) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			calls++
+			return handlerErr
+		},
+		ErrorHandler: func(task *queue.Task, err error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			errs = append(errs, err)
+		},
+	})
+
+	tube.waitStatus(t, id, queue.BURIED)
+	stop()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []error{handlerErr, handlerErr, handlerErr}, errs)
+
+	tube.mutex.Lock()
+	defer tube.mutex.Unlock()
+	assert.Equal(t, []float64{2, 2}, tube.releases[id])
+}
+
+func TestConsumer_failuresExpire(t *testing.T) {
+	srv, tube := startFakeTube(t)
+	defer srv.Close()
+	conn, q := connectFakeTube(t, srv)
+	defer conn.Close()
+
+	failed := tube.put("failed")
+	tube.put("slow")
+	stop := runConsumer(t, q, queue.ConsumerOpts{
+		Retries:     1,
+		FailuresTTL: 50 * time.Millisecond,
+		Handler: func(ctx context.Context, task *queue.Task) error {
+			if task.Data() == "slow" {
+				// The failed task waits in the tube longer than the
+				// counter lives.
+				time.Sleep(200 * time.Millisecond)
+				return nil
+			}
+			return errors.New("handler error")
+		},
+	})
+	defer stop()
+
+	tube.waitStatus(t, failed, queue.BURIED)
+
+	// The first failure is forgotten, so the task is retried once more.
+	tube.mutex.Lock()
+	defer tube.mutex.Unlock()
+	assert.Equal(t, []float64{0, 0}, tube.releases[failed])
+}
+
+func TestConsumer_panic(t *testing.T) {
+	srv, tube := startFakeTube(t)
+	defer srv.Close()
+	conn, q := connectFakeTube(t, srv)
+	defer conn.Close()
+
+	id := tube.put("data")
+	errCh := make(chan error, 1)
+	stop := runConsumer(t, q, queue.ConsumerOpts{
+		Handler: func(ctx context.Context, task *queue.Task) error {
+			panic("handler panic")
+		},
+		ErrorHandler: func(task *queue.Task, err error) {
+			errCh <- err
+		},
+	})
+	defer stop()
+
+	tube.waitStatus(t, id, queue.BURIED)
+	assert.EqualError(t, <-errCh, "task handler panics: handler panic")
+}
+
+func TestConsumer_touch(t *testing.T) {
+	srv, tube := startFakeTube(t)
+	defer srv.Close()
+	conn, q := connectFakeTube(t, srv)
+	defer conn.Close()
+
+	id := tube.put("data")
+	stop := runConsumer(t, q, queue.ConsumerOpts{
+		TouchInterval: 20 * time.Millisecond,
+		Handler: func(ctx context.Context, task *queue.Task) error {
+			time.Sleep(150 * time.Millisecond)
+			return nil
+		},
+	})
+	defer stop()
+
+	tube.waitStatus(t, id, queue.DONE)
+
+	tube.mutex.Lock()
+	defer tube.mutex.Unlock()
+	assert.GreaterOrEqual(t, tube.touches[id], 2)
+}
+
+func TestConsumer_shutdown(t *testing.T) {
+	srv, tube := startFakeTube(t)
+	defer srv.Close()
+	conn, q := connectFakeTube(t, srv)
+	defer conn.Close()
+
+	id := tube.put("data")
+	started := make(chan struct{})
+	stop := runConsumer(t, q, queue.ConsumerOpts{
+		Handler: func(ctx context.Context, task *queue.Task) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+
+	<-started
+	stop()
+
+	// The interrupted task is released without a delay.
+	assert.Equal(t, queue.READY, tube.statuses()[id])
+	tube.mutex.Lock()
+	defer tube.mutex.Unlock()
+	assert.Equal(t, []float64{0}, tube.releases[id])
+}
@@ -161,13 +161,17 @@ func Example_connectionPool() {
 		User:    "test",
 		Pass:    "test",
 	}
+	instances := make([]pool.Instance, 0, len(servers))
+	for _, server := range servers {
+		instances = append(instances, pool.Instance{Addr: server, Opts: connOpts})
+	}
 	poolOpts := pool.Opts{
 		CheckTimeout:      5 * time.Second,
 		ConnectionHandler: h,
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
 	defer cancel()
-	connPool, err := pool.ConnectWithOpts(ctx, servers, connOpts, poolOpts)
+	connPool, err := pool.ConnectWithOpts(ctx, instances, poolOpts)
 	if err != nil {
 		fmt.Printf("Unable to connect to the pool: %s", err)
 		return
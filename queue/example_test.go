@@ -87,3 +87,72 @@ func Example_simpleQueue() {
 
 	// Output: data_1:  test_data_1
 }
+
+// Example demonstrates processing of tasks with a Consumer.
+func ExampleConsumer() {
+	opts := tarantool.Opts{
+		Timeout: 2500 * time.Millisecond,
+		User:    "test",
+		Pass:    "test",
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	conn, err := tarantool.Connect(ctx, "127.0.0.1:3013", opts)
+	if err != nil {
+		fmt.Printf("error in prepare is %v", err)
+		return
+	}
+	defer conn.Close()
+
+	q := queue.New(conn, "test_consumer_queue")
+	if err := q.Create(queue.Cfg{Temporary: true, Kind: queue.FIFO_TTL}); err != nil {
+		fmt.Printf("error in queue is %v", err)
+		return
+	}
+	defer q.Drop()
+
+	for i := 0; i < 3; i++ {
+		if _, err := q.Put(fmt.Sprintf("task_%d", i)); err != nil {
+			fmt.Printf("error in put is %v", err)
+			return
+		}
+	}
+
+	done := make(chan string)
+	consumer, err := queue.NewConsumer(q, queue.ConsumerOpts{
+		Workers: 2,
+		NewData: func() interface{} {
+			return new(string)
+		},
+		Handler: func(ctx context.Context, task *queue.Task) error {
+			done <- *task.Data().(*string)
+			return nil
+		},
+		TakeTimeout:   100 * time.Millisecond,
+		Retries:       3,
+		ReleaseDelay:  time.Second,
+		TouchInterval: time.Second,
+	})
+	if err != nil {
+		fmt.Printf("error in consumer is %v", err)
+		return
+	}
+
+	runCtx, stop := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() {
+		stopped <- consumer.Run(runCtx)
+	}()
+
+	processed := 0
+	for range done {
+		processed++
+		if processed == 3 {
+			break
+		}
+	}
+	stop()
+	fmt.Println("processed:", processed, <-stopped)
+	// Output: processed: 3 context canceled
+}
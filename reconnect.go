@@ -0,0 +1,120 @@
+package tarantool
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ReconnectPolicy is an interface to control reconnect attempts. It could be
+// passed in Opts.ReconnectPolicy to a connection or in a pool options.
+//
+// A policy object could be shared between several connections, so it should
+// be safe for concurrent use.
+type ReconnectPolicy interface {
+	// NextDelay returns a pause before the reconnect attempt with the
+	// number attempt (starting from 1). prev is a pause returned for the
+	// previous attempt (zero for the first one) and err is an error of the
+	// last failed attempt. The second value is false if the reconnects
+	// should be stopped.
+	NextDelay(attempt uint, prev time.Duration, err error) (time.Duration, bool)
+}
+
+// ConstantReconnectPolicy makes reconnect attempts with the same pause.
+type ConstantReconnectPolicy struct {
+	// Delay is a pause between reconnect attempts.
+	Delay time.Duration
+	// MaxAttempts is a maximum number of reconnect attempts. If MaxAttempts
+	// is zero, the attempts are endless.
+	MaxAttempts uint
+}
+
+// NextDelay returns the constant delay until MaxAttempts are done.
+func (p ConstantReconnectPolicy) NextDelay(attempt uint, prev time.Duration,
+	err error) (time.Duration, bool) {
+	if !canReconnect(attempt, p.MaxAttempts) {
+		return 0, false
+	}
+	return p.Delay, true
+}
+
+// ExponentialReconnectPolicy makes reconnect attempts with a pause that is
+// multiplied by Multiplier after each failed attempt.
+type ExponentialReconnectPolicy struct {
+	// Base is a pause before the first reconnect attempt.
+	Base time.Duration
+	// Max is a maximum pause between reconnect attempts. If Max is zero,
+	// the pause is not limited.
+	Max time.Duration
+	// Multiplier is a multiplier of the pause. It is 2 if Multiplier is less
+	// or equal to 1.
+	Multiplier float64
+	// MaxAttempts is a maximum number of reconnect attempts. If MaxAttempts
+	// is zero, the attempts are endless.
+	MaxAttempts uint
+}
+
+// NextDelay returns Base * Multiplier^(attempt - 1) limited by Max until
+// MaxAttempts are done.
+func (p ExponentialReconnectPolicy) NextDelay(attempt uint, prev time.Duration,
+	err error) (time.Duration, bool) {
+	if !canReconnect(attempt, p.MaxAttempts) {
+		return 0, false
+	}
+
+	multiplier := p.Multiplier
+	if multiplier <= 1 {
+		multiplier = 2
+	}
+	delay := float64(p.Base) * math.Pow(multiplier, float64(attempt-1))
+	return limitDelay(delay, p.Max), true
+}
+
+// DecorrelatedJitterReconnectPolicy makes reconnect attempts with a random
+// pause between Base and a tripled previous pause. It spreads reconnects of
+// many clients to a recovering instance.
+//
+// See https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+type DecorrelatedJitterReconnectPolicy struct {
+	// Base is a minimum pause between reconnect attempts.
+	Base time.Duration
+	// Max is a maximum pause between reconnect attempts. If Max is zero,
+	// the pause is not limited.
+	Max time.Duration
+	// MaxAttempts is a maximum number of reconnect attempts. If MaxAttempts
+	// is zero, the attempts are endless.
+	MaxAttempts uint
+}
+
+// NextDelay returns a random pause in [Base, 3 * prev) limited by Max until
+// MaxAttempts are done.
+func (p DecorrelatedJitterReconnectPolicy) NextDelay(attempt uint,
+	prev time.Duration, err error) (time.Duration, bool) {
+	if !canReconnect(attempt, p.MaxAttempts) {
+		return 0, false
+	}
+
+	if prev < p.Base {
+		prev = p.Base
+	}
+	upper := 3 * float64(prev)
+	delay := float64(p.Base)
+	if upper > delay {
+		delay += rand.Float64() * (upper - delay)
+	}
+	return limitDelay(delay, p.Max), true
+}
+
+func canReconnect(attempt, maxAttempts uint) bool {
+	return maxAttempts == 0 || attempt <= maxAttempts
+}
+
+func limitDelay(delay float64, max time.Duration) time.Duration {
+	if max > 0 && delay > float64(max) {
+		return max
+	}
+	if delay > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(delay)
+}
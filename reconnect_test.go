@@ -0,0 +1,103 @@
+package tarantool_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	. "github.com/tarantool/go-tarantool/v2"
+)
+
+var errReconnect = errors.New("reconnect error")
+
+func TestConstantReconnectPolicy(t *testing.T) {
+	policy := ConstantReconnectPolicy{
+		Delay:       time.Second,
+		MaxAttempts: 3,
+	}
+
+	for attempt := uint(1); attempt <= 3; attempt++ {
+		delay, ok := policy.NextDelay(attempt, time.Second, errReconnect)
+		require.True(t, ok)
+		require.Equal(t, time.Second, delay)
+	}
+	_, ok := policy.NextDelay(4, time.Second, errReconnect)
+	require.False(t, ok)
+}
+
+func TestConstantReconnectPolicy_endless(t *testing.T) {
+	policy := ConstantReconnectPolicy{Delay: time.Second}
+
+	delay, ok := policy.NextDelay(1000000, time.Second, errReconnect)
+	require.True(t, ok)
+	require.Equal(t, time.Second, delay)
+}
+
+func TestExponentialReconnectPolicy(t *testing.T) {
+	policy := ExponentialReconnectPolicy{
+		Base:        100 * time.Millisecond,
+		Max:         time.Second,
+		MaxAttempts: 6,
+	}
+
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+	var prev time.Duration
+	for i, exp := range expected {
+		delay, ok := policy.NextDelay(uint(i+1), prev, errReconnect)
+		require.True(t, ok)
+		require.Equal(t, exp, delay)
+		prev = delay
+	}
+	_, ok := policy.NextDelay(7, prev, errReconnect)
+	require.False(t, ok)
+}
+
+func TestExponentialReconnectPolicy_multiplier(t *testing.T) {
+	policy := ExponentialReconnectPolicy{
+		Base:       time.Second,
+		Multiplier: 3,
+	}
+
+	delay, ok := policy.NextDelay(3, 3*time.Second, errReconnect)
+	require.True(t, ok)
+	require.Equal(t, 9*time.Second, delay)
+}
+
+func TestExponentialReconnectPolicy_overflow(t *testing.T) {
+	policy := ExponentialReconnectPolicy{Base: time.Second}
+
+	delay, ok := policy.NextDelay(1000, 0, errReconnect)
+	require.True(t, ok)
+	require.True(t, delay > 0)
+}
+
+func TestDecorrelatedJitterReconnectPolicy(t *testing.T) {
+	policy := DecorrelatedJitterReconnectPolicy{
+		Base:        100 * time.Millisecond,
+		Max:         10 * time.Second,
+		MaxAttempts: 100,
+	}
+
+	var prev time.Duration
+	for attempt := uint(1); attempt <= 100; attempt++ {
+		delay, ok := policy.NextDelay(attempt, prev, errReconnect)
+		require.True(t, ok)
+		require.GreaterOrEqual(t, delay, policy.Base)
+		require.LessOrEqual(t, delay, policy.Max)
+		if prev > 0 {
+			require.LessOrEqual(t, delay, 3*prev)
+		}
+		prev = delay
+	}
+	_, ok := policy.NextDelay(101, prev, errReconnect)
+	require.False(t, ok)
+}
@@ -7,22 +7,94 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/tarantool/go-iproto"
 	"github.com/vmihailenco/msgpack/v5"
 )
 
-func fillSearch(enc *msgpack.Encoder, spaceNo, indexNo uint32, key interface{}) error {
+// spaceEncoder encodes a space of a request as a number or as a name.
+type spaceEncoder struct {
+	id     uint32
+	name   string
+	isName bool
+}
+
+// Encode encodes the space key and value.
+func (e spaceEncoder) Encode(enc *msgpack.Encoder) error {
+	if e.isName {
+		if err := enc.EncodeUint(uint64(iprotoSpaceName)); err != nil {
+			return err
+		}
+		return enc.EncodeString(e.name)
+	}
 	if err := enc.EncodeUint(uint64(iproto.IPROTO_SPACE_ID)); err != nil {
 		return err
 	}
-	if err := enc.EncodeUint(uint64(spaceNo)); err != nil {
-		return err
+	return enc.EncodeUint(uint64(e.id))
+}
+
+// indexEncoder encodes an index of a request as a number or as a name.
+type indexEncoder struct {
+	id     uint32
+	name   string
+	isName bool
+}
+
+// Encode encodes the index key and value.
+func (e indexEncoder) Encode(enc *msgpack.Encoder) error {
+	if e.isName {
+		if err := enc.EncodeUint(uint64(iprotoIndexName)); err != nil {
+			return err
+		}
+		return enc.EncodeString(e.name)
 	}
 	if err := enc.EncodeUint(uint64(iproto.IPROTO_INDEX_ID)); err != nil {
 		return err
 	}
-	if err := enc.EncodeUint(uint64(indexNo)); err != nil {
+	return enc.EncodeUint(uint64(e.id))
+}
+
+// newSpaceIndexEncoders creates encoders of the space and the index. Names
+// are encoded as is if the resolver implements NamesUseResolver and supports
+// it, otherwise they are resolved into numbers. A nil resolver resolves only
+// numbers and objects.
+func newSpaceIndexEncoders(res SchemaResolver, space,
+	index interface{}) (spaceEncoder, indexEncoder, error) {
+	var spaceEnc spaceEncoder
+	var indexEnc indexEncoder
+
+	if namesRes, ok := res.(NamesUseResolver); ok && namesRes.NamesUseSupported() {
+		spaceEnc.name, spaceEnc.isName = space.(string)
+		indexEnc.name, indexEnc.isName = index.(string)
+	}
+	if res != nil && !spaceEnc.isName && !indexEnc.isName {
+		spaceNo, indexNo, err := res.ResolveSpaceIndex(space, index)
+		return spaceEncoder{id: spaceNo}, indexEncoder{id: indexNo}, err
+	}
+
+	// The rest are numbers or objects, a schema is not required.
+	if !spaceEnc.isName {
+		if _, ok := space.(string); ok {
+			return spaceEnc, indexEnc, errSchemaNotLoaded
+		}
+		spaceEnc.id = spaceNumber(space)
+	}
+	if !indexEnc.isName && index != nil {
+		if _, ok := index.(string); ok {
+			return spaceEnc, indexEnc, errSchemaNotLoaded
+		}
+		indexEnc.id = indexNumber(index)
+	}
+	return spaceEnc, indexEnc, nil
+}
+
+func fillSearch(enc *msgpack.Encoder, spaceEnc spaceEncoder, indexEnc indexEncoder,
+	key interface{}) error {
+	if err := spaceEnc.Encode(enc); err != nil {
+		return err
+	}
+	if err := indexEnc.Encode(enc); err != nil {
 		return err
 	}
 	if err := enc.EncodeUint(uint64(iproto.IPROTO_KEY)); err != nil {
@@ -50,14 +122,11 @@ func fillIterator(enc *msgpack.Encoder, offset, limit uint32, iterator Iter) err
 	return enc.EncodeUint(uint64(limit))
 }
 
-func fillInsert(enc *msgpack.Encoder, spaceNo uint32, tuple interface{}) error {
+func fillInsert(enc *msgpack.Encoder, spaceEnc spaceEncoder, tuple interface{}) error {
 	if err := enc.EncodeMapLen(2); err != nil {
 		return err
 	}
-	if err := enc.EncodeUint(uint64(iproto.IPROTO_SPACE_ID)); err != nil {
-		return err
-	}
-	if err := enc.EncodeUint(uint64(spaceNo)); err != nil {
+	if err := spaceEnc.Encode(enc); err != nil {
 		return err
 	}
 	if err := enc.EncodeUint(uint64(iproto.IPROTO_TUPLE)); err != nil {
@@ -66,8 +135,8 @@ func fillInsert(enc *msgpack.Encoder, spaceNo uint32, tuple interface{}) error {
 	return enc.Encode(tuple)
 }
 
-func fillSelect(enc *msgpack.Encoder, spaceNo, indexNo, offset, limit uint32, iterator Iter,
-	key, after interface{}, fetchPos bool) error {
+func fillSelect(enc *msgpack.Encoder, spaceEnc spaceEncoder, indexEnc indexEncoder,
+	offset, limit uint32, iterator Iter, key, after interface{}, fetchPos bool) error {
 	mapLen := 6
 	if fetchPos {
 		mapLen += 1
@@ -81,7 +150,7 @@ func fillSelect(enc *msgpack.Encoder, spaceNo, indexNo, offset, limit uint32, it
 	if err := fillIterator(enc, offset, limit, iterator); err != nil {
 		return err
 	}
-	if err := fillSearch(enc, spaceNo, indexNo, key); err != nil {
+	if err := fillSearch(enc, spaceEnc, indexEnc, key); err != nil {
 		return err
 	}
 	if fetchPos {
@@ -112,19 +181,21 @@ func fillSelect(enc *msgpack.Encoder, spaceNo, indexNo, offset, limit uint32, it
 	return nil
 }
 
-func fillUpdate(enc *msgpack.Encoder, spaceNo, indexNo uint32, key, ops interface{}) error {
+func fillUpdate(enc *msgpack.Encoder, spaceEnc spaceEncoder, indexEnc indexEncoder,
+	key, ops interface{}) error {
 	enc.EncodeMapLen(4)
-	if err := fillSearch(enc, spaceNo, indexNo, key); err != nil {
+	if err := fillSearch(enc, spaceEnc, indexEnc, key); err != nil {
 		return err
 	}
 	enc.EncodeUint(uint64(iproto.IPROTO_TUPLE))
 	return enc.Encode(ops)
 }
 
-func fillUpsert(enc *msgpack.Encoder, spaceNo uint32, tuple, ops interface{}) error {
+func fillUpsert(enc *msgpack.Encoder, spaceEnc spaceEncoder, tuple, ops interface{}) error {
 	enc.EncodeMapLen(3)
-	enc.EncodeUint(uint64(iproto.IPROTO_SPACE_ID))
-	enc.EncodeUint(uint64(spaceNo))
+	if err := spaceEnc.Encode(enc); err != nil {
+		return err
+	}
 	enc.EncodeUint(uint64(iproto.IPROTO_TUPLE))
 	if err := enc.Encode(tuple); err != nil {
 		return err
@@ -133,9 +204,10 @@ func fillUpsert(enc *msgpack.Encoder, spaceNo uint32, tuple, ops interface{}) er
 	return enc.Encode(ops)
 }
 
-func fillDelete(enc *msgpack.Encoder, spaceNo, indexNo uint32, key interface{}) error {
+func fillDelete(enc *msgpack.Encoder, spaceEnc spaceEncoder, indexEnc indexEncoder,
+	key interface{}) error {
 	enc.EncodeMapLen(3)
-	return fillSearch(enc, spaceNo, indexNo, key)
+	return fillSearch(enc, spaceEnc, indexEnc, key)
 }
 
 func fillCall(enc *msgpack.Encoder, functionName string, args interface{}) error {
@@ -731,6 +803,8 @@ type baseRequest struct {
 	rtype iproto.Type
 	async bool
 	ctx   context.Context
+	// respTimeout is a timeout for a response to the request.
+	respTimeout time.Duration
 }
 
 // Type returns a IPROTO type for the request.
@@ -748,6 +822,10 @@ func (req *baseRequest) Ctx() context.Context {
 	return req.ctx
 }
 
+func (req *baseRequest) responseTimeout() time.Duration {
+	return req.respTimeout
+}
+
 type spaceRequest struct {
 	baseRequest
 	space interface{}
@@ -757,6 +835,10 @@ func (req *spaceRequest) setSpace(space interface{}) {
 	req.space = space
 }
 
+func (req *spaceRequest) getSpace() interface{} {
+	return req.space
+}
+
 type spaceIndexRequest struct {
 	spaceRequest
 	index interface{}
@@ -849,6 +931,14 @@ func (req *PingRequest) Context(ctx context.Context) *PingRequest {
 	return req
 }
 
+// ResponseTimeout sets a timeout for a response to the request. It is used
+// instead of Opts.Timeout of the connection if it is positive, even if
+// the request has a context.
+func (req *PingRequest) ResponseTimeout(timeout time.Duration) *PingRequest {
+	req.respTimeout = timeout
+	return req
+}
+
 // SelectRequest allows you to create a select request object for execution
 // by a Connection.
 type SelectRequest struct {
@@ -938,12 +1028,12 @@ func (req *SelectRequest) After(after interface{}) *SelectRequest {
 
 // Body fills an encoder with the select request body.
 func (req *SelectRequest) Body(res SchemaResolver, enc *msgpack.Encoder) error {
-	spaceNo, indexNo, err := res.ResolveSpaceIndex(req.space, req.index)
+	spaceEnc, indexEnc, err := newSpaceIndexEncoders(res, req.space, req.index)
 	if err != nil {
 		return err
 	}
 
-	return fillSelect(enc, spaceNo, indexNo, req.offset, req.limit, req.iterator,
+	return fillSelect(enc, spaceEnc, indexEnc, req.offset, req.limit, req.iterator,
 		req.key, req.after, req.fetchPos)
 }
 
@@ -958,6 +1048,14 @@ func (req *SelectRequest) Context(ctx context.Context) *SelectRequest {
 	return req
 }
 
+// ResponseTimeout sets a timeout for a response to the request. It is used
+// instead of Opts.Timeout of the connection if it is positive, even if
+// the request has a context.
+func (req *SelectRequest) ResponseTimeout(timeout time.Duration) *SelectRequest {
+	req.respTimeout = timeout
+	return req
+}
+
 // InsertRequest helps you to create an insert request object for execution
 // by a Connection.
 type InsertRequest struct {
@@ -983,12 +1081,12 @@ func (req *InsertRequest) Tuple(tuple interface{}) *InsertRequest {
 
 // Body fills an msgpack.Encoder with the insert request body.
 func (req *InsertRequest) Body(res SchemaResolver, enc *msgpack.Encoder) error {
-	spaceNo, _, err := res.ResolveSpaceIndex(req.space, nil)
+	spaceEnc, _, err := newSpaceIndexEncoders(res, req.space, nil)
 	if err != nil {
 		return err
 	}
 
-	return fillInsert(enc, spaceNo, req.tuple)
+	return fillInsert(enc, spaceEnc, req.tuple)
 }
 
 // Context sets a passed context to the request.
@@ -1002,6 +1100,14 @@ func (req *InsertRequest) Context(ctx context.Context) *InsertRequest {
 	return req
 }
 
+// ResponseTimeout sets a timeout for a response to the request. It is used
+// instead of Opts.Timeout of the connection if it is positive, even if
+// the request has a context.
+func (req *InsertRequest) ResponseTimeout(timeout time.Duration) *InsertRequest {
+	req.respTimeout = timeout
+	return req
+}
+
 // ReplaceRequest helps you to create a replace request object for execution
 // by a Connection.
 type ReplaceRequest struct {
@@ -1027,12 +1133,12 @@ func (req *ReplaceRequest) Tuple(tuple interface{}) *ReplaceRequest {
 
 // Body fills an msgpack.Encoder with the replace request body.
 func (req *ReplaceRequest) Body(res SchemaResolver, enc *msgpack.Encoder) error {
-	spaceNo, _, err := res.ResolveSpaceIndex(req.space, nil)
+	spaceEnc, _, err := newSpaceIndexEncoders(res, req.space, nil)
 	if err != nil {
 		return err
 	}
 
-	return fillInsert(enc, spaceNo, req.tuple)
+	return fillInsert(enc, spaceEnc, req.tuple)
 }
 
 // Context sets a passed context to the request.
@@ -1046,6 +1152,14 @@ func (req *ReplaceRequest) Context(ctx context.Context) *ReplaceRequest {
 	return req
 }
 
+// ResponseTimeout sets a timeout for a response to the request. It is used
+// instead of Opts.Timeout of the connection if it is positive, even if
+// the request has a context.
+func (req *ReplaceRequest) ResponseTimeout(timeout time.Duration) *ReplaceRequest {
+	req.respTimeout = timeout
+	return req
+}
+
 // DeleteRequest helps you to create a delete request object for execution
 // by a Connection.
 type DeleteRequest struct {
@@ -1078,12 +1192,12 @@ func (req *DeleteRequest) Key(key interface{}) *DeleteRequest {
 
 // Body fills an msgpack.Encoder with the delete request body.
 func (req *DeleteRequest) Body(res SchemaResolver, enc *msgpack.Encoder) error {
-	spaceNo, indexNo, err := res.ResolveSpaceIndex(req.space, req.index)
+	spaceEnc, indexEnc, err := newSpaceIndexEncoders(res, req.space, req.index)
 	if err != nil {
 		return err
 	}
 
-	return fillDelete(enc, spaceNo, indexNo, req.key)
+	return fillDelete(enc, spaceEnc, indexEnc, req.key)
 }
 
 // Context sets a passed context to the request.
@@ -1097,6 +1211,14 @@ func (req *DeleteRequest) Context(ctx context.Context) *DeleteRequest {
 	return req
 }
 
+// ResponseTimeout sets a timeout for a response to the request. It is used
+// instead of Opts.Timeout of the connection if it is positive, even if
+// the request has a context.
+func (req *DeleteRequest) ResponseTimeout(timeout time.Duration) *DeleteRequest {
+	req.respTimeout = timeout
+	return req
+}
+
 // UpdateRequest helps you to create an update request object for execution
 // by a Connection.
 type UpdateRequest struct {
@@ -1140,12 +1262,12 @@ func (req *UpdateRequest) Operations(ops *Operations) *UpdateRequest {
 
 // Body fills an msgpack.Encoder with the update request body.
 func (req *UpdateRequest) Body(res SchemaResolver, enc *msgpack.Encoder) error {
-	spaceNo, indexNo, err := res.ResolveSpaceIndex(req.space, req.index)
+	spaceEnc, indexEnc, err := newSpaceIndexEncoders(res, req.space, req.index)
 	if err != nil {
 		return err
 	}
 
-	return fillUpdate(enc, spaceNo, indexNo, req.key, req.ops)
+	return fillUpdate(enc, spaceEnc, indexEnc, req.key, req.ops)
 }
 
 // Context sets a passed context to the request.
@@ -1159,6 +1281,14 @@ func (req *UpdateRequest) Context(ctx context.Context) *UpdateRequest {
 	return req
 }
 
+// ResponseTimeout sets a timeout for a response to the request. It is used
+// instead of Opts.Timeout of the connection if it is positive, even if
+// the request has a context.
+func (req *UpdateRequest) ResponseTimeout(timeout time.Duration) *UpdateRequest {
+	req.respTimeout = timeout
+	return req
+}
+
 // UpsertRequest helps you to create an upsert request object for execution
 // by a Connection.
 type UpsertRequest struct {
@@ -1195,12 +1325,12 @@ func (req *UpsertRequest) Operations(ops *Operations) *UpsertRequest {
 
 // Body fills an msgpack.Encoder with the upsert request body.
 func (req *UpsertRequest) Body(res SchemaResolver, enc *msgpack.Encoder) error {
-	spaceNo, _, err := res.ResolveSpaceIndex(req.space, nil)
+	spaceEnc, _, err := newSpaceIndexEncoders(res, req.space, nil)
 	if err != nil {
 		return err
 	}
 
-	return fillUpsert(enc, spaceNo, req.tuple, req.ops)
+	return fillUpsert(enc, spaceEnc, req.tuple, req.ops)
 }
 
 // Context sets a passed context to the request.
@@ -1214,6 +1344,14 @@ func (req *UpsertRequest) Context(ctx context.Context) *UpsertRequest {
 	return req
 }
 
+// ResponseTimeout sets a timeout for a response to the request. It is used
+// instead of Opts.Timeout of the connection if it is positive, even if
+// the request has a context.
+func (req *UpsertRequest) ResponseTimeout(timeout time.Duration) *UpsertRequest {
+	req.respTimeout = timeout
+	return req
+}
+
 // CallRequest helps you to create a call request object for execution
 // by a Connection.
 type CallRequest struct {
@@ -1258,6 +1396,14 @@ func (req *CallRequest) Context(ctx context.Context) *CallRequest {
 	return req
 }
 
+// ResponseTimeout sets a timeout for a response to the request. It is used
+// instead of Opts.Timeout of the connection if it is positive, even if
+// the request has a context.
+func (req *CallRequest) ResponseTimeout(timeout time.Duration) *CallRequest {
+	req.respTimeout = timeout
+	return req
+}
+
 // NewCall16Request returns a new empty Call16Request. It uses request code for
 // Tarantool 1.6.
 // Deprecated since Tarantool 1.7.2.
@@ -1315,6 +1461,14 @@ func (req *EvalRequest) Context(ctx context.Context) *EvalRequest {
 	return req
 }
 
+// ResponseTimeout sets a timeout for a response to the request. It is used
+// instead of Opts.Timeout of the connection if it is positive, even if
+// the request has a context.
+func (req *EvalRequest) ResponseTimeout(timeout time.Duration) *EvalRequest {
+	req.respTimeout = timeout
+	return req
+}
+
 // ExecuteRequest helps you to create an execute request object for execution
 // by a Connection.
 type ExecuteRequest struct {
@@ -1354,3 +1508,11 @@ func (req *ExecuteRequest) Context(ctx context.Context) *ExecuteRequest {
 	req.ctx = ctx
 	return req
 }
+
+// ResponseTimeout sets a timeout for a response to the request. It is used
+// instead of Opts.Timeout of the connection if it is positive, even if
+// the request has a context.
+func (req *ExecuteRequest) ResponseTimeout(timeout time.Duration) *ExecuteRequest {
+	req.respTimeout = timeout
+	return req
+}
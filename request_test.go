@@ -8,10 +8,13 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/tarantool/go-iproto"
 	"github.com/vmihailenco/msgpack/v5"
 
 	. "github.com/tarantool/go-tarantool/v2"
+	"github.com/tarantool/go-tarantool/v2/test_helpers"
+	"github.com/tarantool/go-tarantool/v2/test_helpers/fakeserver"
 )
 
 const invalidSpaceMsg = "invalid space"
@@ -64,6 +67,16 @@ func (*ValidSchemeResolver) ResolveSpaceIndex(s, i interface{}) (uint32, uint32,
 
 var resolver ValidSchemeResolver
 
+// NamesSchemeResolver is a resolver that supports space and index names in
+// requests.
+type NamesSchemeResolver struct {
+	ValidSchemeResolver
+}
+
+func (*NamesSchemeResolver) NamesUseSupported() bool {
+	return true
+}
+
 func assertBodyCall(t testing.TB, requests []Request, errorMsg string) {
 	t.Helper()
 
@@ -171,6 +184,150 @@ func TestRequestsInvalidIndex(t *testing.T) {
 	assertBodyCall(t, requests, invalidIndexMsg)
 }
 
+func TestRequestsSpaceAndIndexNames(t *testing.T) {
+	const spaceName = "space"
+	const indexName = "index"
+
+	tests := []struct {
+		req        Request
+		space      interface{}
+		index      interface{}
+		spaceIsKey bool
+	}{
+		{NewSelectRequest(spaceName).Index(indexName), spaceName, indexName, true},
+		{NewSelectRequest(spaceName).Index(validIndex), spaceName, uint64(validIndex), true},
+		{NewSelectRequest(validSpace).Index(indexName), uint64(validSpace), indexName, true},
+		{NewSelectRequest(spaceName), spaceName, uint64(defaultIndex), true},
+		{NewUpdateRequest(spaceName).Index(indexName), spaceName, indexName, true},
+		{NewDeleteRequest(spaceName).Index(indexName), spaceName, indexName, true},
+		{NewInsertRequest(spaceName), spaceName, nil, false},
+		{NewReplaceRequest(spaceName), spaceName, nil, false},
+		{NewUpsertRequest(spaceName), spaceName, nil, false},
+	}
+
+	const spaceIdKey, spaceNameKey = uint64(iproto.IPROTO_SPACE_ID), uint64(0x5e)
+	const indexIdKey, indexNameKey = uint64(iproto.IPROTO_INDEX_ID), uint64(0x5f)
+	for _, test := range tests {
+		t.Run(test.req.Type().String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			err := test.req.Body(&NamesSchemeResolver{}, msgpack.NewEncoder(&buf))
+			require.NoError(t, err)
+
+			var body map[uint64]interface{}
+			require.NoError(t, msgpack.Unmarshal(buf.Bytes(), &body))
+
+			if name, ok := test.space.(string); ok {
+				assert.Equal(t, name, body[spaceNameKey])
+				assert.NotContains(t, body, spaceIdKey)
+			} else {
+				assert.EqualValues(t, test.space, body[spaceIdKey])
+				assert.NotContains(t, body, spaceNameKey)
+			}
+			if name, ok := test.index.(string); ok {
+				assert.Equal(t, name, body[indexNameKey])
+				assert.NotContains(t, body, indexIdKey)
+			} else if test.index != nil {
+				assert.EqualValues(t, test.index, body[indexIdKey])
+				assert.NotContains(t, body, indexNameKey)
+			} else {
+				assert.NotContains(t, body, indexIdKey)
+				assert.NotContains(t, body, indexNameKey)
+			}
+		})
+	}
+}
+
+func TestRequestsSpaceAndIndexNames_fakeserver(t *testing.T) {
+	srv, err := fakeserver.StartWithSpace(fakeserver.Opts{}, fakeserver.Space{
+		Id:     1000,
+		Name:   "users",
+		Format: []fakeserver.Field{{Name: "id", Type: "unsigned"}, {Name: "name", Type: "string"}},
+		Indexes: []fakeserver.Index{
+			{Name: "primary", Parts: []fakeserver.IndexPart{{Field: 0, Type: "unsigned"}}},
+			{Name: "name", Unique: true,
+				Parts: []fakeserver.IndexPart{{Field: 1, Type: "string"}}},
+		},
+	})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	// The schema is not required to send requests with names.
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{
+		Timeout:    5 * time.Second,
+		SkipSchema: true,
+	})
+	defer conn.Close()
+	require.Nil(t, conn.Schema)
+
+	_, err = conn.Do(NewInsertRequest("users").
+		Tuple([]interface{}{uint(1), "alice"})).Get()
+	require.NoError(t, err)
+	_, err = conn.Do(NewReplaceRequest("users").
+		Tuple([]interface{}{uint(2), "bob"})).Get()
+	require.NoError(t, err)
+	_, err = conn.Do(NewUpdateRequest("users").Index("name").Key([]interface{}{"bob"}).
+		Operations(NewOperations().Assign(1, "robert"))).Get()
+	require.NoError(t, err)
+	_, err = conn.Do(NewDeleteRequest("users").Index("primary").
+		Key([]interface{}{uint(1)})).Get()
+	require.NoError(t, err)
+
+	var tuples [][]interface{}
+	err = conn.Do(NewSelectRequest("users").Index("name").
+		Key([]interface{}{"robert"})).GetTyped(&tuples)
+	require.NoError(t, err)
+	assert.Equal(t, [][]interface{}{{int8(2), "robert"}}, tuples)
+
+	tuples = nil
+	err = conn.Do(NewSelectRequest(uint(1000)).Index(uint(0)).
+		Iterator(IterAll)).GetTyped(&tuples)
+	require.NoError(t, err)
+	assert.Equal(t, [][]interface{}{{int8(2), "robert"}}, tuples)
+
+	_, err = conn.Do(NewSelectRequest("unknown")).Get()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Space 'unknown' does not exist")
+
+	_, err = conn.Do(NewSelectRequest("users").Index("unknown")).Get()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "No index 'unknown' is defined in space 'users'")
+}
+
+func TestRequestsSpaceAndIndexNames_noFeature(t *testing.T) {
+	srv, err := fakeserver.Start(fakeserver.Opts{
+		ProtocolInfo: ProtocolInfo{
+			Version:  ProtocolVersion(4),
+			Features: []ProtocolFeature{StreamsFeature, PaginationFeature},
+		},
+	})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{
+		Timeout:    5 * time.Second,
+		SkipSchema: true,
+	})
+	defer conn.Close()
+
+	_, err = conn.Do(NewSelectRequest("users")).Get()
+	assert.EqualError(t, err, "schema is not loaded")
+}
+
+func TestRequestsSpaceAndIndex_nilResolver(t *testing.T) {
+	var buf bytes.Buffer
+	req := NewSelectRequest(Space{Id: validSpace}).Index(uint32(validIndex))
+	require.NoError(t, req.Body(nil, msgpack.NewEncoder(&buf)))
+
+	var body map[uint64]interface{}
+	require.NoError(t, msgpack.Unmarshal(buf.Bytes(), &body))
+	assert.EqualValues(t, validSpace, body[uint64(iproto.IPROTO_SPACE_ID)])
+	assert.EqualValues(t, validIndex, body[uint64(iproto.IPROTO_INDEX_ID)])
+
+	buf.Reset()
+	err := NewSelectRequest("space").Body(nil, msgpack.NewEncoder(&buf))
+	assert.EqualError(t, err, "schema is not loaded")
+}
+
 func TestRequestsTypes(t *testing.T) {
 	tests := []struct {
 		req   Request
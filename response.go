@@ -19,6 +19,8 @@ type Response struct {
 	MetaData []ColumnMetaData
 	SQLInfo  SQLInfo
 	buf      smallBuf
+	// schemaVersion contains a schema version of Tarantool.
+	schemaVersion uint64
 }
 
 type ColumnMetaData struct {
@@ -139,6 +141,10 @@ func (resp *Response) decodeHeader(d *msgpack.Decoder) (err error) {
 				return
 			}
 			resp.Code = uint32(rcode)
+		case iproto.IPROTO_SCHEMA_VERSION:
+			if resp.schemaVersion, err = d.DecodeUint64(); err != nil {
+				return
+			}
 		default:
 			if err = d.Skip(); err != nil {
 				return
@@ -158,6 +164,9 @@ func (resp *Response) decodeBody() (err error) {
 		var serverProtocolInfo ProtocolInfo
 		var feature ProtocolFeature
 		var errorExtendedInfo *BoxError = nil
+		var data []byte
+		var dataErr error
+		var formats map[uint64][]byte
 
 		d := msgpack.NewDecoder(&resp.buf)
 		d.SetMapDecoder(func(dec *msgpack.Decoder) (interface{}, error) {
@@ -174,13 +183,20 @@ func (resp *Response) decodeBody() (err error) {
 			}
 			switch iproto.Key(cd) {
 			case iproto.IPROTO_DATA:
+				start := resp.buf.Offset()
 				var res interface{}
 				var ok bool
-				if res, err = d.DecodeInterface(); err != nil {
+				if res, dataErr = d.DecodeInterface(); dataErr == nil {
+					if resp.Data, ok = res.([]interface{}); !ok {
+						return fmt.Errorf("result is not array: %v", res)
+					}
+				}
+				if data, err = resp.dataValue(d, start, dataErr); err != nil {
 					return err
 				}
-				if resp.Data, ok = res.([]interface{}); !ok {
-					return fmt.Errorf("result is not array: %v", res)
+			case iprotoTupleFormats:
+				if formats, err = decodeTupleFormats(d); err != nil {
+					return err
 				}
 			case iproto.IPROTO_ERROR:
 				if errorExtendedInfo, err = decodeBoxError(d); err != nil {
@@ -247,6 +263,23 @@ func (resp *Response) decodeBody() (err error) {
 				}
 			}
 		}
+		if formats == nil && dataErr != nil {
+			return dataErr
+		}
+		if data != nil && formats != nil {
+			// Tuples are decoded as arrays for backward compatibility.
+			if data, err = resolveTuples(data, formats, true); err != nil {
+				return err
+			}
+			var res interface{}
+			var ok bool
+			if res, err = newBodyDecoder(&smallBuf{b: data}).DecodeInterface(); err != nil {
+				return err
+			}
+			if resp.Data, ok = res.([]interface{}); !ok {
+				return fmt.Errorf("result is not array: %v", res)
+			}
+		}
 		if stmtID != 0 {
 			stmt := &Prepared{
 				StatementID: PreparedID(stmtID),
@@ -281,6 +314,9 @@ func (resp *Response) decodeBodyTyped(res interface{}) (err error) {
 		defer resp.buf.Seek(offset)
 
 		var errorExtendedInfo *BoxError = nil
+		var data []byte
+		var dataErr error
+		var formats map[uint64][]byte
 
 		var l int
 
@@ -299,7 +335,19 @@ func (resp *Response) decodeBodyTyped(res interface{}) (err error) {
 			}
 			switch iproto.Key(cd) {
 			case iproto.IPROTO_DATA:
-				if err = d.Decode(res); err != nil {
+				start := resp.buf.Offset()
+				if acceptsTuples(res) {
+					// Tuples with formats are decoded after the body.
+					data, err = resp.rawValue(d)
+				} else {
+					dataErr = d.Decode(res)
+					data, err = resp.dataValue(d, start, dataErr)
+				}
+				if err != nil {
+					return err
+				}
+			case iprotoTupleFormats:
+				if formats, err = decodeTupleFormats(d); err != nil {
 					return err
 				}
 			case iproto.IPROTO_ERROR:
@@ -328,6 +376,20 @@ func (resp *Response) decodeBodyTyped(res interface{}) (err error) {
 				}
 			}
 		}
+		if formats == nil && dataErr != nil {
+			return dataErr
+		}
+		if data != nil && (formats != nil || acceptsTuples(res)) {
+			if formats != nil {
+				data, err = resolveTuples(data, formats, !acceptsTuples(res))
+				if err != nil {
+					return err
+				}
+			}
+			if err = newBodyDecoder(&smallBuf{b: data}).Decode(res); err != nil {
+				return err
+			}
+		}
 		if resp.Code != OkCode && resp.Code != PushCode {
 			resp.Code &^= uint32(iproto.IPROTO_TYPE_ERROR)
 			err = Error{iproto.Error(resp.Code), resp.Error, errorExtendedInfo}
@@ -336,6 +398,88 @@ func (resp *Response) decodeBodyTyped(res interface{}) (err error) {
 	return
 }
 
+// dataValue returns IPROTO_DATA from the start offset in MessagePack after
+// its decoding. The data is decoded in a single pass if it has no MP_TUPLE
+// values. Otherwise, the decoding fails or the tuples are kept as is, so the
+// data is decoded again after the whole body with IPROTO_TUPLE_FORMATS,
+// which could follow IPROTO_DATA.
+func (resp *Response) dataValue(d *msgpack.Decoder, start int,
+	decodeErr error) ([]byte, error) {
+	if decodeErr == nil {
+		return resp.buf.b[start:resp.buf.Offset()], nil
+	}
+	// Skip the rest of the data.
+	if err := resp.buf.Seek(start); err != nil {
+		return nil, err
+	}
+	return resp.rawValue(d)
+}
+
+// rawValue skips a next value and returns it in MessagePack.
+func (resp *Response) rawValue(d *msgpack.Decoder) ([]byte, error) {
+	start := resp.buf.Offset()
+	if err := d.Skip(); err != nil {
+		return nil, err
+	}
+	return resp.buf.b[start:resp.buf.Offset()], nil
+}
+
+// rawData returns IPROTO_DATA of the body in MessagePack without decoding.
+// The data is a part of the response buffer.
+func (resp *Response) rawData() (data []byte, err error) {
+	if resp.buf.Len() == 0 {
+		return nil, nil
+	}
+	offset := resp.buf.Offset()
+	defer resp.buf.Seek(offset)
+
+	var errorExtendedInfo *BoxError = nil
+	var l int
+
+	d := msgpack.NewDecoder(&resp.buf)
+	if l, err = d.DecodeMapLen(); err != nil {
+		return nil, err
+	}
+	for ; l > 0; l-- {
+		var cd int
+		if cd, err = resp.smallInt(d); err != nil {
+			return nil, err
+		}
+		switch iproto.Key(cd) {
+		case iproto.IPROTO_DATA:
+			if data, err = resp.rawValue(d); err != nil {
+				return nil, err
+			}
+		case iproto.IPROTO_ERROR:
+			if errorExtendedInfo, err = decodeBoxError(d); err != nil {
+				return nil, err
+			}
+		case iproto.IPROTO_ERROR_24:
+			if resp.Error, err = d.DecodeString(); err != nil {
+				return nil, err
+			}
+		default:
+			if err = d.Skip(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if resp.Code != OkCode && resp.Code != PushCode {
+		resp.Code &^= uint32(iproto.IPROTO_TYPE_ERROR)
+		return nil, Error{iproto.Error(resp.Code), resp.Error, errorExtendedInfo}
+	}
+	return data, nil
+}
+
+// newBodyDecoder creates a decoder of a body value.
+func newBodyDecoder(buf *smallBuf) *msgpack.Decoder {
+	d := msgpack.NewDecoder(buf)
+	d.SetMapDecoder(func(dec *msgpack.Decoder) (interface{}, error) {
+		return dec.DecodeUntypedMap()
+	})
+	return d
+}
+
 // String implements Stringer interface.
 func (resp *Response) String() (str string) {
 	if resp.Code == OkCode {
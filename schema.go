@@ -3,6 +3,7 @@ package tarantool
 import (
 	"errors"
 	"fmt"
+	"sync/atomic"
 
 	"github.com/vmihailenco/msgpack/v5"
 	"github.com/vmihailenco/msgpack/v5/msgpcode"
@@ -39,6 +40,10 @@ func msgpackIsString(code byte) bool {
 		code == msgpcode.Str16 || code == msgpcode.Str32
 }
 
+// errSchemaNotLoaded is returned if a space or an index name could not be
+// resolved without a schema.
+var errSchemaNotLoaded = errors.New("schema is not loaded")
+
 // SchemaResolver is an interface for resolving schema details.
 type SchemaResolver interface {
 	// ResolveSpaceIndex returns resolved space and index numbers or an
@@ -46,8 +51,49 @@ type SchemaResolver interface {
 	ResolveSpaceIndex(s interface{}, i interface{}) (spaceNo, indexNo uint32, err error)
 }
 
+// NamesUseResolver is an optional interface of a SchemaResolver. If
+// NamesUseSupported() returns true then space and index names are sent in
+// requests as is (see SpaceAndIndexNamesFeature) and are not passed to
+// ResolveSpaceIndex().
+type NamesUseResolver interface {
+	// NamesUseSupported returns true if space and index names could be sent
+	// in requests as is.
+	NamesUseSupported() bool
+}
+
+// schemaResolver resolves spaces and indexes of requests of a connection.
+type schemaResolver struct {
+	// schema is a loaded schema or nil.
+	schema *Schema
+	// namesUseSupported is true if the server supports space and index
+	// names in requests.
+	namesUseSupported bool
+}
+
+// ResolveSpaceIndex resolves space and index numbers with the schema.
+func (res *schemaResolver) ResolveSpaceIndex(s interface{},
+	i interface{}) (uint32, uint32, error) {
+	return res.schema.ResolveSpaceIndex(s, i)
+}
+
+// NamesUseSupported returns true if the server supports space and index
+// names in requests.
+func (res *schemaResolver) NamesUseSupported() bool {
+	return res.namesUseSupported
+}
+
+// withSchema returns a copy of the resolver with the schema. A resolver is
+// not changed since it is used by requests without a lock.
+func (res *schemaResolver) withSchema(schema *Schema) *schemaResolver {
+	return &schemaResolver{
+		schema:            schema,
+		namesUseSupported: res.namesUseSupported,
+	}
+}
+
 // Schema contains information about spaces and indexes.
 type Schema struct {
+	// Version is a schema version of Tarantool at the moment of loading.
 	Version uint
 	// Spaces is map from space names to spaces.
 	Spaces map[string]*Space
@@ -332,6 +378,24 @@ func (indexField *IndexField) DecodeMsgpack(d *msgpack.Decoder) error {
 	return errors.New("unexpected schema format (index fields)")
 }
 
+// schemaRequest is a request to a system space to load a schema. It is sent
+// without a schema version, so Tarantool never rejects it with
+// ER_WRONG_SCHEMA_VERSION.
+type schemaRequest struct {
+	Request
+}
+
+// selectSchema selects all tuples from the system space and returns a schema
+// version from the response.
+func (conn *Connection) selectSchema(spaceNo uint32, result interface{}) (uint64, error) {
+	req := NewSelectRequest(spaceNo).Limit(maxSchemas)
+	fut := conn.Do(schemaRequest{req})
+	if err := fut.GetTyped(result); err != nil {
+		return 0, err
+	}
+	return fut.resp.schemaVersion, nil
+}
+
 func (conn *Connection) loadSchema() (err error) {
 	schema := new(Schema)
 	schema.SpacesById = make(map[uint32]*Space)
@@ -339,7 +403,7 @@ func (conn *Connection) loadSchema() (err error) {
 
 	// Reload spaces.
 	var spaces []*Space
-	err = conn.SelectTyped(vspaceSpId, 0, 0, maxSchemas, IterAll, []interface{}{}, &spaces)
+	spacesVersion, err := conn.selectSchema(vspaceSpId, &spaces)
 	if err != nil {
 		return err
 	}
@@ -350,10 +414,13 @@ func (conn *Connection) loadSchema() (err error) {
 
 	// Reload indexes.
 	var indexes []*Index
-	err = conn.SelectTyped(vindexSpId, 0, 0, maxSchemas, IterAll, []interface{}{}, &indexes)
+	indexesVersion, err := conn.selectSchema(vindexSpId, &indexes)
 	if err != nil {
 		return err
 	}
+	if spacesVersion != indexesVersion {
+		return errors.New("concurrent schema update")
+	}
 	for _, index := range indexes {
 		spaceId := index.SpaceId
 		if _, ok := schema.SpacesById[spaceId]; ok {
@@ -363,9 +430,12 @@ func (conn *Connection) loadSchema() (err error) {
 			return errors.New("concurrent schema update")
 		}
 	}
+	schema.Version = uint(spacesVersion)
 
 	conn.lockShards()
 	conn.Schema = schema
+	conn.resolver = conn.resolver.withSchema(schema)
+	atomic.StoreUint64(&conn.schemaVersion, spacesVersion)
 	conn.unlockShards()
 
 	return nil
@@ -385,45 +455,21 @@ func (schema *Schema) ResolveSpaceIndex(s interface{}, i interface{}) (uint32, u
 	switch s := s.(type) {
 	case string:
 		if schema == nil {
-			return spaceNo, indexNo, fmt.Errorf("Schema is not loaded")
+			return spaceNo, indexNo, errSchemaNotLoaded
 		}
 		if space, ok = schema.Spaces[s]; !ok {
 			return spaceNo, indexNo, fmt.Errorf("there is no space with name %s", s)
 		}
 		spaceNo = space.Id
-	case uint:
-		spaceNo = uint32(s)
-	case uint64:
-		spaceNo = uint32(s)
-	case uint32:
-		spaceNo = s
-	case uint16:
-		spaceNo = uint32(s)
-	case uint8:
-		spaceNo = uint32(s)
-	case int:
-		spaceNo = uint32(s)
-	case int64:
-		spaceNo = uint32(s)
-	case int32:
-		spaceNo = uint32(s)
-	case int16:
-		spaceNo = uint32(s)
-	case int8:
-		spaceNo = uint32(s)
-	case Space:
-		spaceNo = s.Id
-	case *Space:
-		spaceNo = s.Id
 	default:
-		panic("unexpected type of space param")
+		spaceNo = spaceNumber(s)
 	}
 
 	if i != nil {
 		switch i := i.(type) {
 		case string:
 			if schema == nil {
-				return spaceNo, indexNo, fmt.Errorf("schema is not loaded")
+				return spaceNo, indexNo, errSchemaNotLoaded
 			}
 			if space == nil {
 				if space, ok = schema.SpacesById[spaceNo]; !ok {
@@ -435,34 +481,76 @@ func (schema *Schema) ResolveSpaceIndex(s interface{}, i interface{}) (uint32, u
 				return spaceNo, indexNo, err
 			}
 			indexNo = index.Id
-		case uint:
-			indexNo = uint32(i)
-		case uint64:
-			indexNo = uint32(i)
-		case uint32:
-			indexNo = i
-		case uint16:
-			indexNo = uint32(i)
-		case uint8:
-			indexNo = uint32(i)
-		case int:
-			indexNo = uint32(i)
-		case int64:
-			indexNo = uint32(i)
-		case int32:
-			indexNo = uint32(i)
-		case int16:
-			indexNo = uint32(i)
-		case int8:
-			indexNo = uint32(i)
-		case Index:
-			indexNo = i.Id
-		case *Index:
-			indexNo = i.Id
 		default:
-			panic("unexpected type of index param")
+			indexNo = indexNumber(i)
 		}
 	}
 
 	return spaceNo, indexNo, nil
 }
+
+// spaceNumber returns a number of a space passed as a number or a Space
+// object.
+func spaceNumber(s interface{}) uint32 {
+	switch s := s.(type) {
+	case uint:
+		return uint32(s)
+	case uint64:
+		return uint32(s)
+	case uint32:
+		return s
+	case uint16:
+		return uint32(s)
+	case uint8:
+		return uint32(s)
+	case int:
+		return uint32(s)
+	case int64:
+		return uint32(s)
+	case int32:
+		return uint32(s)
+	case int16:
+		return uint32(s)
+	case int8:
+		return uint32(s)
+	case Space:
+		return s.Id
+	case *Space:
+		return s.Id
+	default:
+		panic("unexpected type of space param")
+	}
+}
+
+// indexNumber returns a number of an index passed as a number or an Index
+// object.
+func indexNumber(i interface{}) uint32 {
+	switch i := i.(type) {
+	case uint:
+		return uint32(i)
+	case uint64:
+		return uint32(i)
+	case uint32:
+		return i
+	case uint16:
+		return uint32(i)
+	case uint8:
+		return uint32(i)
+	case int:
+		return uint32(i)
+	case int64:
+		return uint32(i)
+	case int32:
+		return uint32(i)
+	case int16:
+		return uint32(i)
+	case int8:
+		return uint32(i)
+	case Index:
+		return i.Id
+	case *Index:
+		return i.Id
+	default:
+		panic("unexpected type of index param")
+	}
+}
@@ -0,0 +1,324 @@
+package tarantool
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// DefaultSelectPageSize is a default number of tuples fetched by
+// a SelectIterator with a single request.
+const DefaultSelectPageSize = 1000
+
+// selectContinuation is a way to request a next page.
+type selectContinuation int
+
+const (
+	// continueByPosition uses positions of the last tuple (IPROTO_AFTER_POSITION).
+	continueByPosition selectContinuation = iota
+	// continueByKey uses a key of the last tuple with GT or LT iterator.
+	continueByKey
+	// continueByOffset uses an offset from the first tuple.
+	continueByOffset
+)
+
+// SelectIterator iterates over results of a select request page by page.
+// Pages are fetched lazily by Next() calls.
+//
+// A next page is requested after a position of the last tuple if Tarantool
+// supports the PaginationFeature. Otherwise it is requested by a key of the
+// last tuple for IterAll, IterGe, IterGt, IterLe and IterLt iterators over
+// a unique index, or by an offset for other iterators or if index parts are
+// unknown.
+//
+// Offset and limit of the select request are applied to the whole result.
+//
+//	it := NewSelectIterator(conn, NewSelectRequest("space")).PageSize(100)
+//	for it.Next() {
+//		var tuple Tuple
+//		if err := it.Scan(&tuple); err != nil {
+//			return err
+//		}
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+//
+// SelectIterator is not safe for concurrent use.
+type SelectIterator struct {
+	conn      Connector
+	req       SelectRequest
+	ctx       context.Context
+	pageSize  uint32
+	keyFields []uint32
+
+	continuation selectContinuation
+	started      bool
+	done         bool
+	offset       uint32
+	remaining    uint32
+	fetched      uint32
+	pos          []byte
+	lastKey      []interface{}
+
+	page []msgpack.RawMessage
+	cur  int
+	err  error
+}
+
+// NewSelectIterator creates a new iterator over results of the select
+// request. The request should not be modified after the call. Use
+// pool.NewConnectorAdapter() to iterate with a connection pool.
+func NewSelectIterator(conn Connector, req *SelectRequest) *SelectIterator {
+	it := &SelectIterator{
+		conn:      conn,
+		req:       *req,
+		ctx:       req.ctx,
+		pageSize:  DefaultSelectPageSize,
+		offset:    req.offset,
+		remaining: req.limit,
+		cur:       -1,
+	}
+	it.req.fetchPos = false
+	it.req.after = nil
+	if it.ctx == nil {
+		it.ctx = context.Background()
+	}
+	return it
+}
+
+// PageSize sets a maximum number of tuples fetched with a single request.
+// Note: default value is DefaultSelectPageSize.
+func (it *SelectIterator) PageSize(size uint32) *SelectIterator {
+	if size > 0 {
+		it.pageSize = size
+	}
+	return it
+}
+
+// Context sets a context for requests of the iterator. Next() returns false
+// after the context is done.
+func (it *SelectIterator) Context(ctx context.Context) *SelectIterator {
+	it.ctx = ctx
+	return it
+}
+
+// KeyFields sets field numbers (starting from 0) of the unique index parts.
+// They are used to continue by a key of the last tuple if Tarantool does not
+// support the PaginationFeature. By default they are taken from a schema
+// of a Connection.
+func (it *SelectIterator) KeyFields(fields ...uint32) *SelectIterator {
+	it.keyFields = fields
+	return it
+}
+
+// Next fetches a next tuple. It returns false at the end of data or on an
+// error, use Err() to distinguish them.
+func (it *SelectIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	it.cur++
+	if it.cur < len(it.page) {
+		return true
+	}
+	it.page = nil
+	it.cur = -1
+	if it.done {
+		return false
+	}
+
+	if err := it.fetch(); err != nil {
+		it.err = err
+		return false
+	}
+	if len(it.page) == 0 {
+		return false
+	}
+	it.cur = 0
+	return true
+}
+
+// Scan decodes the current tuple into the result.
+func (it *SelectIterator) Scan(result interface{}) error {
+	if it.cur < 0 || it.cur >= len(it.page) {
+		return fmt.Errorf("no current tuple, Next() must be called before Scan()")
+	}
+	d := msgpack.NewDecoder(bytes.NewReader(it.page[it.cur]))
+	d.SetMapDecoder(func(dec *msgpack.Decoder) (interface{}, error) {
+		return dec.DecodeUntypedMap()
+	})
+	return d.Decode(result)
+}
+
+// Err returns an error happened during the iteration.
+func (it *SelectIterator) Err() error {
+	return it.err
+}
+
+func (it *SelectIterator) fetch() error {
+	if err := it.ctx.Err(); err != nil {
+		return err
+	}
+	if !it.started {
+		it.started = true
+		it.continuation = it.initialContinuation()
+	}
+
+	size := it.pageSize
+	if it.remaining < size {
+		size = it.remaining
+	}
+	if size == 0 {
+		it.done = true
+		return nil
+	}
+
+	req := it.req
+	req.ctx = it.ctx
+	req.offset = it.offset
+	req.limit = size
+	switch it.continuation {
+	case continueByPosition:
+		req.fetchPos = true
+		if it.pos != nil {
+			req.after = it.pos
+		}
+	case continueByKey:
+		if it.lastKey != nil {
+			req.iterator, _ = nextKeyIterator(it.req.iterator)
+			req.isIteratorSet = true
+			req.key = it.lastKey
+		}
+	}
+
+	var page []msgpack.RawMessage
+	fut := it.conn.Do(&req)
+	if err := fut.GetTyped(&page); err != nil {
+		return err
+	}
+	it.page = page
+	it.fetched += uint32(len(page))
+	it.remaining -= uint32(len(page))
+	if uint32(len(page)) < size || it.remaining == 0 {
+		it.done = true
+		return nil
+	}
+
+	if it.continuation == continueByPosition {
+		if fut.resp != nil && len(fut.resp.Pos) > 0 {
+			it.pos = fut.resp.Pos
+			it.offset = 0
+			return nil
+		}
+		// Tarantool ignores the position request without the pagination
+		// support.
+		it.continuation = it.fallbackContinuation()
+	}
+
+	switch it.continuation {
+	case continueByKey:
+		key, err := it.tupleKey(page[len(page)-1])
+		if err != nil {
+			return err
+		}
+		it.lastKey = key
+		it.offset = 0
+	case continueByOffset:
+		it.offset = it.req.offset + it.fetched
+	}
+	return nil
+}
+
+// initialContinuation returns a continuation for a connection with a known
+// protocol info.
+func (it *SelectIterator) initialContinuation() selectContinuation {
+	type protocolInfoGetter interface {
+		ServerProtocolInfo() ProtocolInfo
+	}
+
+	if getter, ok := it.conn.(protocolInfoGetter); ok {
+		for _, feature := range getter.ServerProtocolInfo().Features {
+			if feature == PaginationFeature {
+				return continueByPosition
+			}
+		}
+		return it.fallbackContinuation()
+	}
+	return continueByPosition
+}
+
+func (it *SelectIterator) fallbackContinuation() selectContinuation {
+	if _, ok := nextKeyIterator(it.req.iterator); !ok {
+		return continueByOffset
+	}
+	if it.keyFields == nil {
+		it.keyFields = it.schemaKeyFields()
+	}
+	if len(it.keyFields) == 0 {
+		return continueByOffset
+	}
+	return continueByKey
+}
+
+// schemaKeyFields returns field numbers of the index parts from a schema of
+// a Connection or nil.
+func (it *SelectIterator) schemaKeyFields() []uint32 {
+	conn, ok := it.conn.(*Connection)
+	if !ok || conn.Schema == nil {
+		return nil
+	}
+	index := it.req.index
+	if index == nil {
+		index = uint32(0)
+	}
+	spaceNo, indexNo, err := conn.Schema.ResolveSpaceIndex(it.req.space, index)
+	if err != nil {
+		return nil
+	}
+	space, ok := conn.Schema.SpacesById[spaceNo]
+	if !ok {
+		return nil
+	}
+	// A key of a non-unique index does not identify a tuple.
+	idx, ok := space.IndexesById[indexNo]
+	if !ok || !idx.Unique {
+		return nil
+	}
+
+	fields := make([]uint32, len(idx.Fields))
+	for i, field := range idx.Fields {
+		fields[i] = field.Id
+	}
+	return fields
+}
+
+func (it *SelectIterator) tupleKey(raw msgpack.RawMessage) ([]interface{}, error) {
+	var tuple []interface{}
+	if err := msgpack.Unmarshal(raw, &tuple); err != nil {
+		return nil, fmt.Errorf("unable to decode a tuple: %w", err)
+	}
+
+	key := make([]interface{}, len(it.keyFields))
+	for i, field := range it.keyFields {
+		if int(field) >= len(tuple) {
+			return nil, fmt.Errorf("tuple has no key field %d", field)
+		}
+		key[i] = tuple[field]
+	}
+	return key, nil
+}
+
+// nextKeyIterator returns an iterator to continue after a key of the last
+// tuple or false if the continuation by a key is not possible.
+func nextKeyIterator(iterator Iter) (Iter, bool) {
+	switch iterator {
+	case IterAll, IterGe, IterGt:
+		return IterGt, true
+	case IterLe, IterLt:
+		return IterLt, true
+	}
+	return iterator, false
+}
@@ -0,0 +1,270 @@
+package tarantool_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/tarantool/go-tarantool/v2"
+	"github.com/tarantool/go-tarantool/v2/test_helpers"
+	"github.com/tarantool/go-tarantool/v2/test_helpers/fakeserver"
+)
+
+const iteratorTuplesCount = 25
+
+// selectCounter counts sent select requests.
+type selectCounter struct {
+	mutex sync.Mutex
+	count int
+}
+
+func (c *selectCounter) Observe(event RequestEvent) {
+	if _, ok := event.Request.(*SelectRequest); ok && event.Kind == RequestSent {
+		c.mutex.Lock()
+		c.count++
+		c.mutex.Unlock()
+	}
+}
+
+func (c *selectCounter) get() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.count
+}
+
+func startIteratorServer(t *testing.T, pagination bool) *fakeserver.Server {
+	t.Helper()
+
+	opts := fakeserver.Opts{}
+	if !pagination {
+		opts.ProtocolInfo = ProtocolInfo{
+			Auth:     ChapSha1Auth,
+			Version:  ProtocolVersion(3),
+			Features: []ProtocolFeature{StreamsFeature, TransactionsFeature},
+		}
+	}
+	srv, err := fakeserver.StartWithSpace(opts, fakeserver.Space{
+		Id:   1000,
+		Name: "iterator",
+		Format: []fakeserver.Field{
+			{Name: "id", Type: "unsigned"},
+			{Name: "group", Type: "unsigned"},
+		},
+		Indexes: []fakeserver.Index{
+			{Name: "primary", Parts: []fakeserver.IndexPart{{Field: 0, Type: "unsigned"}}},
+			{Name: "group", Parts: []fakeserver.IndexPart{{Field: 1, Type: "unsigned"}}},
+		},
+	})
+	require.NoError(t, err)
+	return srv
+}
+
+func connectIteratorServer(t *testing.T, srv *fakeserver.Server,
+	counter *selectCounter) *Connection {
+	t.Helper()
+
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{
+		Timeout:  5 * time.Second,
+		Observer: counter,
+	})
+	for i := 1; i <= iteratorTuplesCount; i++ {
+		_, err := conn.Do(NewInsertRequest("iterator").
+			Tuple([]interface{}{uint(i), uint(i % 2)})).Get()
+		require.NoError(t, err)
+	}
+	return conn
+}
+
+func collectIds(t *testing.T, it *SelectIterator) []uint64 {
+	t.Helper()
+
+	ids := []uint64{}
+	for it.Next() {
+		var tuple struct {
+			_msgpack struct{} `msgpack:",asArray"` //nolint: structcheck,unused
+			Id       uint64
+			Group    uint64
+		}
+		require.NoError(t, it.Scan(&tuple))
+		ids = append(ids, tuple.Id)
+	}
+	require.NoError(t, it.Err())
+	return ids
+}
+
+func idsRange(from, to uint64) []uint64 {
+	ids := []uint64{}
+	if from <= to {
+		for i := from; i <= to; i++ {
+			ids = append(ids, i)
+		}
+	} else {
+		for i := from; i >= to; i-- {
+			ids = append(ids, i)
+		}
+	}
+	return ids
+}
+
+func TestSelectIterator(t *testing.T) {
+	for _, pagination := range []bool{true, false} {
+		t.Run(fmt.Sprintf("pagination=%t", pagination), func(t *testing.T) {
+			srv := startIteratorServer(t, pagination)
+			defer srv.Close()
+
+			counter := &selectCounter{}
+			conn := connectIteratorServer(t, srv, counter)
+			defer conn.Close()
+
+			before := counter.get()
+			it := NewSelectIterator(conn, NewSelectRequest("iterator")).PageSize(10)
+			assert.Equal(t, idsRange(1, iteratorTuplesCount), collectIds(t, it))
+			assert.Equal(t, 3, counter.get()-before)
+
+			// Without more data and requests.
+			assert.False(t, it.Next())
+			assert.NoError(t, it.Err())
+			assert.Equal(t, 3, counter.get()-before)
+		})
+	}
+}
+
+func TestSelectIterator_iterators(t *testing.T) {
+	cases := []struct {
+		name     string
+		iterator Iter
+		key      uint
+		expected []uint64
+	}{
+		{"all", IterAll, 0, idsRange(1, 25)},
+		{"ge", IterGe, 5, idsRange(5, 25)},
+		{"gt", IterGt, 5, idsRange(6, 25)},
+		{"le", IterLe, 20, idsRange(20, 1)},
+		{"lt", IterLt, 20, idsRange(19, 1)},
+	}
+
+	for _, pagination := range []bool{true, false} {
+		srv := startIteratorServer(t, pagination)
+		conn := connectIteratorServer(t, srv, &selectCounter{})
+
+		for _, tc := range cases {
+			name := fmt.Sprintf("%s/pagination=%t", tc.name, pagination)
+			t.Run(name, func(t *testing.T) {
+				req := NewSelectRequest("iterator").Iterator(tc.iterator)
+				if tc.iterator != IterAll {
+					req = req.Key([]interface{}{tc.key})
+				}
+				it := NewSelectIterator(conn, req).PageSize(4)
+				assert.Equal(t, tc.expected, collectIds(t, it))
+			})
+		}
+
+		conn.Close()
+		srv.Close()
+	}
+}
+
+func TestSelectIterator_nonUniqueIndex(t *testing.T) {
+	for _, pagination := range []bool{true, false} {
+		t.Run(fmt.Sprintf("pagination=%t", pagination), func(t *testing.T) {
+			srv := startIteratorServer(t, pagination)
+			defer srv.Close()
+
+			conn := connectIteratorServer(t, srv, &selectCounter{})
+			defer conn.Close()
+
+			req := NewSelectRequest("iterator").
+				Index("group").
+				Iterator(IterEq).
+				Key([]interface{}{uint(0)})
+			it := NewSelectIterator(conn, req).PageSize(3)
+
+			expected := []uint64{}
+			for i := uint64(2); i <= iteratorTuplesCount; i += 2 {
+				expected = append(expected, i)
+			}
+			assert.Equal(t, expected, collectIds(t, it))
+		})
+	}
+}
+
+func TestSelectIterator_offsetLimit(t *testing.T) {
+	for _, pagination := range []bool{true, false} {
+		t.Run(fmt.Sprintf("pagination=%t", pagination), func(t *testing.T) {
+			srv := startIteratorServer(t, pagination)
+			defer srv.Close()
+
+			counter := &selectCounter{}
+			conn := connectIteratorServer(t, srv, counter)
+			defer conn.Close()
+
+			before := counter.get()
+			req := NewSelectRequest("iterator").Offset(3).Limit(12)
+			it := NewSelectIterator(conn, req).PageSize(5)
+			assert.Equal(t, idsRange(4, 15), collectIds(t, it))
+			assert.Equal(t, 3, counter.get()-before)
+		})
+	}
+}
+
+func TestSelectIterator_keyFields(t *testing.T) {
+	srv := startIteratorServer(t, false)
+	defer srv.Close()
+
+	conn := connectIteratorServer(t, srv, &selectCounter{})
+	defer conn.Close()
+
+	adapter := &connectorWithoutSchema{Connection: conn}
+	it := NewSelectIterator(adapter, NewSelectRequest(uint(1000)).Iterator(IterGe).
+		Key([]interface{}{uint(10)})).PageSize(7).KeyFields(0)
+	assert.Equal(t, idsRange(10, 25), collectIds(t, it))
+}
+
+// connectorWithoutSchema hides a type of a connection from a SelectIterator.
+type connectorWithoutSchema struct {
+	*Connection
+}
+
+func TestSelectIterator_context(t *testing.T) {
+	srv := startIteratorServer(t, true)
+	defer srv.Close()
+
+	conn := connectIteratorServer(t, srv, &selectCounter{})
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	it := NewSelectIterator(conn, NewSelectRequest("iterator")).
+		PageSize(10).
+		Context(ctx)
+
+	count := 0
+	for it.Next() {
+		count++
+		if count == 5 {
+			cancel()
+		}
+	}
+	assert.Equal(t, 10, count)
+	assert.ErrorIs(t, it.Err(), context.Canceled)
+}
+
+func TestSelectIterator_error(t *testing.T) {
+	srv := startIteratorServer(t, true)
+	defer srv.Close()
+
+	conn := connectIteratorServer(t, srv, &selectCounter{})
+	defer conn.Close()
+
+	it := NewSelectIterator(conn, NewSelectRequest(uint(2000)))
+	assert.False(t, it.Next())
+	assert.Error(t, it.Err())
+
+	var tuple []interface{}
+	assert.Error(t, it.Scan(&tuple))
+}
@@ -0,0 +1,192 @@
+package sqldriver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+
+	"github.com/tarantool/go-tarantool/v2"
+)
+
+var (
+	errTxInProgress = errors.New("a transaction is already in progress")
+	errTxReadOnly   = errors.New("read-only transactions are not supported")
+)
+
+// conn implements driver.Conn. database/sql does not use a connection
+// concurrently, so it has no locks.
+type conn struct {
+	tnt *tarantool.Connection
+	// stream is a stream of an active transaction or nil.
+	stream *tarantool.Stream
+}
+
+var (
+	_ driver.Conn               = (*conn)(nil)
+	_ driver.ConnPrepareContext = (*conn)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.Pinger             = (*conn)(nil)
+	_ driver.SessionResetter    = (*conn)(nil)
+)
+
+// do sends a request in the stream of an active transaction or without a
+// stream and waits for a response.
+func (c *conn) do(req tarantool.Request) (*tarantool.Response, error) {
+	if c.tnt.ClosedNow() {
+		return nil, driver.ErrBadConn
+	}
+
+	var fut *tarantool.Future
+	if c.stream != nil {
+		fut = c.stream.Do(req)
+	} else {
+		fut = c.tnt.Do(req)
+	}
+	return fut.Get()
+}
+
+// Prepare returns a prepared statement.
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext returns a prepared statement.
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	resp, err := c.do(tarantool.NewPrepareRequest(query).Context(ctx))
+	if err != nil {
+		return nil, err
+	}
+	prepared, err := tarantool.NewPreparedFromResponse(c.tnt, resp)
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{conn: c, prepared: prepared}, nil
+}
+
+// Close closes the connection.
+func (c *conn) Close() error {
+	return c.tnt.Close()
+}
+
+// Begin starts a transaction.
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx starts a transaction in a new stream. All statements are executed
+// in the stream until the transaction is finished.
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if c.stream != nil {
+		return nil, errTxInProgress
+	}
+	if opts.ReadOnly {
+		return nil, errTxReadOnly
+	}
+	level, err := isolationLevel(sql.IsolationLevel(opts.Isolation))
+	if err != nil {
+		return nil, err
+	}
+	if c.tnt.ClosedNow() {
+		return nil, driver.ErrBadConn
+	}
+
+	stream, err := c.tnt.NewStream()
+	if err != nil {
+		return nil, err
+	}
+	req := tarantool.NewBeginRequest().TxnIsolation(level).Context(ctx)
+	if _, err := stream.Do(req).Get(); err != nil {
+		return nil, err
+	}
+	c.stream = stream
+	return &tx{conn: c}, nil
+}
+
+// ExecContext executes a query without a preparation.
+func (c *conn) ExecContext(ctx context.Context, query string,
+	args []driver.NamedValue) (driver.Result, error) {
+	req := tarantool.NewExecuteRequest(query).Args(binds(args)).Context(ctx)
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return newResult(resp), nil
+}
+
+// QueryContext executes a query without a preparation.
+func (c *conn) QueryContext(ctx context.Context, query string,
+	args []driver.NamedValue) (driver.Rows, error) {
+	req := tarantool.NewExecuteRequest(query).Args(binds(args)).Context(ctx)
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return newRows(resp), nil
+}
+
+// Ping sends a ping request.
+func (c *conn) Ping(ctx context.Context) error {
+	_, err := c.do(tarantool.NewPingRequest().Context(ctx))
+	return err
+}
+
+// ResetSession marks closed connections as bad ones, so database/sql does
+// not reuse them.
+func (c *conn) ResetSession(ctx context.Context) error {
+	if c.tnt.ClosedNow() {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+// isolationLevel converts a database/sql isolation level into a Tarantool
+// transaction isolation level. The best-effort level of Tarantool does not
+// guarantee serializability, so sql.LevelSerializable is not supported.
+func isolationLevel(level sql.IsolationLevel) (tarantool.TxnIsolationLevel, error) {
+	switch level {
+	case sql.LevelDefault:
+		return tarantool.DefaultIsolationLevel, nil
+	case sql.LevelReadCommitted:
+		return tarantool.ReadCommittedLevel, nil
+	}
+	return 0, fmt.Errorf("unsupported isolation level: %s", level)
+}
+
+// binds converts arguments into SQL binds. Named arguments are converted
+// into tarantool.KeyValueBind, they match :name parameters in a query.
+func binds(args []driver.NamedValue) []interface{} {
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			values[i] = tarantool.KeyValueBind{Key: arg.Name, Value: arg.Value}
+		} else {
+			values[i] = arg.Value
+		}
+	}
+	return values
+}
+
+// tx implements driver.Tx.
+type tx struct {
+	conn *conn
+}
+
+// Commit commits the transaction.
+func (t *tx) Commit() error {
+	return t.finish(tarantool.NewCommitRequest())
+}
+
+// Rollback rolls back the transaction.
+func (t *tx) Rollback() error {
+	return t.finish(tarantool.NewRollbackRequest())
+}
+
+func (t *tx) finish(req tarantool.Request) error {
+	_, err := t.conn.do(req)
+	t.conn.stream = nil
+	return err
+}
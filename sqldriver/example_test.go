@@ -0,0 +1,59 @@
+package sqldriver_test
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/tarantool/go-tarantool/v2/sqldriver"
+)
+
+func Example() {
+	db, err := sql.Open(sqldriver.DriverName, "tarantool://test:test@"+server)
+	if err != nil {
+		fmt.Printf("Failed to open: %s\n", err)
+		return
+	}
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE example_users (id INTEGER PRIMARY KEY, name STRING);")
+	if err != nil {
+		fmt.Printf("Failed to create a table: %s\n", err)
+		return
+	}
+	defer db.Exec("DROP TABLE example_users;")
+
+	_, err = db.Exec("INSERT INTO example_users VALUES (?, ?), (:id, :name);",
+		1, "first", sql.Named("id", 2), sql.Named("name", "second"))
+	if err != nil {
+		fmt.Printf("Failed to insert: %s\n", err)
+		return
+	}
+
+	var name string
+	err = db.QueryRow("SELECT name FROM example_users WHERE id = ?;", 2).Scan(&name)
+	if err != nil {
+		fmt.Printf("Failed to select: %s\n", err)
+		return
+	}
+	fmt.Println(name)
+}
+
+func ExampleNewConnector() {
+	db := sql.OpenDB(sqldriver.NewConnector(server, opts))
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		fmt.Printf("Failed to begin a transaction: %s\n", err)
+		return
+	}
+	// Statements are executed in a stream of the transaction.
+	if _, err := tx.Exec("SELECT 1;"); err != nil {
+		tx.Rollback()
+		fmt.Printf("Failed to execute: %s\n", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		fmt.Printf("Failed to commit: %s\n", err)
+	}
+}
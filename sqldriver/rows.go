@@ -0,0 +1,168 @@
+package sqldriver
+
+import (
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/tarantool/go-tarantool/v2"
+)
+
+var errNoAutoincrementIds = errors.New("no autoincrement ids in the result")
+
+// result implements driver.Result with SQL info of a response.
+type result struct {
+	info tarantool.SQLInfo
+}
+
+func newResult(resp *tarantool.Response) *result {
+	return &result{info: resp.SQLInfo}
+}
+
+// LastInsertId returns the last autoincrement id generated by the statement.
+func (r *result) LastInsertId() (int64, error) {
+	ids := r.info.InfoAutoincrementIds
+	if len(ids) == 0 {
+		return 0, errNoAutoincrementIds
+	}
+	return int64(ids[len(ids)-1]), nil
+}
+
+// RowsAffected returns a number of rows affected by the statement.
+func (r *result) RowsAffected() (int64, error) {
+	return int64(r.info.AffectedCount), nil
+}
+
+// rows implements driver.Rows with data of a response. Column types are
+// taken from the response metadata.
+type rows struct {
+	metadata []tarantool.ColumnMetaData
+	data     []interface{}
+	pos      int
+}
+
+var (
+	_ driver.Rows                           = (*rows)(nil)
+	_ driver.RowsColumnTypeDatabaseTypeName = (*rows)(nil)
+	_ driver.RowsColumnTypeNullable         = (*rows)(nil)
+	_ driver.RowsColumnTypeScanType         = (*rows)(nil)
+)
+
+func newRows(resp *tarantool.Response) *rows {
+	return &rows{metadata: resp.MetaData, data: resp.Data}
+}
+
+// Columns returns column names.
+func (r *rows) Columns() []string {
+	columns := make([]string, len(r.metadata))
+	for i, meta := range r.metadata {
+		columns[i] = meta.FieldName
+	}
+	return columns
+}
+
+// Close closes the rows.
+func (r *rows) Close() error {
+	r.data = nil
+	return nil
+}
+
+// Next fills the next row.
+func (r *rows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.data) {
+		return io.EOF
+	}
+	row, ok := r.data[r.pos].([]interface{})
+	if !ok {
+		return errors.New("unexpected row format")
+	}
+	r.pos++
+
+	for i := range dest {
+		if i < len(row) {
+			dest[i] = value(row[i])
+		} else {
+			dest[i] = nil
+		}
+	}
+	return nil
+}
+
+// ColumnTypeDatabaseTypeName returns a Tarantool type name of the column in
+// upper case, like "INTEGER" or "STRING".
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	return strings.ToUpper(r.metadata[index].FieldType)
+}
+
+// ColumnTypeNullable returns a nullable flag of the column. The flag is sent
+// by Tarantool only with the sql_full_metadata session setting.
+func (r *rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	return r.metadata[index].FieldIsNullable, true
+}
+
+var (
+	scanTypeInt64   = reflect.TypeOf(int64(0))
+	scanTypeUint64  = reflect.TypeOf(uint64(0))
+	scanTypeFloat64 = reflect.TypeOf(float64(0))
+	scanTypeString  = reflect.TypeOf("")
+	scanTypeBool    = reflect.TypeOf(false)
+	scanTypeBytes   = reflect.TypeOf([]byte(nil))
+	scanTypeAny     = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// ColumnTypeScanType returns a Go type suitable to scan values of the
+// column.
+func (r *rows) ColumnTypeScanType(index int) reflect.Type {
+	switch strings.ToLower(r.metadata[index].FieldType) {
+	case "integer":
+		return scanTypeInt64
+	case "unsigned":
+		return scanTypeUint64
+	case "double", "number":
+		return scanTypeFloat64
+	case "string", "text":
+		return scanTypeString
+	case "boolean":
+		return scanTypeBool
+	case "varbinary":
+		return scanTypeBytes
+	}
+	return scanTypeAny
+}
+
+// value converts a decoded value into a driver.Value. Integers are converted
+// into int64 unless they overflow it, other types are passed as is.
+func value(v interface{}) driver.Value {
+	switch v := v.(type) {
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int:
+		return int64(v)
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case uint:
+		if uint64(v) <= math.MaxInt64 {
+			return int64(v)
+		}
+		return uint64(v)
+	case uint64:
+		if v <= math.MaxInt64 {
+			return int64(v)
+		}
+		return v
+	case float32:
+		return float64(v)
+	}
+	return v
+}
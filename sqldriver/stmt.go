@@ -0,0 +1,79 @@
+package sqldriver
+
+import (
+	"context"
+	"database/sql/driver"
+
+	"github.com/tarantool/go-tarantool/v2"
+)
+
+// stmt implements driver.Stmt on top of tarantool.Prepared.
+type stmt struct {
+	conn     *conn
+	prepared *tarantool.Prepared
+}
+
+var (
+	_ driver.Stmt             = (*stmt)(nil)
+	_ driver.StmtExecContext  = (*stmt)(nil)
+	_ driver.StmtQueryContext = (*stmt)(nil)
+)
+
+// Close unprepares the statement.
+func (s *stmt) Close() error {
+	_, err := s.conn.do(tarantool.NewUnprepareRequest(s.prepared))
+	return err
+}
+
+// NumInput returns -1: named parameters could be used several times in
+// a query, so database/sql can not check a number of arguments.
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+// Exec executes the prepared statement.
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+// ExecContext executes the prepared statement.
+func (s *stmt) ExecContext(ctx context.Context,
+	args []driver.NamedValue) (driver.Result, error) {
+	resp, err := s.execute(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return newResult(resp), nil
+}
+
+// Query executes the prepared statement.
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+// QueryContext executes the prepared statement.
+func (s *stmt) QueryContext(ctx context.Context,
+	args []driver.NamedValue) (driver.Rows, error) {
+	resp, err := s.execute(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return newRows(resp), nil
+}
+
+func (s *stmt) execute(ctx context.Context,
+	args []driver.NamedValue) (*tarantool.Response, error) {
+	req := tarantool.NewExecutePreparedRequest(s.prepared).
+		Args(binds(args)).
+		Context(ctx)
+	return s.conn.do(req)
+}
+
+// namedValues converts positional values into named values without names.
+func namedValues(args []driver.Value) []driver.NamedValue {
+	values := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		values[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return values
+}
@@ -132,6 +132,14 @@ func (req *BeginRequest) Context(ctx context.Context) *BeginRequest {
 	return req
 }
 
+// ResponseTimeout sets a timeout for a response to the request. It is used
+// instead of Opts.Timeout of the connection if it is positive, even if
+// the request has a context.
+func (req *BeginRequest) ResponseTimeout(timeout time.Duration) *BeginRequest {
+	req.respTimeout = timeout
+	return req
+}
+
 // CommitRequest helps you to create a commit request object for execution
 // by a Stream.
 // Commit request can not be processed out of stream.
@@ -162,6 +170,14 @@ func (req *CommitRequest) Context(ctx context.Context) *CommitRequest {
 	return req
 }
 
+// ResponseTimeout sets a timeout for a response to the request. It is used
+// instead of Opts.Timeout of the connection if it is positive, even if
+// the request has a context.
+func (req *CommitRequest) ResponseTimeout(timeout time.Duration) *CommitRequest {
+	req.respTimeout = timeout
+	return req
+}
+
 // RollbackRequest helps you to create a rollback request object for execution
 // by a Stream.
 // Rollback request can not be processed out of stream.
@@ -192,6 +208,14 @@ func (req *RollbackRequest) Context(ctx context.Context) *RollbackRequest {
 	return req
 }
 
+// ResponseTimeout sets a timeout for a response to the request. It is used
+// instead of Opts.Timeout of the connection if it is positive, even if
+// the request has a context.
+func (req *RollbackRequest) ResponseTimeout(timeout time.Duration) *RollbackRequest {
+	req.respTimeout = timeout
+	return req
+}
+
 // Do verifies, sends the request and returns a future.
 //
 // An error is returned if the request was formed incorrectly, or failure to
@@ -206,3 +230,19 @@ func (s *Stream) Do(req Request) *Future {
 	}
 	return s.Conn.send(req, s.Id)
 }
+
+// DoBatch verifies, sends the requests and returns their futures in the
+// order of the requests. See Connection.DoBatch().
+func (s *Stream) DoBatch(reqs []Request) []*Future {
+	futs := make([]*Future, len(reqs))
+	for i, req := range reqs {
+		if connectedReq, ok := req.(ConnectedRequest); ok {
+			if connectedReq.Conn() != s.Conn {
+				futs[i] = NewFuture()
+				futs[i].SetError(errUnknownStreamRequest)
+			}
+		}
+	}
+	s.Conn.sendBatch(reqs, futs, s.Id)
+	return futs
+}
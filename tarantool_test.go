@@ -294,6 +294,71 @@ func BenchmarkClientFutureTyped(b *testing.B) {
 	}
 }
 
+func BenchmarkClientFutureReplace(b *testing.B) {
+	conn := test_helpers.ConnectWithValidation(b, server, opts)
+	defer conn.Close()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i += N {
+		var fs [N]*Future
+		for j := 0; j < N; j++ {
+			req := NewReplaceRequest(spaceNo).
+				Tuple([]interface{}{uint(1111 + j), "hello", "world"})
+			fs[j] = conn.Do(req)
+		}
+		for j := 0; j < N; j++ {
+			if _, err := fs[j].Get(); err != nil {
+				b.Error(err)
+			}
+		}
+	}
+}
+
+func BenchmarkClientBatchReplace(b *testing.B) {
+	conn := test_helpers.ConnectWithValidation(b, server, opts)
+	defer conn.Close()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i += N {
+		reqs := make([]Request, N)
+		for j := 0; j < N; j++ {
+			reqs[j] = NewReplaceRequest(spaceNo).
+				Tuple([]interface{}{uint(1111 + j), "hello", "world"})
+		}
+		for _, fut := range conn.DoBatch(reqs) {
+			if _, err := fut.Get(); err != nil {
+				b.Error(err)
+			}
+		}
+	}
+}
+
+func BenchmarkClientBatchReplaceParallel(b *testing.B) {
+	conn := test_helpers.ConnectWithValidation(b, server, opts)
+	defer conn.Close()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		reqs := make([]Request, 0, N)
+		flush := func() {
+			for _, fut := range conn.DoBatch(reqs) {
+				if _, err := fut.Get(); err != nil {
+					b.Error(err)
+				}
+			}
+			reqs = reqs[:0]
+		}
+		for pb.Next() {
+			reqs = append(reqs, NewReplaceRequest(spaceNo).
+				Tuple([]interface{}{uint(1111 + len(reqs)), "hello", "world"}))
+			if len(reqs) == N {
+				flush()
+			}
+		}
+		flush()
+	})
+}
+
 func BenchmarkClientFutureParallel(b *testing.B) {
 	var err error
 
@@ -3247,13 +3312,16 @@ func TestConnectionProtocolInfoSupported(t *testing.T) {
 	require.Equal(t,
 		clientProtocolInfo,
 		ProtocolInfo{
-			Version: ProtocolVersion(4),
+			Version: ProtocolVersion(6),
 			Features: []ProtocolFeature{
 				StreamsFeature,
 				TransactionsFeature,
 				ErrorExtensionFeature,
 				WatchersFeature,
 				PaginationFeature,
+				SpaceAndIndexNamesFeature,
+				DMLTupleExtensionFeature,
+				CallRetTupleExtensionFeature,
 			},
 		})
 
@@ -3364,13 +3432,16 @@ func TestConnectionProtocolInfoUnsupported(t *testing.T) {
 	require.Equal(t,
 		clientProtocolInfo,
 		ProtocolInfo{
-			Version: ProtocolVersion(4),
+			Version: ProtocolVersion(6),
 			Features: []ProtocolFeature{
 				StreamsFeature,
 				TransactionsFeature,
 				ErrorExtensionFeature,
 				WatchersFeature,
 				PaginationFeature,
+				SpaceAndIndexNamesFeature,
+				DMLTupleExtensionFeature,
+				CallRetTupleExtensionFeature,
 			},
 		})
 
@@ -3669,21 +3740,6 @@ func TestConnection_NewWatcher(t *testing.T) {
 	}
 }
 
-func TestConnection_NewWatcher_noWatchersFeature(t *testing.T) {
-	const key = "TestConnection_NewWatcher_noWatchersFeature"
-	connOpts := opts.Clone()
-	connOpts.RequiredProtocolInfo.Features = []ProtocolFeature{}
-	conn := test_helpers.ConnectWithValidation(t, server, connOpts)
-	defer conn.Close()
-
-	watcher, err := conn.NewWatcher(key, func(event WatchEvent) {})
-	require.Nilf(t, watcher, "watcher must not be created")
-	require.NotNilf(t, err, "an error is expected")
-	expected := "the feature WatchersFeature must be required by connection " +
-		"options to create a watcher"
-	require.Equal(t, expected, err.Error())
-}
-
 func TestConnection_NewWatcher_reconnect(t *testing.T) {
 	test_helpers.SkipIfWatchersUnsupported(t)
 
@@ -4035,6 +4091,291 @@ func TestConnect_schema_update(t *testing.T) {
 	}
 }
 
+const recreateSchemaReloadSpace = `
+local id = ...
+if box.space.schema_reload ~= nil then
+    box.space.schema_reload:drop()
+end
+local s = box.schema.space.create('schema_reload', {id = id})
+s:create_index('primary', {parts = {1, 'uint'}})
+`
+
+const dropSchemaReloadSpace = `
+if box.space.schema_reload ~= nil then
+    box.space.schema_reload:drop()
+end
+`
+
+func waitSchemaSpace(t *testing.T, conn *Connection, name string, id uint32) {
+	t.Helper()
+
+	for i := 0; i < 100; i++ {
+		_, err := conn.Do(NewPingRequest()).Get()
+		require.Nilf(t, err, "failed to ping")
+
+		if space, ok := conn.Schema.Spaces[name]; ok && space.Id == id {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("space %s with id %d was not found in the schema", name, id)
+}
+
+func TestConnection_SchemaReload_onSchemaVersionChange(t *testing.T) {
+	conn := test_helpers.ConnectWithValidation(t, server, opts)
+	defer conn.Close()
+
+	defer conn.Do(NewEvalRequest(dropSchemaReloadSpace)).Get()
+
+	_, err := conn.Do(NewEvalRequest(recreateSchemaReloadSpace).
+		Args([]interface{}{650})).Get()
+	require.Nilf(t, err, "failed to create a space")
+
+	waitSchemaSpace(t, conn, "schema_reload", 650)
+}
+
+func TestConnection_SchemaReload_onWrongSchemaVersion(t *testing.T) {
+	conn := test_helpers.ConnectWithValidation(t, server, opts)
+	defer conn.Close()
+	ddlConn := test_helpers.ConnectWithValidation(t, server, opts)
+	defer ddlConn.Close()
+
+	defer ddlConn.Do(NewEvalRequest(dropSchemaReloadSpace)).Get()
+
+	_, err := ddlConn.Do(NewEvalRequest(recreateSchemaReloadSpace).
+		Args([]interface{}{650})).Get()
+	require.Nilf(t, err, "failed to create a space")
+	waitSchemaSpace(t, conn, "schema_reload", 650)
+
+	// The space is recreated with another id, but the connection still has
+	// an outdated schema.
+	_, err = ddlConn.Do(NewEvalRequest(recreateSchemaReloadSpace).
+		Args([]interface{}{651})).Get()
+	require.Nilf(t, err, "failed to recreate a space")
+
+	req := NewReplaceRequest("schema_reload").Tuple([]interface{}{uint(1)})
+	_, err = conn.Do(req).Get()
+	require.Nilf(t, err, "failed to replace a tuple")
+
+	var tuples [][]interface{}
+	err = ddlConn.Do(NewSelectRequest(651)).GetTyped(&tuples)
+	require.Nilf(t, err, "failed to select tuples")
+	assert.Equal(t, [][]interface{}{{uint64(1)}}, tuples)
+}
+
+func TestConnection_SchemaReload_afterReconnect(t *testing.T) {
+	const server = "127.0.0.1:3014"
+
+	inst, err := test_helpers.StartTarantool(test_helpers.StartOpts{
+		InitScript:   "config.lua",
+		Listen:       server,
+		User:         opts.User,
+		Pass:         opts.Pass,
+		WaitStart:    100 * time.Millisecond,
+		ConnectRetry: 10,
+		RetryTimeout: 500 * time.Millisecond,
+	})
+	defer test_helpers.StopTarantoolWithCleanup(inst)
+	if err != nil {
+		t.Fatalf("Unable to start Tarantool: %s", err)
+	}
+
+	reconnectOpts := opts
+	reconnectOpts.Reconnect = 100 * time.Millisecond
+	reconnectOpts.MaxReconnects = 100
+	conn := test_helpers.ConnectWithValidation(t, server, reconnectOpts)
+	defer conn.Close()
+
+	test_helpers.StopTarantool(inst)
+	if err := test_helpers.RestartTarantool(&inst); err != nil {
+		t.Fatalf("Unable to restart Tarantool: %s", err)
+	}
+
+	ddlConn := test_helpers.ConnectWithValidation(t, server, opts)
+	defer ddlConn.Close()
+	_, err = ddlConn.Do(NewEvalRequest(recreateSchemaReloadSpace).
+		Args([]interface{}{650})).Get()
+	require.Nilf(t, err, "failed to create a space")
+
+	if !test_helpers.WaitUntilReconnected(conn, 100, 100*time.Millisecond) {
+		t.Fatalf("Failed to reconnect")
+	}
+	waitSchemaSpace(t, conn, "schema_reload", 650)
+}
+
+func TestConnection_ReconnectPolicy_giveUp(t *testing.T) {
+	const server = "127.0.0.1:3014"
+
+	inst, err := test_helpers.StartTarantool(test_helpers.StartOpts{
+		InitScript:   "config.lua",
+		Listen:       server,
+		User:         opts.User,
+		Pass:         opts.Pass,
+		WaitStart:    100 * time.Millisecond,
+		ConnectRetry: 10,
+		RetryTimeout: 500 * time.Millisecond,
+	})
+	defer test_helpers.StopTarantoolWithCleanup(inst)
+	require.Nilf(t, err, "Unable to start Tarantool")
+
+	reconnectOpts := opts
+	reconnectOpts.ReconnectPolicy = ExponentialReconnectPolicy{
+		Base:        10 * time.Millisecond,
+		MaxAttempts: 3,
+	}
+	conn := test_helpers.ConnectWithValidation(t, server, reconnectOpts)
+	defer conn.Close()
+
+	test_helpers.StopTarantool(inst)
+
+	// 10ms + 20ms + 40ms of pauses and 4 failed dials.
+	for i := 0; i < 500 && !conn.ClosedNow(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.True(t, conn.ClosedNow(), "connection is not closed")
+	_, err = conn.Do(NewPingRequest()).Get()
+	require.NotNil(t, err)
+}
+
+type recordingObserver struct {
+	mutex  sync.Mutex
+	events []RequestEvent
+}
+
+func (o *recordingObserver) Observe(event RequestEvent) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.events = append(o.events, event)
+}
+
+func (o *recordingObserver) kinds() []RequestEventKind {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	kinds := []RequestEventKind{}
+	for _, event := range o.events {
+		kinds = append(kinds, event.Kind)
+	}
+	return kinds
+}
+
+func (o *recordingObserver) last() RequestEvent {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.events[len(o.events)-1]
+}
+
+func connectWithObserver(t *testing.T, observer Observer) *Connection {
+	t.Helper()
+
+	observerOpts := opts
+	observerOpts.Observer = observer
+	observerOpts.SkipSchema = true
+	return test_helpers.ConnectWithValidation(t, server, observerOpts)
+}
+
+func TestConnection_Observer_response(t *testing.T) {
+	observer := &recordingObserver{}
+	conn := connectWithObserver(t, observer)
+	defer conn.Close()
+
+	_, err := conn.Do(NewPingRequest()).Get()
+	require.Nil(t, err)
+
+	require.Equal(t, []RequestEventKind{RequestSent, ResponseReceived},
+		observer.kinds())
+	event := observer.last()
+	require.Equal(t, conn, event.Conn)
+	require.Nil(t, event.Err)
+	require.NotNil(t, event.Response)
+	require.NotZero(t, event.RequestId)
+	require.False(t, event.Start.IsZero())
+	require.False(t, event.When.Before(event.Start))
+}
+
+func TestConnection_Observer_space(t *testing.T) {
+	observer := &recordingObserver{}
+	conn := connectWithObserver(t, observer)
+	defer conn.Close()
+
+	_, err := conn.Do(NewSelectRequest(spaceNo).Limit(1)).Get()
+	require.Nil(t, err)
+
+	require.Equal(t, []RequestEventKind{RequestSent, ResponseReceived},
+		observer.kinds())
+	require.Equal(t, spaceNo, observer.last().Space)
+}
+
+func TestConnection_Observer_error(t *testing.T) {
+	observer := &recordingObserver{}
+	conn := connectWithObserver(t, observer)
+	defer conn.Close()
+
+	_, err := conn.Do(NewEvalRequest("error('observer error')")).Get()
+	require.NotNil(t, err)
+
+	require.Equal(t, []RequestEventKind{RequestSent, ResponseReceived},
+		observer.kinds())
+	event := observer.last()
+	require.NotNil(t, event.Err)
+	require.Contains(t, event.Err.Error(), "observer error")
+}
+
+func TestConnection_Observer_push(t *testing.T) {
+	observer := &recordingObserver{}
+	conn := connectWithObserver(t, observer)
+	defer conn.Close()
+
+	_, err := conn.Do(NewCallRequest("push_func").Args([]interface{}{1})).Get()
+	require.Nil(t, err)
+
+	require.Equal(t,
+		[]RequestEventKind{RequestSent, PushReceived, ResponseReceived},
+		observer.kinds())
+}
+
+func TestConnection_Observer_timeout(t *testing.T) {
+	observer := &recordingObserver{}
+	observerOpts := opts
+	observerOpts.Observer = observer
+	observerOpts.SkipSchema = true
+	observerOpts.Timeout = 100 * time.Millisecond
+	conn := test_helpers.ConnectWithValidation(t, server, observerOpts)
+	defer conn.Close()
+
+	_, err := conn.Do(NewEvalRequest("require('fiber').sleep(1)")).Get()
+	require.NotNil(t, err)
+
+	require.Equal(t, []RequestEventKind{RequestSent, RequestTimedOut},
+		observer.kinds())
+	require.Equal(t, err, observer.last().Err)
+}
+
+func TestConnection_Observer_cancel(t *testing.T) {
+	observer := &recordingObserver{}
+	conn := connectWithObserver(t, observer)
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := conn.Do(NewPingRequest().Context(ctx)).Get()
+	require.NotNil(t, err)
+
+	require.Equal(t, []RequestEventKind{RequestCanceled}, observer.kinds())
+	require.Equal(t, err, observer.last().Err)
+}
+
+func TestConnection_Observer_closed(t *testing.T) {
+	observer := &recordingObserver{}
+	conn := connectWithObserver(t, observer)
+	conn.Close()
+
+	_, err := conn.Do(NewPingRequest()).Get()
+	require.NotNil(t, err)
+
+	require.Equal(t, []RequestEventKind{RequestFailed}, observer.kinds())
+	require.Equal(t, err, observer.last().Err)
+}
+
 func TestConnect_context_cancel(t *testing.T) {
 	var connLongReconnectOpts = Opts{
 		Timeout:       5 * time.Second,
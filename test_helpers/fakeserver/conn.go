@@ -0,0 +1,639 @@
+package fakeserver
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/tarantool/go-iproto"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/tarantool/go-tarantool/v2"
+)
+
+const (
+	greetingLineSize = 64
+	saltSize         = 32
+	scrambleSize     = sha1.Size
+	streamQueueSize  = 1024
+	// iprotoSpaceName is the IPROTO_SPACE_NAME request key.
+	iprotoSpaceName = iproto.Key(0x5e)
+	// iprotoIndexName is the IPROTO_INDEX_NAME request key.
+	iprotoIndexName = iproto.Key(0x5f)
+	// iprotoTupleFormats is the IPROTO_TUPLE_FORMATS response key.
+	iprotoTupleFormats = iproto.Key(0x60)
+	// tupleExtID is the MP_TUPLE extension type.
+	tupleExtID = 7
+)
+
+type request struct {
+	typ           iproto.Type
+	sync          uint64
+	streamId      uint64
+	schemaVersion uint64
+	body          map[iproto.Key]interface{}
+}
+
+type connWatch struct {
+	version uint64
+	waiting bool
+}
+
+type stream struct {
+	requests chan *request
+	txn      *txn
+}
+
+type conn struct {
+	server *Server
+	net    net.Conn
+	salt   []byte
+
+	writeMutex sync.Mutex
+
+	// authenticated is used by the reader goroutine only.
+	authenticated bool
+	// streams is used by the reader goroutine only.
+	streams map[uint64]*stream
+	// watches is protected by the server mutex.
+	watches map[string]*connWatch
+	// dmlTuples is set by the reader goroutine if the client has negotiated
+	// the DMLTupleExtensionFeature. Requests after IPROTO_ID see it.
+	dmlTuples bool
+}
+
+func newConn(server *Server, nc net.Conn) *conn {
+	salt := make([]byte, saltSize)
+	rand.Read(salt)
+	return &conn{
+		server:        server,
+		net:           nc,
+		salt:          salt,
+		authenticated: server.opts.User == "",
+		streams:       make(map[uint64]*stream),
+		watches:       make(map[string]*connWatch),
+	}
+}
+
+func (c *conn) serve() {
+	var wg sync.WaitGroup
+	defer func() {
+		c.net.Close()
+		for _, st := range c.streams {
+			close(st.requests)
+		}
+		wg.Wait()
+	}()
+
+	if err := c.writeGreeting(); err != nil {
+		return
+	}
+
+	r := bufio.NewReader(c.net)
+	for {
+		req, err := readRequest(r)
+		if err != nil {
+			return
+		}
+
+		switch {
+		case req.streamId != 0:
+			st, ok := c.streams[req.streamId]
+			if !ok {
+				st = &stream{requests: make(chan *request, streamQueueSize)}
+				c.streams[req.streamId] = st
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					c.serveStream(st)
+				}()
+			}
+			if !c.checkAccess(req) {
+				continue
+			}
+			st.requests <- req
+		case req.typ == iproto.IPROTO_CALL || req.typ == iproto.IPROTO_CALL_16 ||
+			req.typ == iproto.IPROTO_EVAL:
+			if !c.checkAccess(req) {
+				continue
+			}
+			go c.handle(req, nil)
+		default:
+			if !c.checkAccess(req) {
+				continue
+			}
+			c.handle(req, nil)
+		}
+	}
+}
+
+func (c *conn) serveStream(st *stream) {
+	for req := range st.requests {
+		c.handle(req, st)
+	}
+
+	if st.txn != nil {
+		c.server.mutex.Lock()
+		c.server.rollback(st.txn)
+		c.server.mutex.Unlock()
+	}
+}
+
+// checkAccess handles authentication and returns true if the request could
+// be processed.
+func (c *conn) checkAccess(req *request) bool {
+	switch req.typ {
+	case iproto.IPROTO_ID, iproto.IPROTO_PING, iproto.IPROTO_WATCH,
+		iproto.IPROTO_UNWATCH:
+		return true
+	case iproto.IPROTO_AUTH:
+		if err := c.authenticate(req); err != nil {
+			c.writeError(req, err)
+		} else {
+			c.authenticated = true
+			c.writeOk(req, nil)
+		}
+		return false
+	}
+
+	if !c.authenticated {
+		c.writeError(req, Error{iproto.ER_ACCESS_DENIED,
+			fmt.Sprintf("%s access is denied for user 'guest'", req.typ)})
+		return false
+	}
+	return true
+}
+
+func (c *conn) authenticate(req *request) error {
+	if c.server.opts.User == "" {
+		return nil
+	}
+
+	user, _ := req.body[iproto.IPROTO_USER_NAME].(string)
+	tuple, _ := req.body[iproto.IPROTO_TUPLE].([]interface{})
+	if len(tuple) != 2 {
+		return Error{iproto.ER_INVALID_MSGPACK, "Invalid MsgPack - authentication request body"}
+	}
+	method, _ := tuple[0].(string)
+	if method != "chap-sha1" {
+		return Error{iproto.ER_UNKNOWN_AUTH_METHOD,
+			fmt.Sprintf("Unknown authentication method '%s'", method)}
+	}
+	scramble, _ := tuple[1].(string)
+	if user != c.server.opts.User ||
+		scramble != string(chapSha1(c.salt, c.server.opts.Pass)) {
+		return Error{iproto.ER_CREDS_MISMATCH,
+			"User not found or supplied credentials are invalid"}
+	}
+	return nil
+}
+
+func (c *conn) handle(req *request, st *stream) {
+	s := c.server
+
+	switch req.typ {
+	case iproto.IPROTO_ID:
+		if s.opts.NoIdRequest {
+			c.writeError(req, Error{iproto.ER_UNKNOWN_REQUEST_TYPE,
+				fmt.Sprintf("Unknown request type %d", req.typ)})
+			return
+		}
+		c.dmlTuples = s.hasFeature(tarantool.DMLTupleExtensionFeature) &&
+			requestsFeature(req, tarantool.DMLTupleExtensionFeature)
+		c.writeOk(req, c.idResponse())
+	case iproto.IPROTO_PING:
+		c.writeOk(req, nil)
+	case iproto.IPROTO_WATCH:
+		if key, ok := req.body[iproto.IPROTO_EVENT_KEY].(string); ok {
+			s.mutex.Lock()
+			c.watch(key)
+			s.mutex.Unlock()
+		}
+	case iproto.IPROTO_UNWATCH:
+		if key, ok := req.body[iproto.IPROTO_EVENT_KEY].(string); ok {
+			s.mutex.Lock()
+			delete(c.watches, key)
+			s.mutex.Unlock()
+		}
+	case iproto.IPROTO_CALL, iproto.IPROTO_CALL_16, iproto.IPROTO_EVAL:
+		c.call(req, st)
+	case iproto.IPROTO_BEGIN, iproto.IPROTO_COMMIT, iproto.IPROTO_ROLLBACK:
+		c.transaction(req, st)
+	case iproto.IPROTO_SELECT, iproto.IPROTO_INSERT, iproto.IPROTO_REPLACE,
+		iproto.IPROTO_UPDATE, iproto.IPROTO_UPSERT, iproto.IPROTO_DELETE:
+		var tx *txn
+		if st != nil {
+			tx = st.txn
+		}
+		body, err := s.execute(req, tx, c.dmlTuples)
+		if err != nil {
+			c.writeError(req, err)
+		} else {
+			c.writeOk(req, body)
+		}
+	default:
+		c.writeError(req, Error{iproto.ER_UNKNOWN_REQUEST_TYPE,
+			fmt.Sprintf("Unknown request type %d", req.typ)})
+	}
+}
+
+func (c *conn) idResponse() map[iproto.Key]interface{} {
+	info := c.server.opts.ProtocolInfo
+	features := make([]uint64, 0, len(info.Features))
+	for _, feature := range info.Features {
+		features = append(features, uint64(feature))
+	}
+	body := map[iproto.Key]interface{}{
+		iproto.IPROTO_VERSION:  uint64(info.Version),
+		iproto.IPROTO_FEATURES: features,
+	}
+	if info.Auth != 0 {
+		body[iproto.IPROTO_AUTH_TYPE] = info.Auth.String()
+	}
+	return body
+}
+
+// requestsFeature checks that the client requests the feature in an IPROTO_ID
+// request.
+func requestsFeature(req *request, feature tarantool.ProtocolFeature) bool {
+	features, _ := req.body[iproto.IPROTO_FEATURES].([]interface{})
+	for _, f := range features {
+		if id, ok := toUint(f); ok && id == uint64(feature) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *conn) call(req *request, st *stream) {
+	s := c.server
+
+	var call Call
+	var handler Handler
+	var ok bool
+
+	call.Args, _ = req.body[iproto.IPROTO_TUPLE].([]interface{})
+	call.StreamId = req.streamId
+	call.push = func(value interface{}) error {
+		return c.write(iproto.Type(iproto.IPROTO_CHUNK), req.sync,
+			map[iproto.Key]interface{}{
+				iproto.IPROTO_DATA: []interface{}{value},
+			})
+	}
+
+	s.mutex.Lock()
+	if req.typ == iproto.IPROTO_EVAL {
+		call.Name, _ = req.body[iproto.IPROTO_EXPR].(string)
+		handler, ok = s.evals[call.Name]
+	} else {
+		call.Name, _ = req.body[iproto.IPROTO_FUNCTION_NAME].(string)
+		handler, ok = s.functions[call.Name]
+	}
+	s.mutex.Unlock()
+
+	if !ok {
+		if req.typ == iproto.IPROTO_EVAL {
+			c.writeError(req, Error{iproto.ER_PROC_LUA,
+				fmt.Sprintf("eval:1: expression is not registered: %s", call.Name)})
+		} else {
+			c.writeError(req, Error{iproto.ER_NO_SUCH_PROC,
+				fmt.Sprintf("Procedure '%s' is not defined", call.Name)})
+		}
+		return
+	}
+
+	result, err := handler(call)
+	if err != nil {
+		if _, ok := err.(Error); !ok {
+			err = Error{iproto.ER_PROC_LUA, err.Error()}
+		}
+		c.writeError(req, err)
+		return
+	}
+
+	if result == nil {
+		result = []interface{}{}
+	}
+	if req.typ == iproto.IPROTO_CALL_16 {
+		// Each result is converted into a tuple.
+		for i, value := range result {
+			if _, ok := value.([]interface{}); !ok {
+				result[i] = []interface{}{value}
+			}
+		}
+	}
+	c.writeOk(req, map[iproto.Key]interface{}{iproto.IPROTO_DATA: result})
+}
+
+func (c *conn) transaction(req *request, st *stream) {
+	s := c.server
+
+	if st == nil {
+		c.writeError(req, Error{iproto.ER_UNABLE_TO_PROCESS_OUT_OF_STREAM,
+			fmt.Sprintf("Unable to process %s request out of stream",
+				strings.TrimPrefix(req.typ.String(), "IPROTO_"))})
+		return
+	}
+
+	switch req.typ {
+	case iproto.IPROTO_BEGIN:
+		if st.txn != nil {
+			c.writeError(req, Error{iproto.ER_ACTIVE_TRANSACTION,
+				"Operation is not permitted when there is an active transaction "})
+			return
+		}
+		st.txn = &txn{}
+	case iproto.IPROTO_COMMIT:
+		st.txn = nil
+	case iproto.IPROTO_ROLLBACK:
+		if st.txn != nil {
+			s.mutex.Lock()
+			s.rollback(st.txn)
+			s.mutex.Unlock()
+			st.txn = nil
+		}
+	}
+	c.writeOk(req, nil)
+}
+
+// watch must be called with the locked server mutex.
+func (c *conn) watch(key string) {
+	w, ok := c.watches[key]
+	if !ok {
+		w = &connWatch{}
+		c.watches[key] = w
+		c.sendEvent(key, w)
+		return
+	}
+
+	// The request is an acknowledgement of the last event.
+	w.waiting = false
+	c.notify(key)
+}
+
+// notify must be called with the locked server mutex.
+func (c *conn) notify(key string) {
+	w, ok := c.watches[key]
+	if !ok || w.waiting {
+		return
+	}
+
+	var version uint64
+	if value, ok := c.server.watches[key]; ok {
+		version = value.version
+	}
+	if version > w.version {
+		c.sendEvent(key, w)
+	}
+}
+
+// sendEvent must be called with the locked server mutex.
+func (c *conn) sendEvent(key string, w *connWatch) {
+	body := map[iproto.Key]interface{}{
+		iproto.IPROTO_EVENT_KEY: key,
+	}
+	w.version = 0
+	if value, ok := c.server.watches[key]; ok {
+		w.version = value.version
+		if value.value != nil {
+			body[iproto.IPROTO_EVENT_DATA] = value.value
+		}
+	}
+	w.waiting = true
+
+	c.write(iproto.IPROTO_EVENT, 0, body)
+}
+
+func (c *conn) writeGreeting() error {
+	greeting := make([]byte, 2*greetingLineSize)
+	for i := range greeting {
+		greeting[i] = ' '
+	}
+	copy(greeting, fmt.Sprintf("Tarantool %s (Binary) %s",
+		c.server.opts.Version, c.server.uuid))
+	copy(greeting[greetingLineSize:], base64.StdEncoding.EncodeToString(c.salt))
+	greeting[greetingLineSize-1] = '\n'
+	greeting[2*greetingLineSize-1] = '\n'
+
+	_, err := c.net.Write(greeting)
+	return err
+}
+
+func (c *conn) writeOk(req *request, body map[iproto.Key]interface{}) error {
+	return c.write(iproto.IPROTO_OK, req.sync, body)
+}
+
+func (c *conn) writeError(req *request, err error) error {
+	tntErr, ok := err.(Error)
+	if !ok {
+		tntErr = Error{iproto.ER_PROC_LUA, err.Error()}
+	}
+
+	return c.write(iproto.Type(iproto.IPROTO_TYPE_ERROR)|iproto.Type(tntErr.Code),
+		req.sync, map[iproto.Key]interface{}{
+			iproto.IPROTO_ERROR_24: tntErr.Msg,
+			iproto.IPROTO_ERROR: map[uint64]interface{}{
+				0x00: []interface{}{
+					map[uint64]interface{}{
+						0x00: "ClientError",
+						0x01: "fakeserver",
+						0x02: uint64(0),
+						0x03: tntErr.Msg,
+						0x04: uint64(0),
+						0x05: uint64(tntErr.Code),
+					},
+				},
+			},
+		})
+}
+
+func (c *conn) write(typ iproto.Type, sync uint64,
+	body map[iproto.Key]interface{}) error {
+	var buf bytes.Buffer
+	buf.Write([]byte{0xce, 0, 0, 0, 0})
+
+	enc := msgpack.NewEncoder(&buf)
+	enc.UseCompactInts(true)
+
+	header := map[iproto.Key]interface{}{
+		iproto.IPROTO_REQUEST_TYPE: uint64(typ),
+		iproto.IPROTO_SYNC:         sync,
+	}
+	// Events are sent with the locked server mutex and without a schema
+	// version.
+	if typ != iproto.IPROTO_EVENT {
+		c.server.mutex.Lock()
+		header[iproto.IPROTO_SCHEMA_VERSION] = c.server.schemaVersion
+		c.server.mutex.Unlock()
+	}
+	if err := enc.Encode(header); err != nil {
+		return err
+	}
+	if body == nil {
+		body = map[iproto.Key]interface{}{}
+	}
+	if err := enc.Encode(body); err != nil {
+		return err
+	}
+
+	packet := buf.Bytes()
+	binary.BigEndian.PutUint32(packet[1:], uint32(len(packet)-5))
+
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	_, err := c.net.Write(packet)
+	return err
+}
+
+func readRequest(r io.Reader) (*request, error) {
+	var lenbuf [5]byte
+	if _, err := io.ReadFull(r, lenbuf[:]); err != nil {
+		return nil, err
+	}
+	if lenbuf[0] != 0xce {
+		return nil, fmt.Errorf("unexpected packet length code 0x%x", lenbuf[0])
+	}
+	packet := make([]byte, binary.BigEndian.Uint32(lenbuf[1:]))
+	if _, err := io.ReadFull(r, packet); err != nil {
+		return nil, err
+	}
+
+	d := msgpack.NewDecoder(bytes.NewReader(packet))
+	d.SetMapDecoder(func(dec *msgpack.Decoder) (interface{}, error) {
+		return dec.DecodeUntypedMap()
+	})
+
+	req := &request{body: make(map[iproto.Key]interface{})}
+
+	l, err := d.DecodeMapLen()
+	if err != nil {
+		return nil, err
+	}
+	for ; l > 0; l-- {
+		key, err := d.DecodeUint64()
+		if err != nil {
+			return nil, err
+		}
+		switch iproto.Key(key) {
+		case iproto.IPROTO_REQUEST_TYPE:
+			typ, err := d.DecodeUint64()
+			if err != nil {
+				return nil, err
+			}
+			req.typ = iproto.Type(typ)
+		case iproto.IPROTO_SYNC:
+			if req.sync, err = d.DecodeUint64(); err != nil {
+				return nil, err
+			}
+		case iproto.IPROTO_STREAM_ID:
+			if req.streamId, err = d.DecodeUint64(); err != nil {
+				return nil, err
+			}
+		case iproto.IPROTO_SCHEMA_VERSION:
+			if req.schemaVersion, err = d.DecodeUint64(); err != nil {
+				return nil, err
+			}
+		default:
+			if err := d.Skip(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// A body is optional.
+	if l, err = d.DecodeMapLen(); err == io.EOF {
+		return req, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for ; l > 0; l-- {
+		key, err := d.DecodeUint64()
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.DecodeInterface()
+		if err != nil {
+			return nil, err
+		}
+		req.body[iproto.Key(key)] = normalize(value)
+	}
+	return req, nil
+}
+
+// tupleValue is encoded as an MP_TUPLE value: a format identifier and
+// fields.
+type tupleValue struct {
+	formatId uint64
+	fields   []interface{}
+}
+
+// EncodeMsgpack encodes the tuple as an MP_TUPLE value.
+func (t tupleValue) EncodeMsgpack(e *msgpack.Encoder) error {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.UseCompactInts(true)
+	if err := enc.EncodeUint(t.formatId); err != nil {
+		return err
+	}
+	if err := enc.Encode(t.fields); err != nil {
+		return err
+	}
+
+	if err := e.EncodeExtHeader(tupleExtID, buf.Len()); err != nil {
+		return err
+	}
+	_, err := e.Writer().Write(buf.Bytes())
+	return err
+}
+
+// normalize converts integers into uint64 for non-negative values and into
+// int64 for negative values.
+func normalize(value interface{}) interface{} {
+	switch value := value.(type) {
+	case []interface{}:
+		for i := range value {
+			value[i] = normalize(value[i])
+		}
+		return value
+	case map[interface{}]interface{}:
+		for k, v := range value {
+			value[k] = normalize(v)
+		}
+		return value
+	case float32:
+		return float64(value)
+	}
+
+	if i, ok := toInt(value); ok && i < 0 {
+		return i
+	}
+	if u, ok := toUint(value); ok {
+		return u
+	}
+	return value
+}
+
+// chapSha1 returns a chap-sha1 scramble for the password.
+func chapSha1(salt []byte, pass string) []byte {
+	step1 := sha1.Sum([]byte(pass))
+	step2 := sha1.Sum(step1[:])
+	hash := sha1.New()
+	hash.Write(salt[:scrambleSize])
+	hash.Write(step2[:])
+	step3 := hash.Sum(nil)
+
+	scramble := make([]byte, scrambleSize)
+	for i := range scramble {
+		scramble[i] = step1[i] ^ step3[i]
+	}
+	return scramble
+}
@@ -0,0 +1,438 @@
+// Package fakeserver implements an in-process Tarantool IPROTO server for
+// unit tests.
+//
+// The server speaks the greeting, IPROTO_ID, chap-sha1 authentication,
+// ping, select/insert/replace/update/upsert/delete requests against
+// in-memory spaces, call/eval requests with Go handlers, watchers, streams
+// and interactive transactions, optionally over TLS. So tarantool.Connect
+// and pool.Connect could be used without a real Tarantool instance.
+//
+// It is not a Tarantool emulator: Lua is not supported, transactions are not
+// isolated and indexes are not real trees or hashes. Use it for tests of a
+// client logic only.
+package fakeserver
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/tarantool/go-iproto"
+
+	"github.com/tarantool/go-tarantool/v2"
+)
+
+const (
+	defaultListen  = "127.0.0.1:0"
+	defaultVersion = "2.11.0-0-g0000000"
+	boxStatusKey   = "box.status"
+	boxInfoFunc    = "box.info"
+)
+
+// Opts is a set of options for a fake server.
+type Opts struct {
+	// Listen is an address to listen, "127.0.0.1:0" by default.
+	Listen string
+	// Version is a Tarantool version in the greeting message.
+	Version string
+	// User is a user name for chap-sha1 authentication. If User is empty,
+	// any credentials are accepted and an authentication is not required.
+	User string
+	// Pass is a password of the user.
+	Pass string
+	// ProtocolInfo is a response for IPROTO_ID requests. All features
+	// supported by the fake server are used by default. Select requests
+	// ignore pagination keys without the PaginationFeature as an old
+	// Tarantool does. Tuples are sent as MP_TUPLE values with formats to
+	// clients that have negotiated the DMLTupleExtensionFeature.
+	ProtocolInfo tarantool.ProtocolInfo
+	// NoIdRequest makes the server to reject IPROTO_ID requests as an old
+	// Tarantool does.
+	NoIdRequest bool
+	// ReadOnly makes the server read-only at start.
+	ReadOnly bool
+	// Tls makes the server to accept TLS connections with the configuration.
+	Tls *tls.Config
+}
+
+// Field describes a space format field.
+type Field struct {
+	// Name is a field name.
+	Name string
+	// Type is a field type: "unsigned", "string", "any" and so on.
+	Type string
+	// IsNullable allows to skip the field in tuples.
+	IsNullable bool
+}
+
+// IndexPart describes an index part.
+type IndexPart struct {
+	// Field is a field number starting from 0.
+	Field uint32
+	// Type is a field type: "unsigned", "string", "scalar" and so on.
+	Type string
+}
+
+// Index describes a space index.
+type Index struct {
+	// Name is an index name.
+	Name string
+	// Type is an index type, "TREE" by default.
+	Type string
+	// Unique makes the index unique. A primary index is always unique.
+	Unique bool
+	// Parts are index parts.
+	Parts []IndexPart
+}
+
+// Space describes an in-memory space.
+type Space struct {
+	// Id is a space identifier.
+	Id uint32
+	// Name is a space name.
+	Name string
+	// Engine is a space engine, "memtx" by default.
+	Engine string
+	// Format is an optional space format.
+	Format []Field
+	// Indexes are space indexes. The first one is a primary index.
+	Indexes []Index
+}
+
+// Error is an error sent to a client in a response. It could be returned by
+// a handler to set an error code.
+type Error struct {
+	Code iproto.Error
+	Msg  string
+}
+
+// Error converts an Error to a string.
+func (err Error) Error() string {
+	return fmt.Sprintf("%s (%s)", err.Msg, err.Code)
+}
+
+// Call describes a call or an eval request passed to a Handler.
+type Call struct {
+	// Name is a function name or an expression for eval requests.
+	Name string
+	// Args are request arguments.
+	Args []interface{}
+	// StreamId is a stream identifier of the request or zero.
+	StreamId uint64
+
+	push func(value interface{}) error
+}
+
+// Push sends a push message to a client.
+func (call Call) Push(value interface{}) error {
+	return call.push(value)
+}
+
+// Handler handles call or eval requests. It returns values to send in a
+// response or an error. The error is sent with ER_PROC_LUA code unless it is
+// an Error.
+//
+// Handlers for requests out of a stream are called concurrently.
+type Handler func(call Call) ([]interface{}, error)
+
+type watchValue struct {
+	value   interface{}
+	version uint64
+}
+
+// Server is an in-process Tarantool IPROTO server.
+type Server struct {
+	opts     Opts
+	listener net.Listener
+	uuid     string
+	wg       sync.WaitGroup
+
+	mutex         sync.Mutex
+	closed        bool
+	conns         map[*conn]struct{}
+	readOnly      bool
+	schemaVersion uint64
+	spaces        map[uint32]*space
+	functions     map[string]Handler
+	evals         map[string]Handler
+	watches       map[string]*watchValue
+}
+
+// Start starts a fake server with the options.
+func Start(opts Opts) (*Server, error) {
+	if opts.Listen == "" {
+		opts.Listen = defaultListen
+	}
+	if opts.Version == "" {
+		opts.Version = defaultVersion
+	}
+	if opts.ProtocolInfo.Version == 0 {
+		opts.ProtocolInfo = tarantool.ProtocolInfo{
+			Auth:    tarantool.ChapSha1Auth,
+			Version: tarantool.ProtocolVersion(6),
+			Features: []tarantool.ProtocolFeature{
+				tarantool.StreamsFeature,
+				tarantool.TransactionsFeature,
+				tarantool.ErrorExtensionFeature,
+				tarantool.WatchersFeature,
+				tarantool.PaginationFeature,
+				tarantool.SpaceAndIndexNamesFeature,
+				tarantool.DMLTupleExtensionFeature,
+			},
+		}
+	}
+
+	listener, err := net.Listen("tcp", opts.Listen)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Tls != nil {
+		listener = tls.NewListener(listener, opts.Tls)
+	}
+
+	s := &Server{
+		opts:          opts,
+		listener:      listener,
+		uuid:          newUUID(),
+		conns:         make(map[*conn]struct{}),
+		readOnly:      opts.ReadOnly,
+		schemaVersion: 1,
+		spaces:        make(map[uint32]*space),
+		functions:     make(map[string]Handler),
+		evals:         make(map[string]Handler),
+		watches:       make(map[string]*watchValue),
+	}
+	s.functions[boxInfoFunc] = s.boxInfo
+	s.broadcast(boxStatusKey, s.boxStatus())
+
+	s.wg.Add(1)
+	go s.accept()
+	return s, nil
+}
+
+// StartWithSpace starts a fake server with the options and creates the space.
+// The server is closed if the space could not be created.
+func StartWithSpace(opts Opts, def Space) (*Server, error) {
+	s, err := Start(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.CreateSpace(def); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Addr returns an address of the server.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops the server and closes all connections.
+func (s *Server) Close() error {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.closed = true
+	err := s.listener.Close()
+	for c := range s.conns {
+		c.net.Close()
+	}
+	s.mutex.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+// DropConnections closes all current connections, but the server still
+// accepts new ones.
+func (s *Server) DropConnections() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for c := range s.conns {
+		c.net.Close()
+	}
+}
+
+// CreateSpace creates a space. It increments a schema version.
+func (s *Server) CreateSpace(def Space) error {
+	if len(def.Indexes) == 0 {
+		return errors.New("a primary index is required")
+	}
+	if def.Engine == "" {
+		def.Engine = "memtx"
+	}
+	def.Indexes = append([]Index(nil), def.Indexes...)
+	def.Indexes[0].Unique = true
+	for i := range def.Indexes {
+		if def.Indexes[i].Type == "" {
+			def.Indexes[i].Type = "TREE"
+		}
+		if len(def.Indexes[i].Parts) == 0 {
+			return fmt.Errorf("index %q has no parts", def.Indexes[i].Name)
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, sp := range s.spaces {
+		if sp.def.Id == def.Id || sp.def.Name == def.Name {
+			return Error{iproto.ER_SPACE_EXISTS,
+				fmt.Sprintf("Space '%s' already exists", def.Name)}
+		}
+	}
+	s.spaces[def.Id] = &space{def: def}
+	s.schemaVersion++
+	return nil
+}
+
+// DropSpace drops a space. It increments a schema version.
+func (s *Server) DropSpace(id uint32) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.spaces[id]; !ok {
+		return Error{iproto.ER_NO_SUCH_SPACE,
+			fmt.Sprintf("Space '%d' does not exist", id)}
+	}
+	delete(s.spaces, id)
+	s.schemaVersion++
+	return nil
+}
+
+// SetSchemaVersion sets a schema version of the server. It allows to emulate
+// a failover to an instance with another schema version.
+func (s *Server) SetSchemaVersion(version uint64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.schemaVersion = version
+}
+
+// RegisterFunction registers a handler for call requests to the function.
+func (s *Server) RegisterFunction(name string, handler Handler) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.functions[name] = handler
+}
+
+// RegisterEval registers a handler for eval requests with the expression.
+func (s *Server) RegisterEval(expr string, handler Handler) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.evals[expr] = handler
+}
+
+// Broadcast sets a value for the key and notifies watchers.
+func (s *Server) Broadcast(key string, value interface{}) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.broadcast(key, value)
+}
+
+// SetReadOnly changes a read-only state of the server. It updates a
+// box.status event and a box.info result.
+func (s *Server) SetReadOnly(readOnly bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.readOnly = readOnly
+	s.broadcast(boxStatusKey, s.boxStatus())
+}
+
+func (s *Server) accept() {
+	defer s.wg.Done()
+
+	for {
+		nc, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mutex.Lock()
+		if s.closed {
+			s.mutex.Unlock()
+			nc.Close()
+			return
+		}
+		c := newConn(s, nc)
+		s.conns[c] = struct{}{}
+		s.wg.Add(1)
+		s.mutex.Unlock()
+
+		go func() {
+			defer s.wg.Done()
+			c.serve()
+
+			s.mutex.Lock()
+			delete(s.conns, c)
+			s.mutex.Unlock()
+		}()
+	}
+}
+
+// broadcast must be called with the locked mutex.
+func (s *Server) broadcast(key string, value interface{}) {
+	watch, ok := s.watches[key]
+	if !ok {
+		watch = &watchValue{}
+		s.watches[key] = watch
+	}
+	watch.value = value
+	watch.version++
+
+	for c := range s.conns {
+		c.notify(key)
+	}
+}
+
+// boxStatus must be called with the locked mutex.
+func (s *Server) boxStatus() map[string]interface{} {
+	return map[string]interface{}{
+		"is_ro":     s.readOnly,
+		"is_ro_cfg": s.readOnly,
+		"status":    "running",
+	}
+}
+
+func (s *Server) boxInfo(call Call) ([]interface{}, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return []interface{}{map[string]interface{}{
+		"id":      1,
+		"ro":      s.readOnly,
+		"status":  "running",
+		"uuid":    s.uuid,
+		"version": s.opts.Version,
+	}}, nil
+}
+
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// hasFeature checks that the feature is enabled in the protocol info.
+func (s *Server) hasFeature(feature tarantool.ProtocolFeature) bool {
+	for _, f := range s.opts.ProtocolInfo.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
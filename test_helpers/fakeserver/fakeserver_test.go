@@ -0,0 +1,475 @@
+package fakeserver_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tarantool/go-iproto"
+
+	"github.com/tarantool/go-tarantool/v2"
+	"github.com/tarantool/go-tarantool/v2/pool"
+	"github.com/tarantool/go-tarantool/v2/test_helpers/fakeserver"
+)
+
+const (
+	spaceNo   = uint32(617)
+	spaceName = "test"
+	indexName = "primary"
+)
+
+var opts = tarantool.Opts{
+	Timeout: 5 * time.Second,
+	User:    "test",
+	Pass:    "test",
+}
+
+var testSpace = fakeserver.Space{
+	Id:   spaceNo,
+	Name: spaceName,
+	Format: []fakeserver.Field{
+		{Name: "id", Type: "unsigned"},
+		{Name: "name", Type: "string"},
+		{Name: "value", Type: "any", IsNullable: true},
+	},
+	Indexes: []fakeserver.Index{
+		{Name: indexName, Parts: []fakeserver.IndexPart{{0, "unsigned"}}},
+		{Name: "secondary", Parts: []fakeserver.IndexPart{{1, "string"}}},
+	},
+}
+
+func startServer(t *testing.T) *fakeserver.Server {
+	t.Helper()
+
+	server, err := fakeserver.StartWithSpace(fakeserver.Opts{
+		User: opts.User,
+		Pass: opts.Pass,
+	}, testSpace)
+	require.NoError(t, err)
+	return server
+}
+
+func connect(t *testing.T, server *fakeserver.Server,
+	connOpts tarantool.Opts) *tarantool.Connection {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := tarantool.Connect(ctx, server.Addr(), connOpts)
+	require.NoError(t, err)
+	return conn
+}
+
+func TestConnect(t *testing.T) {
+	server := startServer(t)
+	defer server.Close()
+	conn := connect(t, server, opts)
+	defer conn.Close()
+
+	_, err := conn.Do(tarantool.NewPingRequest()).Get()
+	require.NoError(t, err)
+
+	info := conn.ServerProtocolInfo()
+	assert.Equal(t, tarantool.ChapSha1Auth, info.Auth)
+	assert.Contains(t, info.Features, tarantool.StreamsFeature)
+	assert.Contains(t, info.Features, tarantool.WatchersFeature)
+}
+
+func TestConnect_wrongPassword(t *testing.T) {
+	server := startServer(t)
+	defer server.Close()
+
+	wrongOpts := opts
+	wrongOpts.Pass = "wrong"
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := tarantool.Connect(ctx, server.Addr(), wrongOpts)
+	require.Error(t, err)
+
+	var tntErr tarantool.Error
+	require.True(t, errors.As(err, &tntErr))
+	assert.Equal(t, iproto.ER_CREDS_MISMATCH, tntErr.Code)
+}
+
+func TestConnect_noIdRequest(t *testing.T) {
+	server, err := fakeserver.Start(fakeserver.Opts{NoIdRequest: true})
+	require.NoError(t, err)
+	defer server.Close()
+
+	conn := connect(t, server, tarantool.Opts{Timeout: 5 * time.Second})
+	defer conn.Close()
+	assert.Equal(t, tarantool.ProtocolInfo{}, conn.ServerProtocolInfo())
+}
+
+func TestSchema(t *testing.T) {
+	server := startServer(t)
+	defer server.Close()
+	conn := connect(t, server, opts)
+	defer conn.Close()
+
+	space, ok := conn.Schema.Spaces[spaceName]
+	require.True(t, ok)
+	assert.Equal(t, spaceNo, space.Id)
+	assert.Equal(t, "memtx", space.Engine)
+	require.Len(t, space.FieldsById, 3)
+	assert.Equal(t, "name", space.FieldsById[1].Name)
+	assert.True(t, space.Fields["value"].IsNullable)
+
+	require.Contains(t, space.Indexes, "secondary")
+	index := space.Indexes["secondary"]
+	assert.Equal(t, uint32(1), index.Id)
+	assert.False(t, index.Unique)
+	require.Len(t, index.Fields, 1)
+	assert.Equal(t, uint32(1), index.Fields[0].Id)
+	assert.Equal(t, "string", index.Fields[0].Type)
+}
+
+func TestSchema_reload(t *testing.T) {
+	server := startServer(t)
+	defer server.Close()
+	conn := connect(t, server, opts)
+	defer conn.Close()
+
+	require.NoError(t, server.CreateSpace(fakeserver.Space{
+		Id:   618,
+		Name: "new",
+		Indexes: []fakeserver.Index{
+			{Name: indexName, Parts: []fakeserver.IndexPart{{0, "unsigned"}}},
+		},
+	}))
+
+	// The next response contains a new schema version.
+	_, err := conn.Do(tarantool.NewPingRequest()).Get()
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		_, err := conn.Do(tarantool.NewInsertRequest("new").
+			Tuple([]interface{}{uint(1)})).Get()
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestCrud(t *testing.T) {
+	server := startServer(t)
+	defer server.Close()
+	conn := connect(t, server, opts)
+	defer conn.Close()
+
+	resp, err := conn.Do(tarantool.NewInsertRequest(spaceName).
+		Tuple([]interface{}{uint(1), "one", "foo"})).Get()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{[]interface{}{int8(1), "one", "foo"}}, resp.Data)
+
+	_, err = conn.Do(tarantool.NewInsertRequest(spaceName).
+		Tuple([]interface{}{uint(1), "one"})).Get()
+	var tntErr tarantool.Error
+	require.True(t, errors.As(err, &tntErr))
+	assert.Equal(t, iproto.ER_TUPLE_FOUND, tntErr.Code)
+
+	_, err = conn.Do(tarantool.NewReplaceRequest(spaceName).
+		Tuple([]interface{}{uint(2), "two"})).Get()
+	require.NoError(t, err)
+
+	resp, err = conn.Do(tarantool.NewUpdateRequest(spaceName).
+		Key([]interface{}{uint(2)}).
+		Operations(tarantool.NewOperations().
+			Assign(1, "second").
+			Insert(2, 10).
+			Add(2, 5))).Get()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{[]interface{}{int8(2), "second", int8(15)}},
+		resp.Data)
+
+	_, err = conn.Do(tarantool.NewUpsertRequest(spaceName).
+		Tuple([]interface{}{uint(3), "three"}).
+		Operations(tarantool.NewOperations().Assign(1, "ignored"))).Get()
+	require.NoError(t, err)
+	_, err = conn.Do(tarantool.NewUpsertRequest(spaceName).
+		Tuple([]interface{}{uint(3), "ignored"}).
+		Operations(tarantool.NewOperations().Insert(2, "inserted"))).Get()
+	require.NoError(t, err)
+
+	resp, err = conn.Do(tarantool.NewSelectRequest(spaceName).
+		Iterator(tarantool.IterAll)).Get()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		[]interface{}{int8(1), "one", "foo"},
+		[]interface{}{int8(2), "second", int8(15)},
+		[]interface{}{int8(3), "three", "inserted"},
+	}, resp.Data)
+
+	resp, err = conn.Do(tarantool.NewSelectRequest(spaceName).
+		Index("secondary").
+		Iterator(tarantool.IterLt).
+		Key([]interface{}{"t"}).
+		Limit(1)).Get()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{[]interface{}{int8(2), "second", int8(15)}},
+		resp.Data)
+
+	resp, err = conn.Do(tarantool.NewDeleteRequest(spaceName).
+		Key([]interface{}{uint(1)})).Get()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{[]interface{}{int8(1), "one", "foo"}}, resp.Data)
+
+	resp, err = conn.Do(tarantool.NewSelectRequest(spaceName).
+		Key([]interface{}{uint(1)})).Get()
+	require.NoError(t, err)
+	assert.Empty(t, resp.Data)
+}
+
+func TestCrud_wrongType(t *testing.T) {
+	server := startServer(t)
+	defer server.Close()
+	conn := connect(t, server, opts)
+	defer conn.Close()
+
+	_, err := conn.Do(tarantool.NewInsertRequest(spaceName).
+		Tuple([]interface{}{"1", "one"})).Get()
+	var tntErr tarantool.Error
+	require.True(t, errors.As(err, &tntErr))
+	assert.Equal(t, iproto.ER_FIELD_TYPE, tntErr.Code)
+}
+
+func TestCrud_readOnly(t *testing.T) {
+	server := startServer(t)
+	defer server.Close()
+	conn := connect(t, server, opts)
+	defer conn.Close()
+
+	server.SetReadOnly(true)
+	_, err := conn.Do(tarantool.NewInsertRequest(spaceName).
+		Tuple([]interface{}{uint(1), "one"})).Get()
+	var tntErr tarantool.Error
+	require.True(t, errors.As(err, &tntErr))
+	assert.Equal(t, iproto.ER_READONLY, tntErr.Code)
+}
+
+func TestSelect_pagination(t *testing.T) {
+	server := startServer(t)
+	defer server.Close()
+	conn := connect(t, server, opts)
+	defer conn.Close()
+
+	for i := 1; i <= 5; i++ {
+		_, err := conn.Do(tarantool.NewInsertRequest(spaceName).
+			Tuple([]interface{}{uint(i), "name"})).Get()
+		require.NoError(t, err)
+	}
+
+	resp, err := conn.Do(tarantool.NewSelectRequest(spaceName).
+		Iterator(tarantool.IterAll).
+		Limit(2).
+		FetchPos(true)).Get()
+	require.NoError(t, err)
+	require.Len(t, resp.Data, 2)
+	require.NotEmpty(t, resp.Pos)
+
+	resp, err = conn.Do(tarantool.NewSelectRequest(spaceName).
+		Iterator(tarantool.IterAll).
+		Limit(2).
+		After(resp.Pos)).Get()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		[]interface{}{int8(3), "name"},
+		[]interface{}{int8(4), "name"},
+	}, resp.Data)
+}
+
+func TestCall(t *testing.T) {
+	server := startServer(t)
+	defer server.Close()
+	server.RegisterFunction("sum", func(call fakeserver.Call) ([]interface{}, error) {
+		var sum int64
+		for i, arg := range call.Args {
+			if err := call.Push(i); err != nil {
+				return nil, err
+			}
+			sum += int64(arg.(uint64))
+		}
+		return []interface{}{sum}, nil
+	})
+	server.RegisterFunction("fail", func(call fakeserver.Call) ([]interface{}, error) {
+		return nil, errors.New("fail error")
+	})
+	conn := connect(t, server, opts)
+	defer conn.Close()
+
+	fut := conn.Do(tarantool.NewCallRequest("sum").Args([]interface{}{1, 2, 3}))
+	resp, err := fut.Get()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{int8(6)}, resp.Data)
+
+	var pushes []interface{}
+	it := fut.GetIterator()
+	for it.Next() {
+		resp := it.Value()
+		if resp.Code == tarantool.PushCode {
+			pushes = append(pushes, resp.Data[0])
+		}
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, []interface{}{int8(0), int8(1), int8(2)}, pushes)
+
+	resp, err = conn.Do(tarantool.NewCall16Request("sum").
+		Args([]interface{}{1})).Get()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{[]interface{}{int8(1)}}, resp.Data)
+
+	_, err = conn.Do(tarantool.NewCallRequest("fail")).Get()
+	var tntErr tarantool.Error
+	require.True(t, errors.As(err, &tntErr))
+	assert.Equal(t, iproto.ER_PROC_LUA, tntErr.Code)
+	assert.Equal(t, "fail error", tntErr.Msg)
+
+	_, err = conn.Do(tarantool.NewCallRequest("unknown")).Get()
+	require.True(t, errors.As(err, &tntErr))
+	assert.Equal(t, iproto.ER_NO_SUCH_PROC, tntErr.Code)
+}
+
+func TestEval(t *testing.T) {
+	server := startServer(t)
+	defer server.Close()
+	server.RegisterEval("return ...", func(call fakeserver.Call) ([]interface{}, error) {
+		return call.Args, nil
+	})
+	conn := connect(t, server, opts)
+	defer conn.Close()
+
+	resp, err := conn.Do(tarantool.NewEvalRequest("return ...").
+		Args([]interface{}{"foo", "bar"})).Get()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"foo", "bar"}, resp.Data)
+}
+
+func TestWatcher(t *testing.T) {
+	server := startServer(t)
+	defer server.Close()
+	watchOpts := opts
+	watchOpts.RequiredProtocolInfo.Features = []tarantool.ProtocolFeature{
+		tarantool.WatchersFeature,
+	}
+	conn := connect(t, server, watchOpts)
+	defer conn.Close()
+
+	events := make(chan tarantool.WatchEvent, 10)
+	watcher, err := conn.NewWatcher("key", func(event tarantool.WatchEvent) {
+		events <- event
+	})
+	require.NoError(t, err)
+	defer watcher.Unregister()
+
+	event := <-events
+	assert.Nil(t, event.Value)
+
+	server.Broadcast("key", "value")
+	event = <-events
+	assert.Equal(t, "value", event.Value)
+}
+
+func TestStream_rollback(t *testing.T) {
+	server := startServer(t)
+	defer server.Close()
+	conn := connect(t, server, opts)
+	defer conn.Close()
+
+	stream, err := conn.NewStream()
+	require.NoError(t, err)
+
+	_, err = stream.Do(tarantool.NewBeginRequest()).Get()
+	require.NoError(t, err)
+	_, err = stream.Do(tarantool.NewInsertRequest(spaceName).
+		Tuple([]interface{}{uint(1), "one"})).Get()
+	require.NoError(t, err)
+
+	resp, err := stream.Do(tarantool.NewSelectRequest(spaceName).
+		Key([]interface{}{uint(1)})).Get()
+	require.NoError(t, err)
+	require.Len(t, resp.Data, 1)
+
+	_, err = stream.Do(tarantool.NewRollbackRequest()).Get()
+	require.NoError(t, err)
+
+	resp, err = conn.Do(tarantool.NewSelectRequest(spaceName).
+		Key([]interface{}{uint(1)})).Get()
+	require.NoError(t, err)
+	assert.Empty(t, resp.Data)
+}
+
+func TestStream_commit(t *testing.T) {
+	server := startServer(t)
+	defer server.Close()
+	conn := connect(t, server, opts)
+	defer conn.Close()
+
+	stream, err := conn.NewStream()
+	require.NoError(t, err)
+
+	_, err = stream.Do(tarantool.NewBeginRequest()).Get()
+	require.NoError(t, err)
+	_, err = stream.Do(tarantool.NewBeginRequest()).Get()
+	var tntErr tarantool.Error
+	require.True(t, errors.As(err, &tntErr))
+	assert.Equal(t, iproto.ER_ACTIVE_TRANSACTION, tntErr.Code)
+
+	_, err = stream.Do(tarantool.NewInsertRequest(spaceName).
+		Tuple([]interface{}{uint(1), "one"})).Get()
+	require.NoError(t, err)
+	_, err = stream.Do(tarantool.NewCommitRequest()).Get()
+	require.NoError(t, err)
+
+	resp, err := conn.Do(tarantool.NewSelectRequest(spaceName).
+		Key([]interface{}{uint(1)})).Get()
+	require.NoError(t, err)
+	assert.Len(t, resp.Data, 1)
+}
+
+func TestDropConnections(t *testing.T) {
+	server := startServer(t)
+	defer server.Close()
+
+	reconnectOpts := opts
+	reconnectOpts.Reconnect = 10 * time.Millisecond
+	conn := connect(t, server, reconnectOpts)
+	defer conn.Close()
+
+	server.DropConnections()
+
+	require.Eventually(t, func() bool {
+		_, err := conn.Do(tarantool.NewPingRequest()).Get()
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestPool(t *testing.T) {
+	master := startServer(t)
+	defer master.Close()
+	replica := startServer(t)
+	defer replica.Close()
+	replica.SetReadOnly(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	connPool, err := pool.Connect(ctx, []pool.Instance{
+		{Addr: master.Addr(), Opts: opts},
+		{Addr: replica.Addr(), Opts: opts},
+	})
+	require.NoError(t, err)
+	defer connPool.Close()
+
+	roles := connPool.GetPoolInfo()
+	assert.Equal(t, pool.MasterRole, roles[master.Addr()].ConnRole)
+	assert.Equal(t, pool.ReplicaRole, roles[replica.Addr()].ConnRole)
+
+	_, err = connPool.Do(tarantool.NewInsertRequest(spaceName).
+		Tuple([]interface{}{uint(1), "one"}), pool.RW).Get()
+	require.NoError(t, err)
+
+	resp, err := connPool.Do(tarantool.NewSelectRequest(spaceName).
+		Key([]interface{}{uint(1)}), pool.RO).Get()
+	require.NoError(t, err)
+	assert.Empty(t, resp.Data)
+}
@@ -0,0 +1,613 @@
+package fakeserver
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/tarantool/go-iproto"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/tarantool/go-tarantool/v2"
+)
+
+const (
+	vspaceSpaceId = 281
+	vindexSpaceId = 289
+	spaceSpaceId  = 280
+	indexSpaceId  = 288
+)
+
+type space struct {
+	def    Space
+	system bool
+	tuples [][]interface{}
+}
+
+// txn is an interactive transaction. It keeps an undo log to rollback
+// changes.
+type txn struct {
+	undo []undoEntry
+}
+
+type undoEntry struct {
+	space    uint32
+	old, new []interface{}
+}
+
+// execute executes a data request. Tuples are sent as MP_TUPLE values with
+// formats if tuples is true.
+func (s *Server) execute(req *request, tx *txn,
+	tuples bool) (map[iproto.Key]interface{}, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	body, err := s.executeLocked(req, tx)
+	if err != nil || !tuples {
+		return body, err
+	}
+
+	spaceId, _ := toUint(req.body[iproto.IPROTO_SPACE_ID])
+	sp, err := s.space(uint32(spaceId))
+	if err != nil {
+		return nil, err
+	}
+	data, _ := body[iproto.IPROTO_DATA].([]interface{})
+	for i, tuple := range data {
+		data[i] = tupleValue{formatId: uint64(sp.def.Id), fields: tuple.([]interface{})}
+	}
+	body[iprotoTupleFormats] = map[uint64]interface{}{
+		uint64(sp.def.Id): sp.format(),
+	}
+	return body, nil
+}
+
+// format returns the space format as Tarantool sends it with MP_TUPLE values.
+func (sp *space) format() []interface{} {
+	format := make([]interface{}, 0, len(sp.def.Format))
+	for _, field := range sp.def.Format {
+		def := map[string]interface{}{
+			"name": field.Name,
+			"type": field.Type,
+		}
+		if field.IsNullable {
+			def["is_nullable"] = true
+		}
+		format = append(format, def)
+	}
+	return format
+}
+
+// executeLocked executes a data request. It must be called with the locked
+// mutex.
+func (s *Server) executeLocked(req *request, tx *txn) (map[iproto.Key]interface{}, error) {
+	if req.schemaVersion != 0 && req.schemaVersion != s.schemaVersion {
+		return nil, Error{iproto.ER_WRONG_SCHEMA_VERSION,
+			fmt.Sprintf("Wrong schema version, current: %d, in request: %d",
+				s.schemaVersion, req.schemaVersion)}
+	}
+	if err := s.resolveNames(req); err != nil {
+		return nil, err
+	}
+
+	spaceId, _ := toUint(req.body[iproto.IPROTO_SPACE_ID])
+	sp, err := s.space(uint32(spaceId))
+	if err != nil {
+		return nil, err
+	}
+
+	if req.typ == iproto.IPROTO_SELECT {
+		return sp.selectTuples(req, s.hasFeature(tarantool.PaginationFeature))
+	}
+
+	if sp.system {
+		return nil, Error{iproto.ER_ACCESS_DENIED,
+			fmt.Sprintf("Write access to space '%s' is denied", sp.def.Name)}
+	}
+	if s.readOnly {
+		return nil, Error{iproto.ER_READONLY,
+			"Can't modify data on a read-only instance"}
+	}
+
+	indexId, _ := toUint(req.body[iproto.IPROTO_INDEX_ID])
+	key, _ := req.body[iproto.IPROTO_KEY].([]interface{})
+	tuple, _ := req.body[iproto.IPROTO_TUPLE].([]interface{})
+	base, _ := toInt(req.body[iproto.IPROTO_INDEX_BASE])
+
+	var old, new []interface{}
+	switch req.typ {
+	case iproto.IPROTO_INSERT:
+		old, new, err = sp.put(tuple, false)
+	case iproto.IPROTO_REPLACE:
+		old, new, err = sp.put(tuple, true)
+	case iproto.IPROTO_UPDATE:
+		old, new, err = sp.update(uint32(indexId), key, tuple, int(base))
+	case iproto.IPROTO_UPSERT:
+		ops, _ := req.body[iproto.IPROTO_OPS].([]interface{})
+		old, new, err = sp.upsert(tuple, ops, int(base))
+	case iproto.IPROTO_DELETE:
+		old, err = sp.delete(uint32(indexId), key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tx != nil && (old != nil || new != nil) {
+		tx.undo = append(tx.undo, undoEntry{space: sp.def.Id, old: old, new: new})
+	}
+
+	data := []interface{}{}
+	switch {
+	case req.typ == iproto.IPROTO_UPSERT:
+	case req.typ == iproto.IPROTO_DELETE && old != nil:
+		data = append(data, old)
+	case new != nil:
+		data = append(data, new)
+	}
+	return map[iproto.Key]interface{}{iproto.IPROTO_DATA: data}, nil
+}
+
+// rollback reverts changes of the transaction. It must be called with the
+// locked mutex.
+func (s *Server) rollback(tx *txn) {
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		entry := tx.undo[i]
+		sp, ok := s.spaces[entry.space]
+		if !ok {
+			continue
+		}
+		if entry.new != nil {
+			if i := sp.find(0, sp.key(0, entry.new)); i >= 0 {
+				sp.remove(i)
+			}
+		}
+		if entry.old != nil {
+			sp.tuples = append(sp.tuples, entry.old)
+		}
+	}
+	tx.undo = nil
+}
+
+// space returns a space by an identifier. It must be called with the locked
+// mutex.
+func (s *Server) space(id uint32) (*space, error) {
+	switch id {
+	case vspaceSpaceId, spaceSpaceId:
+		return s.systemSpaces(id), nil
+	case vindexSpaceId, indexSpaceId:
+		return s.systemIndexes(id), nil
+	}
+
+	if sp, ok := s.spaces[id]; ok {
+		return sp, nil
+	}
+	return nil, Error{iproto.ER_NO_SUCH_SPACE,
+		fmt.Sprintf("Space '%d' does not exist", id)}
+}
+
+// resolveNames replaces space and index names of the request with
+// identifiers. It must be called with the locked mutex.
+func (s *Server) resolveNames(req *request) error {
+	if name, ok := req.body[iprotoSpaceName].(string); ok {
+		var found *space
+		for _, sp := range s.spaces {
+			if sp.def.Name == name {
+				found = sp
+				break
+			}
+		}
+		if found == nil {
+			return Error{iproto.ER_NO_SUCH_SPACE,
+				fmt.Sprintf("Space '%s' does not exist", name)}
+		}
+		req.body[iproto.IPROTO_SPACE_ID] = uint64(found.def.Id)
+		delete(req.body, iprotoSpaceName)
+	}
+
+	if name, ok := req.body[iprotoIndexName].(string); ok {
+		spaceId, _ := toUint(req.body[iproto.IPROTO_SPACE_ID])
+		sp, err := s.space(uint32(spaceId))
+		if err != nil {
+			return err
+		}
+		indexId := -1
+		for i, index := range sp.def.Indexes {
+			if index.Name == name {
+				indexId = i
+				break
+			}
+		}
+		if indexId < 0 {
+			return Error{iproto.ER_NO_SUCH_INDEX_NAME,
+				fmt.Sprintf("No index '%s' is defined in space '%s'", name, sp.def.Name)}
+		}
+		req.body[iproto.IPROTO_INDEX_ID] = uint64(indexId)
+		delete(req.body, iprotoIndexName)
+	}
+	return nil
+}
+
+// systemSpaces returns a _vspace view of the spaces.
+func (s *Server) systemSpaces(id uint32) *space {
+	sp := &space{system: true, def: Space{
+		Id:   id,
+		Name: "_vspace",
+		Indexes: []Index{
+			{Name: "primary", Parts: []IndexPart{{0, "unsigned"}}},
+		},
+	}}
+	for _, def := range s.spaces {
+		format := []interface{}{}
+		for _, field := range def.def.Format {
+			format = append(format, map[string]interface{}{
+				"name":        field.Name,
+				"type":        field.Type,
+				"is_nullable": field.IsNullable,
+			})
+		}
+		sp.tuples = append(sp.tuples, []interface{}{
+			uint64(def.def.Id), uint64(1), def.def.Name, def.def.Engine,
+			uint64(0), map[string]interface{}{}, format,
+		})
+	}
+	return sp
+}
+
+// systemIndexes returns a _vindex view of the spaces.
+func (s *Server) systemIndexes(id uint32) *space {
+	sp := &space{system: true, def: Space{
+		Id:   id,
+		Name: "_vindex",
+		Indexes: []Index{
+			{Name: "primary", Parts: []IndexPart{{0, "unsigned"}, {1, "unsigned"}}},
+		},
+	}}
+	for _, def := range s.spaces {
+		for i, index := range def.def.Indexes {
+			parts := []interface{}{}
+			for _, part := range index.Parts {
+				parts = append(parts, map[string]interface{}{
+					"field": uint64(part.Field),
+					"type":  part.Type,
+				})
+			}
+			sp.tuples = append(sp.tuples, []interface{}{
+				uint64(def.def.Id), uint64(i), index.Name, index.Type,
+				map[string]interface{}{"unique": index.Unique}, parts,
+			})
+		}
+	}
+	return sp
+}
+
+func (sp *space) index(id uint32) (*Index, error) {
+	if int(id) >= len(sp.def.Indexes) {
+		return nil, Error{iproto.ER_NO_SUCH_INDEX_ID,
+			fmt.Sprintf("No index #%d is defined in space '%s'", id, sp.def.Name)}
+	}
+	return &sp.def.Indexes[id], nil
+}
+
+// key returns index parts of the tuple.
+func (sp *space) key(indexId uint32, tuple []interface{}) []interface{} {
+	parts := sp.def.Indexes[indexId].Parts
+	key := make([]interface{}, len(parts))
+	for i, part := range parts {
+		if int(part.Field) < len(tuple) {
+			key[i] = tuple[part.Field]
+		}
+	}
+	return key
+}
+
+// fullKey returns index parts of the tuple with primary key parts to make it
+// unique.
+func (sp *space) fullKey(indexId uint32, tuple []interface{}) []interface{} {
+	key := sp.key(indexId, tuple)
+	if indexId != 0 && !sp.def.Indexes[indexId].Unique {
+		key = append(key, sp.key(0, tuple)...)
+	}
+	return key
+}
+
+// find returns a position of a tuple with the key in the unique index or -1.
+func (sp *space) find(indexId uint32, key []interface{}) int {
+	for i, tuple := range sp.tuples {
+		if compareKeys(sp.key(indexId, tuple), key) == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+func (sp *space) remove(i int) {
+	sp.tuples = append(sp.tuples[:i], sp.tuples[i+1:]...)
+}
+
+func (sp *space) selectTuples(req *request, pagination bool) (map[iproto.Key]interface{}, error) {
+	indexId, _ := toUint(req.body[iproto.IPROTO_INDEX_ID])
+	index, err := sp.index(uint32(indexId))
+	if err != nil {
+		return nil, err
+	}
+	key, _ := req.body[iproto.IPROTO_KEY].([]interface{})
+	if len(key) > len(index.Parts) {
+		return nil, Error{iproto.ER_KEY_PART_COUNT,
+			fmt.Sprintf("Invalid key part count (expected [0..%d], got %d)",
+				len(index.Parts), len(key))}
+	}
+
+	iterator := iproto.ITER_EQ
+	if it, ok := toUint(req.body[iproto.IPROTO_ITERATOR]); ok {
+		iterator = iproto.Iterator(it)
+	}
+	if iterator > iproto.ITER_GT {
+		return nil, Error{iproto.ER_UNSUPPORTED,
+			fmt.Sprintf("%s does not support %s", index.Type, iterator)}
+	}
+	reverse := iterator == iproto.ITER_REQ || iterator == iproto.ITER_LT ||
+		iterator == iproto.ITER_LE
+
+	tuples := append([][]interface{}(nil), sp.tuples...)
+	sort.SliceStable(tuples, func(i, j int) bool {
+		cmp := compareKeys(sp.fullKey(uint32(indexId), tuples[i]),
+			sp.fullKey(uint32(indexId), tuples[j]))
+		if reverse {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+
+	// An old Tarantool ignores pagination keys.
+	if !pagination {
+		delete(req.body, iproto.IPROTO_AFTER_POSITION)
+		delete(req.body, iproto.IPROTO_AFTER_TUPLE)
+		delete(req.body, iproto.IPROTO_FETCH_POSITION)
+	}
+
+	var after []interface{}
+	if pos, ok := req.body[iproto.IPROTO_AFTER_POSITION]; ok {
+		after, err = decodePosition(pos)
+		if err != nil {
+			return nil, err
+		}
+	} else if tuple, ok := req.body[iproto.IPROTO_AFTER_TUPLE].([]interface{}); ok {
+		after = sp.fullKey(uint32(indexId), tuple)
+	}
+
+	offset, _ := toUint(req.body[iproto.IPROTO_OFFSET])
+	limit, ok := toUint(req.body[iproto.IPROTO_LIMIT])
+	if !ok {
+		limit = ^uint64(0)
+	}
+
+	data := []interface{}{}
+	var last []interface{}
+	for _, tuple := range tuples {
+		if uint64(len(data)) >= limit {
+			break
+		}
+		if len(key) > 0 && !matchIterator(iterator,
+			compareKeys(sp.key(uint32(indexId), tuple)[:len(key)], key)) {
+			continue
+		}
+		if after != nil {
+			cmp := compareKeys(sp.fullKey(uint32(indexId), tuple), after)
+			if (!reverse && cmp <= 0) || (reverse && cmp >= 0) {
+				continue
+			}
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		data = append(data, tuple)
+		last = tuple
+	}
+
+	body := map[iproto.Key]interface{}{iproto.IPROTO_DATA: data}
+	if fetch, _ := req.body[iproto.IPROTO_FETCH_POSITION].(bool); fetch && last != nil {
+		pos, err := msgpack.Marshal(sp.fullKey(uint32(indexId), last))
+		if err != nil {
+			return nil, err
+		}
+		body[iproto.IPROTO_POSITION] = pos
+	}
+	return body, nil
+}
+
+func matchIterator(iterator iproto.Iterator, cmp int) bool {
+	switch iterator {
+	case iproto.ITER_EQ, iproto.ITER_REQ:
+		return cmp == 0
+	case iproto.ITER_LT:
+		return cmp < 0
+	case iproto.ITER_LE:
+		return cmp <= 0
+	case iproto.ITER_GE:
+		return cmp >= 0
+	case iproto.ITER_GT:
+		return cmp > 0
+	}
+	return true
+}
+
+func decodePosition(pos interface{}) ([]interface{}, error) {
+	var b []byte
+	switch pos := pos.(type) {
+	case []byte:
+		b = pos
+	case string:
+		b = []byte(pos)
+	}
+
+	var key []interface{}
+	if err := msgpack.Unmarshal(b, &key); err != nil {
+		return nil, Error{iproto.ER_ILLEGAL_PARAMS, "Invalid position"}
+	}
+	return key, nil
+}
+
+// put inserts or replaces the tuple.
+func (sp *space) put(tuple []interface{}, replace bool) ([]interface{}, []interface{}, error) {
+	if err := sp.validate(tuple); err != nil {
+		return nil, nil, err
+	}
+
+	pos := sp.find(0, sp.key(0, tuple))
+	if pos >= 0 && !replace {
+		return nil, nil, sp.duplicate(0, sp.tuples[pos], tuple)
+	}
+	if err := sp.checkUnique(tuple, pos); err != nil {
+		return nil, nil, err
+	}
+
+	var old []interface{}
+	if pos >= 0 {
+		old = sp.tuples[pos]
+		sp.tuples[pos] = tuple
+	} else {
+		sp.tuples = append(sp.tuples, tuple)
+	}
+	return old, tuple, nil
+}
+
+func (sp *space) update(indexId uint32, key []interface{}, ops []interface{},
+	base int) ([]interface{}, []interface{}, error) {
+	pos, err := sp.findExact(indexId, key)
+	if err != nil || pos < 0 {
+		return nil, nil, err
+	}
+
+	old := sp.tuples[pos]
+	tuple, err := sp.applyOperations(old, ops, base)
+	if err != nil {
+		return nil, nil, err
+	}
+	if compareKeys(sp.key(0, old), sp.key(0, tuple)) != 0 {
+		return nil, nil, Error{iproto.ER_CANT_UPDATE_PRIMARY_KEY,
+			fmt.Sprintf("Attempt to modify a tuple field which is part of "+
+				"primary index in space '%s'", sp.def.Name)}
+	}
+	if err := sp.validate(tuple); err != nil {
+		return nil, nil, err
+	}
+	if err := sp.checkUnique(tuple, pos); err != nil {
+		return nil, nil, err
+	}
+
+	sp.tuples[pos] = tuple
+	return old, tuple, nil
+}
+
+func (sp *space) upsert(tuple []interface{}, ops []interface{},
+	base int) ([]interface{}, []interface{}, error) {
+	if err := sp.validate(tuple); err != nil {
+		return nil, nil, err
+	}
+
+	pos := sp.find(0, sp.key(0, tuple))
+	if pos < 0 {
+		return sp.put(tuple, false)
+	}
+
+	// Tarantool skips invalid operations of upsert requests.
+	old := sp.tuples[pos]
+	updated, err := sp.applyOperations(old, ops, base)
+	if err != nil || compareKeys(sp.key(0, old), sp.key(0, updated)) != 0 ||
+		sp.validate(updated) != nil || sp.checkUnique(updated, pos) != nil {
+		return nil, nil, nil
+	}
+	sp.tuples[pos] = updated
+	return old, updated, nil
+}
+
+func (sp *space) delete(indexId uint32, key []interface{}) ([]interface{}, error) {
+	pos, err := sp.findExact(indexId, key)
+	if err != nil || pos < 0 {
+		return nil, err
+	}
+
+	old := sp.tuples[pos]
+	sp.remove(pos)
+	return old, nil
+}
+
+// findExact returns a position of a tuple by a full key of the unique
+// index.
+func (sp *space) findExact(indexId uint32, key []interface{}) (int, error) {
+	index, err := sp.index(indexId)
+	if err != nil {
+		return -1, err
+	}
+	if !index.Unique {
+		return -1, Error{iproto.ER_MORE_THAN_ONE_TUPLE,
+			"Get() doesn't support partial keys and non-unique indexes"}
+	}
+	if len(key) != len(index.Parts) {
+		return -1, Error{iproto.ER_EXACT_MATCH,
+			fmt.Sprintf("Invalid key part count in an exact match "+
+				"(expected %d, got %d)", len(index.Parts), len(key))}
+	}
+	return sp.find(indexId, key), nil
+}
+
+// checkUnique checks unique indexes for the tuple except a tuple at the
+// position skip.
+func (sp *space) checkUnique(tuple []interface{}, skip int) error {
+	for i, index := range sp.def.Indexes {
+		if !index.Unique {
+			continue
+		}
+		key := sp.key(uint32(i), tuple)
+		for pos, other := range sp.tuples {
+			if pos != skip && compareKeys(sp.key(uint32(i), other), key) == 0 {
+				return sp.duplicate(uint32(i), other, tuple)
+			}
+		}
+	}
+	return nil
+}
+
+func (sp *space) duplicate(indexId uint32, old, new []interface{}) error {
+	return Error{iproto.ER_TUPLE_FOUND,
+		fmt.Sprintf("Duplicate key exists in unique index \"%s\" in space "+
+			"\"%s\" with old tuple - %v and new tuple - %v",
+			sp.def.Indexes[indexId].Name, sp.def.Name, old, new)}
+}
+
+// validate checks the tuple against the space format and indexes.
+func (sp *space) validate(tuple []interface{}) error {
+	for i, field := range sp.def.Format {
+		if i >= len(tuple) || tuple[i] == nil {
+			if field.IsNullable {
+				continue
+			}
+			return Error{iproto.ER_FIELD_MISSING,
+				fmt.Sprintf("Tuple field %d (%s) required by space format "+
+					"is missing", i+1, field.Name)}
+		}
+		if !matchType(field.Type, tuple[i]) {
+			return fieldTypeError(i, field.Type, tuple[i])
+		}
+	}
+
+	for _, index := range sp.def.Indexes {
+		for _, part := range index.Parts {
+			if int(part.Field) >= len(tuple) {
+				return Error{iproto.ER_FIELD_MISSING,
+					fmt.Sprintf("Tuple field %d required by space format "+
+						"is missing", part.Field+1)}
+			}
+			if !matchType(part.Type, tuple[part.Field]) {
+				return fieldTypeError(int(part.Field), part.Type,
+					tuple[part.Field])
+			}
+		}
+	}
+	return nil
+}
+
+func fieldTypeError(field int, expected string, value interface{}) error {
+	return Error{iproto.ER_FIELD_TYPE,
+		fmt.Sprintf("Tuple field %d type does not match one required by "+
+			"operation: expected %s, got %s", field+1, expected, typeName(value))}
+}
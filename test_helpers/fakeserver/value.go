@@ -0,0 +1,383 @@
+package fakeserver
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/tarantool/go-iproto"
+)
+
+// Value classes in the order of Tarantool scalar comparison.
+const (
+	classNil = iota
+	classBool
+	classNumber
+	classString
+	classBinary
+	classOther
+)
+
+func valueClass(value interface{}) int {
+	switch value.(type) {
+	case nil:
+		return classNil
+	case bool:
+		return classBool
+	case string:
+		return classString
+	case []byte:
+		return classBinary
+	}
+	if _, ok := toNumber(value); ok {
+		return classNumber
+	}
+	return classOther
+}
+
+// compareKeys compares keys part by part.
+func compareKeys(a, b []interface{}) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if cmp := compareValues(a[i], b[i]); cmp != 0 {
+			return cmp
+		}
+	}
+	return len(a) - len(b)
+}
+
+func compareValues(a, b interface{}) int {
+	classA, classB := valueClass(a), valueClass(b)
+	if classA != classB {
+		return classA - classB
+	}
+
+	switch classA {
+	case classBool:
+		boolA, boolB := a.(bool), b.(bool)
+		switch {
+		case boolA == boolB:
+			return 0
+		case !boolA:
+			return -1
+		default:
+			return 1
+		}
+	case classNumber:
+		numA, _ := toNumber(a)
+		numB, _ := toNumber(b)
+		return numA.Cmp(numB)
+	case classString:
+		return strings.Compare(a.(string), b.(string))
+	case classBinary:
+		return bytes.Compare(a.([]byte), b.([]byte))
+	case classOther:
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	}
+	return 0
+}
+
+// toNumber converts an integer or a floating point value into a big.Float.
+func toNumber(value interface{}) (*big.Float, bool) {
+	switch value := value.(type) {
+	case int:
+		return new(big.Float).SetInt64(int64(value)), true
+	case int8:
+		return new(big.Float).SetInt64(int64(value)), true
+	case int16:
+		return new(big.Float).SetInt64(int64(value)), true
+	case int32:
+		return new(big.Float).SetInt64(int64(value)), true
+	case int64:
+		return new(big.Float).SetInt64(value), true
+	case uint:
+		return new(big.Float).SetUint64(uint64(value)), true
+	case uint8:
+		return new(big.Float).SetUint64(uint64(value)), true
+	case uint16:
+		return new(big.Float).SetUint64(uint64(value)), true
+	case uint32:
+		return new(big.Float).SetUint64(uint64(value)), true
+	case uint64:
+		return new(big.Float).SetUint64(value), true
+	case float32:
+		return big.NewFloat(float64(value)), !math.IsNaN(float64(value))
+	case float64:
+		return big.NewFloat(value), !math.IsNaN(value)
+	}
+	return nil, false
+}
+
+func isInteger(value interface{}) bool {
+	switch value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return true
+	}
+	return false
+}
+
+func toInt(value interface{}) (int64, bool) {
+	if !isInteger(value) {
+		return 0, false
+	}
+	num, _ := toNumber(value)
+	i, accuracy := num.Int64()
+	return i, accuracy == big.Exact
+}
+
+func toUint(value interface{}) (uint64, bool) {
+	if !isInteger(value) {
+		return 0, false
+	}
+	num, _ := toNumber(value)
+	u, accuracy := num.Uint64()
+	return u, accuracy == big.Exact
+}
+
+func typeName(value interface{}) string {
+	switch valueClass(value) {
+	case classNil:
+		return "nil"
+	case classBool:
+		return "boolean"
+	case classNumber:
+		if !isInteger(value) {
+			return "double"
+		}
+		if _, ok := toUint(value); ok {
+			return "unsigned"
+		}
+		return "integer"
+	case classString:
+		return "string"
+	case classBinary:
+		return "varbinary"
+	}
+	switch value.(type) {
+	case []interface{}:
+		return "array"
+	case map[interface{}]interface{}, map[string]interface{}:
+		return "map"
+	}
+	return "extension"
+}
+
+// matchType checks the value against a field type. Extension types are not
+// checked.
+func matchType(typ string, value interface{}) bool {
+	switch typ {
+	case "", "any":
+		return true
+	case "unsigned":
+		_, ok := toUint(value)
+		return ok
+	case "integer":
+		return isInteger(value)
+	case "number", "double":
+		return valueClass(value) == classNumber
+	case "string":
+		return valueClass(value) == classString
+	case "boolean":
+		return valueClass(value) == classBool
+	case "varbinary":
+		return valueClass(value) == classBinary
+	case "scalar":
+		return valueClass(value) != classNil && typeName(value) != "array" &&
+			typeName(value) != "map"
+	case "array":
+		return typeName(value) == "array"
+	case "map":
+		return typeName(value) == "map"
+	}
+	return true
+}
+
+// applyOperations applies update operations to a copy of the tuple.
+func (sp *space) applyOperations(tuple []interface{}, ops []interface{},
+	base int) ([]interface{}, error) {
+	result := append([]interface{}(nil), tuple...)
+
+	for _, op := range ops {
+		args, ok := op.([]interface{})
+		if !ok || len(args) < 3 {
+			return nil, Error{iproto.ER_ILLEGAL_PARAMS,
+				"Illegal parameters, update operation must be an array {op,..}"}
+		}
+		opcode, _ := args[0].(string)
+		field, err := sp.operationField(result, opcode, args[1], base)
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case "=":
+			if field == len(result) {
+				result = append(result, args[2])
+			} else {
+				result[field] = args[2]
+			}
+		case "!":
+			result = append(result[:field],
+				append([]interface{}{args[2]}, result[field:]...)...)
+		case "#":
+			count, ok := toUint(args[2])
+			if !ok || count == 0 {
+				return nil, Error{iproto.ER_UPDATE_ARG_TYPE,
+					fmt.Sprintf("Argument type in operation '#' on field %d "+
+						"does not match field type: expected a positive integer",
+						field+1)}
+			}
+			end := field + int(count)
+			if end > len(result) {
+				end = len(result)
+			}
+			result = append(result[:field], result[end:]...)
+		case "+", "-", "&", "|", "^":
+			if result[field], err = arithmetic(opcode, result[field], args[2],
+				field); err != nil {
+				return nil, err
+			}
+		case ":":
+			if result[field], err = splice(result[field], args, field); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, Error{iproto.ER_UNKNOWN_UPDATE_OP,
+				fmt.Sprintf("Unknown UPDATE operation #%d: '%s'", field+1, opcode)}
+		}
+	}
+	return result, nil
+}
+
+// operationField returns a zero-based field number for the operation.
+func (sp *space) operationField(tuple []interface{}, opcode string,
+	field interface{}, base int) (int, error) {
+	var no int
+	if name, ok := field.(string); ok {
+		no = -1
+		for i, format := range sp.def.Format {
+			if format.Name == name {
+				no = i
+			}
+		}
+		if no < 0 {
+			return 0, Error{iproto.ER_NO_SUCH_FIELD_NAME,
+				fmt.Sprintf("Field '%s' was not found in the tuple", name)}
+		}
+	} else if i, ok := toInt(field); ok {
+		no = int(i)
+		if no < 0 {
+			no += len(tuple)
+			if opcode == "!" {
+				no++
+			}
+		} else {
+			no -= base
+		}
+	} else {
+		return 0, Error{iproto.ER_ILLEGAL_PARAMS,
+			"Illegal parameters, field id must be a number or a string"}
+	}
+
+	max := len(tuple)
+	if opcode == "=" || opcode == "!" {
+		max++
+	}
+	if no < 0 || no >= max {
+		return 0, Error{iproto.ER_NO_SUCH_FIELD_NO,
+			fmt.Sprintf("Field %v was not found in the tuple", field)}
+	}
+	return no, nil
+}
+
+func arithmetic(opcode string, value, arg interface{}, field int) (interface{}, error) {
+	argError := Error{iproto.ER_UPDATE_ARG_TYPE,
+		fmt.Sprintf("Argument type in operation '%s' on field %d does not "+
+			"match field type: expected a number", opcode, field+1)}
+
+	if opcode == "+" || opcode == "-" {
+		a, okA := toNumber(value)
+		b, okB := toNumber(arg)
+		if !okA || !okB {
+			return nil, argError
+		}
+		if opcode == "-" {
+			b.Neg(b)
+		}
+		sum := new(big.Float).Add(a, b)
+
+		if !isInteger(value) || !isInteger(arg) {
+			f, _ := sum.Float64()
+			return f, nil
+		}
+		if i, accuracy := sum.Int64(); accuracy == big.Exact && i < 0 {
+			return i, nil
+		}
+		if u, accuracy := sum.Uint64(); accuracy == big.Exact {
+			return u, nil
+		}
+		return nil, Error{iproto.ER_UPDATE_INTEGER_OVERFLOW,
+			fmt.Sprintf("Integer overflow when performing '%s' operation on "+
+				"field %d", opcode, field+1)}
+	}
+
+	a, okA := toUint(value)
+	b, okB := toUint(arg)
+	if !okA || !okB {
+		argError.Msg = fmt.Sprintf("Argument type in operation '%s' on field "+
+			"%d does not match field type: expected a positive integer",
+			opcode, field+1)
+		return nil, argError
+	}
+	switch opcode {
+	case "&":
+		return a & b, nil
+	case "|":
+		return a | b, nil
+	default:
+		return a ^ b, nil
+	}
+}
+
+func splice(value interface{}, args []interface{}, field int) (interface{}, error) {
+	str, ok := value.(string)
+	if !ok || len(args) != 5 {
+		return nil, Error{iproto.ER_UPDATE_ARG_TYPE,
+			fmt.Sprintf("Argument type in operation ':' on field %d does not "+
+				"match field type: expected a string", field+1)}
+	}
+	offset, okOffset := toInt(args[2])
+	length, okLength := toInt(args[3])
+	paste, okPaste := args[4].(string)
+	if !okOffset || !okLength || !okPaste {
+		return nil, Error{iproto.ER_ILLEGAL_PARAMS,
+			"Illegal parameters, splice arguments must be {':', field, " +
+				"offset, length, string}"}
+	}
+
+	// The offset is one-based, a negative offset is counted from the end.
+	pos := int(offset)
+	if pos < 0 {
+		pos += len(str) + 1
+	} else if pos > 0 {
+		pos--
+	}
+	if pos < 0 {
+		pos = 0
+	} else if pos > len(str) {
+		pos = len(str)
+	}
+
+	cut := int(length)
+	if cut < 0 {
+		cut += len(str) - pos
+		if cut < 0 {
+			cut = 0
+		}
+	}
+	if pos+cut > len(str) {
+		cut = len(str) - pos
+	}
+	return str[:pos] + paste + str[pos+cut:], nil
+}
@@ -0,0 +1,57 @@
+package tarantool
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+)
+
+// tlsDialContext connects to the address and makes a TLS handshake with
+// crypto/tls. Unlike "ssl" transport it does not depend on cgo and OpenSSL.
+func tlsDialContext(ctx context.Context, network, address string,
+	config *tls.Config) (net.Conn, error) {
+	var dialer net.Dialer
+	rawConn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	if config == nil {
+		config = &tls.Config{}
+	}
+	// A server name is required to verify a certificate of the server.
+	if config.ServerName == "" && !config.InsecureSkipVerify {
+		config = config.Clone()
+		if host, _, err := net.SplitHostPort(address); err == nil {
+			config.ServerName = host
+		} else {
+			config.ServerName = address
+		}
+	}
+	conn := tls.Client(rawConn, config)
+
+	// tls.Conn.HandshakeContext() is not available in old Go versions, so
+	// the handshake is interrupted by closing the connection.
+	handshaked := make(chan struct{})
+	interrupted := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			rawConn.Close()
+			interrupted <- true
+		case <-handshaked:
+			interrupted <- false
+		}
+	}()
+
+	err = conn.Handshake()
+	close(handshaked)
+	if <-interrupted {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
@@ -0,0 +1,177 @@
+package tarantool_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/tarantool/go-tarantool/v2"
+	"github.com/tarantool/go-tarantool/v2/test_helpers"
+	"github.com/tarantool/go-tarantool/v2/test_helpers/fakeserver"
+)
+
+func tlsCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	cert, err := tls.LoadX509KeyPair("testdata/localhost.crt", "testdata/localhost.key")
+	require.NoError(t, err)
+	return cert
+}
+
+func tlsCaPool(t *testing.T) *x509.CertPool {
+	t.Helper()
+
+	ca, err := ioutil.ReadFile("testdata/ca.crt")
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	require.True(t, pool.AppendCertsFromPEM(ca))
+	return pool
+}
+
+func startTlsServer(t *testing.T, config *tls.Config) *fakeserver.Server {
+	t.Helper()
+
+	srv, err := fakeserver.Start(fakeserver.Opts{
+		User: "test",
+		Pass: "test",
+		Tls:  config,
+	})
+	require.NoError(t, err)
+	return srv
+}
+
+func connectTls(addr string, config *tls.Config) (*Connection, error) {
+	ctx, cancel := test_helpers.GetConnectContext()
+	defer cancel()
+	return Connect(ctx, addr, Opts{
+		User:      "test",
+		Pass:      "test",
+		Timeout:   5 * time.Second,
+		Transport: "tls",
+		Tls:       config,
+	})
+}
+
+func TestTlsTransport(t *testing.T) {
+	srv := startTlsServer(t, &tls.Config{
+		Certificates: []tls.Certificate{tlsCertificate(t)},
+	})
+	defer srv.Close()
+
+	conn, err := connectTls(srv.Addr(), &tls.Config{RootCAs: tlsCaPool(t)})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Do(NewPingRequest()).Get()
+	require.NoError(t, err)
+}
+
+func TestTlsTransport_unknownAuthority(t *testing.T) {
+	srv := startTlsServer(t, &tls.Config{
+		Certificates: []tls.Certificate{tlsCertificate(t)},
+	})
+	defer srv.Close()
+
+	conn, err := connectTls(srv.Addr(), nil)
+	require.Error(t, err)
+	require.Nil(t, conn)
+
+	var unknownAuthority x509.UnknownAuthorityError
+	assert.ErrorAs(t, err, &unknownAuthority)
+}
+
+func TestTlsTransport_serverName(t *testing.T) {
+	srv := startTlsServer(t, &tls.Config{
+		Certificates: []tls.Certificate{tlsCertificate(t)},
+	})
+	defer srv.Close()
+
+	conn, err := connectTls(srv.Addr(), &tls.Config{
+		RootCAs:    tlsCaPool(t),
+		ServerName: "localhost",
+	})
+	require.NoError(t, err)
+	conn.Close()
+
+	conn, err = connectTls(srv.Addr(), &tls.Config{
+		RootCAs:    tlsCaPool(t),
+		ServerName: "example.com",
+	})
+	require.Error(t, err)
+	require.Nil(t, conn)
+}
+
+func TestTlsTransport_clientCertificate(t *testing.T) {
+	srv := startTlsServer(t, &tls.Config{
+		Certificates: []tls.Certificate{tlsCertificate(t)},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    tlsCaPool(t),
+	})
+	defer srv.Close()
+
+	conn, err := connectTls(srv.Addr(), &tls.Config{
+		RootCAs:      tlsCaPool(t),
+		Certificates: []tls.Certificate{tlsCertificate(t)},
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Do(NewPingRequest()).Get()
+	require.NoError(t, err)
+}
+
+func TestTlsTransport_plainServer(t *testing.T) {
+	srv := startTlsServer(t, nil)
+	defer srv.Close()
+
+	conn, err := connectTls(srv.Addr(), &tls.Config{RootCAs: tlsCaPool(t)})
+	require.Error(t, err)
+	require.Nil(t, conn)
+}
+
+func TestTlsTransport_handshakeCanceled(t *testing.T) {
+	// The server accepts connections, but does not answer.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if c, err := listener.Accept(); err == nil {
+			accepted <- c
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	conn, err := Connect(ctx, listener.Addr().String(), Opts{
+		Timeout:   5 * time.Second,
+		Transport: "tls",
+		Tls:       &tls.Config{RootCAs: tlsCaPool(t)},
+	})
+	require.Error(t, err)
+	require.Nil(t, conn)
+	assert.Less(t, int64(time.Since(start)), int64(5*time.Second))
+
+	(<-accepted).Close()
+}
+
+func TestOpts_Clone_tls(t *testing.T) {
+	opts := Opts{
+		Transport: "tls",
+		Tls:       &tls.Config{ServerName: "localhost"},
+	}
+
+	clone := opts.Clone()
+	clone.Tls.ServerName = "example.com"
+
+	assert.Equal(t, "localhost", opts.Tls.ServerName)
+	assert.Nil(t, Opts{}.Clone().Tls)
+}
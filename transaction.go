@@ -0,0 +1,104 @@
+package tarantool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tarantool/go-iproto"
+)
+
+// TxnOpts is options for RunInTransaction().
+type TxnOpts struct {
+	// Isolation is an isolation level of the transaction.
+	Isolation TxnIsolationLevel
+	// Timeout is a timeout of the transaction on the Tarantool side. If
+	// Timeout is zero, the Tarantool default timeout is used.
+	Timeout time.Duration
+	// Retries is a maximum number of additional attempts to run the
+	// transaction if it is aborted because of a conflict with another
+	// transaction (ER_TRANSACTION_CONFLICT).
+	Retries int
+}
+
+// RunInTransaction runs the function within an interactive transaction in
+// a new stream of the connector.
+//
+// The transaction is committed if the function returns nil and is rolled
+// back if the function returns an error or panics. The whole transaction
+// is executed again with a new stream up to opts.Retries times if the
+// function or the commit fails with ER_TRANSACTION_CONFLICT, so the function
+// should not have side effects out of the transaction. Requests of the
+// function should use the stream.
+//
+// The context is used for begin and commit requests and is checked before
+// each attempt. A rollback request is sent regardless of the context.
+func RunInTransaction(ctx context.Context, connector Connector, opts TxnOpts,
+	fn func(s *Stream) error) error {
+	for attempt := 0; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := runTransaction(ctx, connector, opts, fn)
+		if err == nil || attempt >= opts.Retries || !IsTransactionConflict(err) {
+			return err
+		}
+	}
+}
+
+// IsTransactionConflict returns true if the error means that a transaction
+// has been aborted because of a conflict with another transaction.
+func IsTransactionConflict(err error) bool {
+	var tntErr Error
+	if !errors.As(err, &tntErr) {
+		return false
+	}
+	if tntErr.Code == iproto.ER_TRANSACTION_CONFLICT {
+		return true
+	}
+	return tntErr.ExtendedInfo != nil &&
+		tntErr.ExtendedInfo.Code == uint64(iproto.ER_TRANSACTION_CONFLICT)
+}
+
+func runTransaction(ctx context.Context, connector Connector, opts TxnOpts,
+	fn func(s *Stream) error) (err error) {
+	stream, err := connector.NewStream()
+	if err != nil {
+		return err
+	}
+
+	begin := NewBeginRequest().
+		TxnIsolation(opts.Isolation).
+		Timeout(opts.Timeout).
+		Context(ctx)
+	if _, err = stream.Do(begin).Get(); err != nil {
+		return fmt.Errorf("failed to begin a transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+		// The function returned an error or panics.
+		if _, rollbackErr := stream.Do(NewRollbackRequest()).Get(); rollbackErr != nil &&
+			err != nil {
+			err = fmt.Errorf("%w (rollback failed: %s)", err, rollbackErr)
+		}
+	}()
+
+	if err = fn(stream); err != nil {
+		return err
+	}
+
+	committed = true
+	if _, err = stream.Do(NewCommitRequest().Context(ctx)).Get(); err != nil {
+		// Tarantool rolls back the transaction on a commit failure, but it
+		// is still active if the commit request was not sent.
+		stream.Do(NewRollbackRequest())
+		return err
+	}
+	return nil
+}
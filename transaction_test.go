@@ -0,0 +1,176 @@
+package tarantool_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tarantool/go-iproto"
+
+	. "github.com/tarantool/go-tarantool/v2"
+	"github.com/tarantool/go-tarantool/v2/test_helpers"
+	"github.com/tarantool/go-tarantool/v2/test_helpers/fakeserver"
+)
+
+func startTxnServer(t *testing.T) (*fakeserver.Server, *Connection) {
+	t.Helper()
+
+	srv, err := fakeserver.StartWithSpace(fakeserver.Opts{}, fakeserver.Space{
+		Id:   1000,
+		Name: "txn",
+		Indexes: []fakeserver.Index{
+			{Name: "primary", Parts: []fakeserver.IndexPart{{Field: 0, Type: "unsigned"}}},
+		},
+	})
+	require.NoError(t, err)
+
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{Timeout: 5 * time.Second})
+	return srv, conn
+}
+
+func selectTxnIds(t *testing.T, conn *Connection) []uint {
+	t.Helper()
+
+	var tuples [][]uint
+	err := conn.Do(NewSelectRequest("txn").Limit(100)).GetTyped(&tuples)
+	require.NoError(t, err)
+
+	ids := []uint{}
+	for _, tuple := range tuples {
+		ids = append(ids, tuple[0])
+	}
+	return ids
+}
+
+func insertTxn(s *Stream, id uint) error {
+	_, err := s.Do(NewInsertRequest("txn").Tuple([]interface{}{id})).Get()
+	return err
+}
+
+func TestRunInTransaction_commit(t *testing.T) {
+	srv, conn := startTxnServer(t)
+	defer srv.Close()
+	defer conn.Close()
+
+	err := RunInTransaction(context.Background(), conn, TxnOpts{
+		Isolation: ReadCommittedLevel,
+		Timeout:   time.Second,
+	}, func(s *Stream) error {
+		if err := insertTxn(s, 1); err != nil {
+			return err
+		}
+		return insertTxn(s, 2)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{1, 2}, selectTxnIds(t, conn))
+}
+
+func TestRunInTransaction_rollback(t *testing.T) {
+	srv, conn := startTxnServer(t)
+	defer srv.Close()
+	defer conn.Close()
+
+	fnErr := errors.New("some error")
+	err := RunInTransaction(context.Background(), conn, TxnOpts{Retries: 3},
+		func(s *Stream) error {
+			require.NoError(t, insertTxn(s, 1))
+			return fnErr
+		})
+	assert.Equal(t, fnErr, err)
+	assert.Equal(t, []uint{}, selectTxnIds(t, conn))
+}
+
+func TestRunInTransaction_panic(t *testing.T) {
+	srv, conn := startTxnServer(t)
+	defer srv.Close()
+	defer conn.Close()
+
+	assert.PanicsWithValue(t, "some panic", func() {
+		RunInTransaction(context.Background(), conn, TxnOpts{},
+			func(s *Stream) error {
+				require.NoError(t, insertTxn(s, 1))
+				panic("some panic")
+			})
+	})
+	assert.Equal(t, []uint{}, selectTxnIds(t, conn))
+}
+
+func TestRunInTransaction_conflict(t *testing.T) {
+	srv, conn := startTxnServer(t)
+	defer srv.Close()
+	defer conn.Close()
+
+	conflicts := 2
+	srv.RegisterFunction("conflict", func(call fakeserver.Call) ([]interface{}, error) {
+		if conflicts > 0 {
+			conflicts--
+			return nil, fakeserver.Error{Code: iproto.ER_TRANSACTION_CONFLICT,
+				Msg: "Transaction has been aborted by conflict"}
+		}
+		return nil, nil
+	})
+
+	attempts := 0
+	err := RunInTransaction(context.Background(), conn, TxnOpts{Retries: 2},
+		func(s *Stream) error {
+			attempts++
+			if err := insertTxn(s, uint(attempts)); err != nil {
+				return err
+			}
+			_, err := s.Do(NewCallRequest("conflict")).Get()
+			return err
+		})
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, []uint{3}, selectTxnIds(t, conn))
+}
+
+func TestRunInTransaction_conflictRetriesExceeded(t *testing.T) {
+	srv, conn := startTxnServer(t)
+	defer srv.Close()
+	defer conn.Close()
+
+	attempts := 0
+	conflict := Error{Code: iproto.ER_TRANSACTION_CONFLICT}
+	err := RunInTransaction(context.Background(), conn, TxnOpts{Retries: 2},
+		func(s *Stream) error {
+			attempts++
+			return conflict
+		})
+	assert.Equal(t, conflict, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestRunInTransaction_context(t *testing.T) {
+	srv, conn := startTxnServer(t)
+	defer srv.Close()
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	err := RunInTransaction(ctx, conn, TxnOpts{Retries: 5},
+		func(s *Stream) error {
+			attempts++
+			cancel()
+			return Error{Code: iproto.ER_TRANSACTION_CONFLICT}
+		})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestIsTransactionConflict(t *testing.T) {
+	assert.True(t, IsTransactionConflict(Error{Code: iproto.ER_TRANSACTION_CONFLICT}))
+	assert.True(t, IsTransactionConflict(Error{
+		Code:         iproto.ER_PROC_LUA,
+		ExtendedInfo: &BoxError{Code: uint64(iproto.ER_TRANSACTION_CONFLICT)},
+	}))
+	assert.True(t, IsTransactionConflict(
+		fmt.Errorf("wrapped: %w", Error{Code: iproto.ER_TRANSACTION_CONFLICT})))
+	assert.False(t, IsTransactionConflict(Error{Code: iproto.ER_PROC_LUA}))
+	assert.False(t, IsTransactionConflict(errors.New("some error")))
+	assert.False(t, IsTransactionConflict(nil))
+}
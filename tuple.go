@@ -0,0 +1,469 @@
+package tarantool
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+)
+
+// tupleExtID is the MP_TUPLE extension type.
+const tupleExtID = 7
+
+var tupleType = reflect.TypeOf(FormattedTuple{})
+
+// FormattedTuple is a tuple with a format. Tarantool sends tuples as MP_TUPLE values
+// with formats if DMLTupleExtensionFeature or CallRetTupleExtensionFeature
+// is negotiated.
+//
+// Tuples are decoded as arrays into Response.Data and into typed results
+// for backward compatibility. Use FormattedTuple, a slice of tuples or a TupleMapper
+// codec as a typed result to get tuples with formats:
+//
+//	var tuples []tarantool.FormattedTuple
+//	err := conn.Do(NewSelectRequest("users")).GetTyped(&tuples)
+//	name, ok := tuples[0].Get("name")
+//
+// A FormattedTuple could be decoded from an array too, it has no format in the case.
+type FormattedTuple struct {
+	// Format describes fields of the tuple. It is nil if the tuple is
+	// received without a format.
+	Format []Field
+	// Fields contains values of the tuple fields.
+	Fields []interface{}
+	// data contains the tuple fields in MessagePack.
+	data []byte
+}
+
+// Get returns a value of the field with the name. It returns false if there
+// is no such field in the format or the tuple.
+func (t *FormattedTuple) Get(name string) (interface{}, bool) {
+	for i, field := range t.Format {
+		if field.Name == name {
+			if i < len(t.Fields) {
+				return t.Fields[i], true
+			}
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// Decode decodes the tuple into a struct. The value must be a non-nil
+// pointer.
+//
+// Struct fields are matched with tuple fields by names from the `tarantool`
+// tag as TupleMapper does. Tuple fields without a matching struct field are
+// skipped. A tuple without a format is decoded as an array.
+func (t *FormattedTuple) Decode(value interface{}) error {
+	if t.Format == nil {
+		return msgpack.Unmarshal(t.data, value)
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("unable to decode a tuple into %T, a non-nil pointer is expected",
+			value)
+	}
+	d := msgpack.NewDecoder(bytes.NewReader(t.data))
+	return decodeTupleByFormat(d, v.Elem(), t.Format)
+}
+
+// EncodeMsgpack encodes the tuple as an array of fields, so it could be
+// passed to requests.
+func (t *FormattedTuple) EncodeMsgpack(e *msgpack.Encoder) error {
+	return e.Encode(t.Fields)
+}
+
+// DecodeMsgpack decodes an MP_TUPLE value or an array.
+func (t *FormattedTuple) DecodeMsgpack(d *msgpack.Decoder) error {
+	code, err := d.PeekCode()
+	if err != nil {
+		return err
+	}
+
+	t.Format = nil
+	if !msgpcode.IsExt(code) {
+		raw, err := d.DecodeRaw()
+		if err != nil {
+			return err
+		}
+		t.data = raw
+		return t.decodeFields()
+	}
+
+	extID, extLen, err := d.DecodeExtHeader()
+	if err != nil {
+		return err
+	}
+	if extID != tupleExtID {
+		return fmt.Errorf("msgpack: got ext type=%d, wanted %d", extID, tupleExtID)
+	}
+	payload := make([]byte, extLen)
+	if err := d.ReadFull(payload); err != nil {
+		return err
+	}
+
+	// A payload contains a format identifier or a format resolved by
+	// resolveTuples() and fields.
+	r := bytes.NewReader(payload)
+	pd := msgpack.NewDecoder(r)
+	if code, err = pd.PeekCode(); err != nil {
+		return err
+	}
+	if msgpcode.IsFixedArray(code) || code == msgpcode.Array16 || code == msgpcode.Array32 {
+		if err := pd.Decode(&t.Format); err != nil {
+			return fmt.Errorf("failed to decode a tuple format: %w", err)
+		}
+		for i := range t.Format {
+			t.Format[i].Id = uint32(i)
+		}
+	} else if _, err := pd.DecodeUint64(); err != nil {
+		return fmt.Errorf("failed to decode a tuple format id: %w", err)
+	}
+	t.data = payload[len(payload)-r.Len():]
+	return t.decodeFields()
+}
+
+func (t *FormattedTuple) decodeFields() error {
+	d := msgpack.NewDecoder(bytes.NewReader(t.data))
+	d.SetMapDecoder(func(dec *msgpack.Decoder) (interface{}, error) {
+		return dec.DecodeUntypedMap()
+	})
+
+	var err error
+	t.Fields, err = d.DecodeSlice()
+	return err
+}
+
+// decodeTupleByFormat decodes a tuple into a struct by names of fields from
+// the format.
+func decodeTupleByFormat(d *msgpack.Decoder, v reflect.Value, format []Field) error {
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("unable to map a tuple to %s, a struct is expected", v.Type())
+	}
+
+	typ := v.Type()
+	byName := make(map[string]int)
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		tag, ok := sf.Tag.Lookup(tupleMapperTag)
+		if !ok || tag == "-" {
+			continue
+		}
+		if sf.PkgPath != "" {
+			return fmt.Errorf("field %s of %s is tagged, but not exported",
+				sf.Name, typ)
+		}
+		byName[strings.Split(tag, ",")[0]] = i
+	}
+
+	l, err := d.DecodeArrayLen()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < l; i++ {
+		index, ok := -1, false
+		if i < len(format) {
+			index, ok = byName[format[i].Name]
+		}
+		if ok {
+			err = d.DecodeValue(v.Field(index))
+		} else {
+			err = d.Skip()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tupleAcceptor is implemented by typed results that decode MP_TUPLE values.
+type tupleAcceptor interface {
+	acceptTuples()
+}
+
+// acceptsTuples returns true if the typed result decodes MP_TUPLE values:
+// a FormattedTuple, a slice of tuples or a TupleMapper codec. Other results receive
+// tuples as arrays.
+func acceptsTuples(result interface{}) bool {
+	if _, ok := result.(tupleAcceptor); ok {
+		return true
+	}
+
+	typ := reflect.TypeOf(result)
+	for typ != nil {
+		switch typ.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array:
+			typ = typ.Elem()
+		default:
+			return typ == tupleType
+		}
+	}
+	return false
+}
+
+// decodeTupleFormats decodes IPROTO_TUPLE_FORMATS: MessagePack formats by
+// identifiers.
+func decodeTupleFormats(d *msgpack.Decoder) (map[uint64][]byte, error) {
+	l, err := d.DecodeMapLen()
+	if err != nil {
+		return nil, err
+	}
+
+	formats := make(map[uint64][]byte, l)
+	for ; l > 0; l-- {
+		id, err := d.DecodeUint64()
+		if err != nil {
+			return nil, err
+		}
+		raw, err := d.DecodeRaw()
+		if err != nil {
+			return nil, err
+		}
+		formats[id] = raw
+	}
+	return formats, nil
+}
+
+// resolveTuples copies MessagePack data and replaces format identifiers of
+// MP_TUPLE values with formats, so the tuples could be decoded without
+// a response. If unwrap is true, MP_TUPLE values are replaced with arrays of
+// fields.
+func resolveTuples(data []byte, formats map[uint64][]byte, unwrap bool) ([]byte, error) {
+	r := tupleResolver{
+		formats: formats,
+		unwrap:  unwrap,
+		src:     data,
+		dst:     make([]byte, 0, len(data)),
+	}
+	if err := r.value(); err != nil {
+		return nil, fmt.Errorf("failed to resolve tuples: %w", err)
+	}
+	return r.dst, nil
+}
+
+// tupleResolver walks MessagePack data without decoding of values.
+type tupleResolver struct {
+	formats map[uint64][]byte
+	unwrap  bool
+	src     []byte
+	pos     int
+	dst     []byte
+}
+
+func (r *tupleResolver) value() error {
+	if r.pos >= len(r.src) {
+		return io.ErrUnexpectedEOF
+	}
+
+	c := r.src[r.pos]
+	switch {
+	case msgpcode.IsFixedNum(c) || c == msgpcode.Nil ||
+		c == msgpcode.False || c == msgpcode.True:
+		return r.copy(1)
+	case msgpcode.IsFixedMap(c):
+		return r.container(1, 2*int(c&msgpcode.FixedMapMask))
+	case msgpcode.IsFixedArray(c):
+		return r.container(1, int(c&msgpcode.FixedArrayMask))
+	case msgpcode.IsFixedString(c):
+		return r.copy(1 + int(c&msgpcode.FixedStrMask))
+	}
+
+	switch c {
+	case msgpcode.Uint8, msgpcode.Int8:
+		return r.copy(2)
+	case msgpcode.Uint16, msgpcode.Int16:
+		return r.copy(3)
+	case msgpcode.Uint32, msgpcode.Int32, msgpcode.Float:
+		return r.copy(5)
+	case msgpcode.Uint64, msgpcode.Int64, msgpcode.Double:
+		return r.copy(9)
+	case msgpcode.Str8, msgpcode.Bin8:
+		n, err := r.length(1)
+		if err != nil {
+			return err
+		}
+		return r.copy(2 + n)
+	case msgpcode.Str16, msgpcode.Bin16:
+		n, err := r.length(2)
+		if err != nil {
+			return err
+		}
+		return r.copy(3 + n)
+	case msgpcode.Str32, msgpcode.Bin32:
+		n, err := r.length(4)
+		if err != nil {
+			return err
+		}
+		return r.copy(5 + n)
+	case msgpcode.Array16, msgpcode.Map16:
+		n, err := r.length(2)
+		if err != nil {
+			return err
+		}
+		if c == msgpcode.Map16 {
+			n *= 2
+		}
+		return r.container(3, n)
+	case msgpcode.Array32, msgpcode.Map32:
+		n, err := r.length(4)
+		if err != nil {
+			return err
+		}
+		if c == msgpcode.Map32 {
+			n *= 2
+		}
+		return r.container(5, n)
+	case msgpcode.FixExt1, msgpcode.FixExt2, msgpcode.FixExt4, msgpcode.FixExt8,
+		msgpcode.FixExt16:
+		return r.ext(2, 1<<uint(c-msgpcode.FixExt1))
+	case msgpcode.Ext8:
+		n, err := r.length(1)
+		if err != nil {
+			return err
+		}
+		return r.ext(3, n)
+	case msgpcode.Ext16:
+		n, err := r.length(2)
+		if err != nil {
+			return err
+		}
+		return r.ext(4, n)
+	case msgpcode.Ext32:
+		n, err := r.length(4)
+		if err != nil {
+			return err
+		}
+		return r.ext(6, n)
+	}
+	return fmt.Errorf("msgpack: invalid code=%x", c)
+}
+
+// length reads a big-endian length of the size after a code.
+func (r *tupleResolver) length(size int) (int, error) {
+	if r.pos+1+size > len(r.src) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	b := r.src[r.pos+1 : r.pos+1+size]
+	switch size {
+	case 1:
+		return int(b[0]), nil
+	case 2:
+		return int(binary.BigEndian.Uint16(b)), nil
+	default:
+		return int(binary.BigEndian.Uint32(b)), nil
+	}
+}
+
+func (r *tupleResolver) copy(n int) error {
+	if r.pos+n > len(r.src) {
+		return io.ErrUnexpectedEOF
+	}
+	r.dst = append(r.dst, r.src[r.pos:r.pos+n]...)
+	r.pos += n
+	return nil
+}
+
+func (r *tupleResolver) container(header, n int) error {
+	if err := r.copy(header); err != nil {
+		return err
+	}
+	for ; n > 0; n-- {
+		if err := r.value(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *tupleResolver) ext(header, n int) error {
+	if r.pos+header+n > len(r.src) {
+		return io.ErrUnexpectedEOF
+	}
+	if int8(r.src[r.pos+header-1]) != tupleExtID {
+		return r.copy(header + n)
+	}
+
+	payload := r.src[r.pos+header : r.pos+header+n]
+	pr := bytes.NewReader(payload)
+	id, err := msgpack.NewDecoder(pr).DecodeUint64()
+	if err != nil {
+		// The tuple is resolved already.
+		return r.copy(header + n)
+	}
+	fields := payload[len(payload)-pr.Len():]
+	format, ok := r.formats[id]
+
+	switch {
+	case r.unwrap:
+		r.dst = append(r.dst, fields...)
+	case !ok:
+		r.dst = append(r.dst, r.src[r.pos:r.pos+header+n]...)
+	default:
+		var buf bytes.Buffer
+		enc := msgpack.NewEncoder(&buf)
+		if err := enc.EncodeExtHeader(tupleExtID, len(format)+len(fields)); err != nil {
+			return err
+		}
+		r.dst = append(r.dst, buf.Bytes()...)
+		r.dst = append(r.dst, format...)
+		r.dst = append(r.dst, fields...)
+	}
+	r.pos += header + n
+	return nil
+}
+
+// tupleFields returns an array of fields of an MP_TUPLE value in MessagePack
+// without copying. Other values are returned as is.
+func tupleFields(raw []byte) []byte {
+	if len(raw) == 0 {
+		return raw
+	}
+
+	var header int
+	switch raw[0] {
+	case msgpcode.FixExt1, msgpcode.FixExt2, msgpcode.FixExt4, msgpcode.FixExt8,
+		msgpcode.FixExt16:
+		header = 2
+	case msgpcode.Ext8:
+		header = 3
+	case msgpcode.Ext16:
+		header = 4
+	case msgpcode.Ext32:
+		header = 6
+	default:
+		return raw
+	}
+	if len(raw) <= header || int8(raw[header-1]) != tupleExtID {
+		return raw
+	}
+
+	// Skip the format identifier.
+	payload := raw[header:]
+	var n int
+	switch c := payload[0]; {
+	case c <= msgpcode.PosFixedNumHigh:
+		n = 1
+	case c == msgpcode.Uint8:
+		n = 2
+	case c == msgpcode.Uint16:
+		n = 3
+	case c == msgpcode.Uint32:
+		n = 5
+	case c == msgpcode.Uint64:
+		n = 9
+	default:
+		return raw
+	}
+	if n >= len(payload) {
+		return raw
+	}
+	return payload[n:]
+}
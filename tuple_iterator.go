@@ -0,0 +1,111 @@
+package tarantool
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// TupleIterator walks tuples of a response one by one without decoding of
+// the whole response. Tuples are not copied: Raw() returns a part of the
+// response buffer. It allows to stream large select results without
+// intermediate allocations.
+//
+// The iterator walks values of IPROTO_DATA: tuples for select and data
+// manipulation requests, returned values for calls and evals. MP_TUPLE
+// values are returned as arrays of fields.
+//
+//	it := conn.Do(NewSelectRequest("space").Limit(100000)).GetTupleIterator()
+//	for it.Next() {
+//		var id uint64
+//		if err := it.Decoder().Decode(&id); err != nil {
+//			return err
+//		}
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+//
+// TupleIterator is not safe for concurrent use.
+type TupleIterator struct {
+	// data is IPROTO_DATA of the response.
+	data smallBuf
+	// dec walks over the data.
+	dec *msgpack.Decoder
+	// left is a number of tuples left.
+	left int
+	// raw is the current tuple in MessagePack.
+	raw []byte
+	// tuple is a buffer of the current tuple for tupleDec.
+	tuple    smallBuf
+	tupleDec *msgpack.Decoder
+	err      error
+}
+
+// newTupleIterator creates an iterator over data in MessagePack. The data
+// must be an array.
+func newTupleIterator(data []byte, err error) *TupleIterator {
+	it := &TupleIterator{
+		data: smallBuf{b: data},
+		err:  err,
+	}
+	if it.err != nil || data == nil {
+		return it
+	}
+
+	it.dec = msgpack.NewDecoder(&it.data)
+	if it.left, it.err = it.dec.DecodeArrayLen(); it.err != nil {
+		it.err = fmt.Errorf("failed to decode response data: %w", it.err)
+	} else if it.left < 0 {
+		it.err = errors.New("result is not array")
+	}
+	return it
+}
+
+// Next switches to a next tuple and returns true if it exists. It returns
+// false after the last tuple or an error.
+func (it *TupleIterator) Next() bool {
+	it.raw = nil
+	if it.err != nil || it.left <= 0 {
+		return false
+	}
+
+	start := it.data.Offset()
+	if err := it.dec.Skip(); err != nil {
+		it.err = fmt.Errorf("failed to decode response data: %w", err)
+		return false
+	}
+	it.raw = tupleFields(it.data.b[start:it.data.Offset()])
+	it.left--
+	return true
+}
+
+// Raw returns the current tuple in MessagePack. The slice points into
+// the response buffer, so it must not be modified. It is valid after
+// the next Next() call too.
+func (it *TupleIterator) Raw() []byte {
+	return it.raw
+}
+
+// Decoder returns a decoder positioned at the beginning of the current
+// tuple. The decoder is reused by the iterator: it could read the current
+// tuple only, until the next Next() call.
+func (it *TupleIterator) Decoder() *msgpack.Decoder {
+	it.tuple = smallBuf{b: it.raw}
+	if it.tupleDec == nil {
+		it.tupleDec = newBodyDecoder(&it.tuple)
+	}
+	return it.tupleDec
+}
+
+// Decode decodes the current tuple into the value.
+func (it *TupleIterator) Decode(value interface{}) error {
+	return it.Decoder().Decode(value)
+}
+
+// Err returns an error of the request or an error of decoding if it
+// happens.
+func (it *TupleIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,127 @@
+package tarantool_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+
+	. "github.com/tarantool/go-tarantool/v2"
+	"github.com/tarantool/go-tarantool/v2/test_helpers"
+	"github.com/tarantool/go-tarantool/v2/test_helpers/fakeserver"
+)
+
+func TestFuture_GetTupleIterator(t *testing.T) {
+	srv := startTupleServer(t, fakeserver.Opts{})
+	defer srv.Close()
+
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{Timeout: 5 * time.Second})
+	defer conn.Close()
+
+	var expected [][]byte
+	for i := 1; i <= 100; i++ {
+		tuple := []interface{}{uint(i), fmt.Sprintf("%d@example.com", i), nil}
+		_, err := conn.Do(NewInsertRequest("users").Tuple(tuple)).Get()
+		require.NoError(t, err)
+
+		raw, err := msgpack.Marshal(tuple)
+		require.NoError(t, err)
+		expected = append(expected, raw)
+	}
+
+	it := conn.Do(NewSelectRequest("users").Limit(1000)).GetTupleIterator()
+	var actual [][]byte
+	for it.Next() {
+		actual = append(actual, it.Raw())
+
+		var tuple []interface{}
+		require.NoError(t, it.Decode(&tuple))
+		assert.Equal(t, fmt.Sprintf("%d@example.com", len(actual)), tuple[1])
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, expected, actual)
+	assert.False(t, it.Next())
+	assert.Nil(t, it.Raw())
+
+	// MP_TUPLE values are returned as arrays of fields.
+	it = conn.Do(NewInsertRequest("users").
+		Tuple([]interface{}{uint(101), "bob@example.com", "bob"})).GetTupleIterator()
+	require.True(t, it.Next())
+	var fields []interface{}
+	require.NoError(t, msgpack.Unmarshal(it.Raw(), &fields))
+	assert.Equal(t, []interface{}{int8(101), "bob@example.com", "bob"}, fields)
+	assert.False(t, it.Next())
+	require.NoError(t, it.Err())
+}
+
+func TestFuture_GetTupleIterator_error(t *testing.T) {
+	srv := startTupleServer(t, fakeserver.Opts{})
+	defer srv.Close()
+
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{Timeout: 5 * time.Second})
+	defer conn.Close()
+
+	it := conn.Do(NewCallRequest("unknown")).GetTupleIterator()
+	assert.False(t, it.Next())
+	var tntErr Error
+	require.True(t, errors.As(it.Err(), &tntErr), it.Err())
+	assert.Equal(t, "Procedure 'unknown' is not defined", tntErr.Msg)
+
+	fut := NewFuture()
+	fut.SetError(errors.New("any error"))
+	it = fut.GetTupleIterator()
+	assert.False(t, it.Next())
+	assert.EqualError(t, it.Err(), "any error")
+
+	// A response without data.
+	it = conn.Do(NewPingRequest()).GetTupleIterator()
+	assert.False(t, it.Next())
+	assert.NoError(t, it.Err())
+}
+
+func TestFuture_DecodeEach(t *testing.T) {
+	srv := startTupleServer(t, fakeserver.Opts{})
+	defer srv.Close()
+
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{Timeout: 5 * time.Second})
+	defer conn.Close()
+
+	for i := 1; i <= 3; i++ {
+		tuple := []interface{}{uint(i), fmt.Sprintf("%d@example.com", i), "name"}
+		_, err := conn.Do(NewInsertRequest("users").Tuple(tuple)).Get()
+		require.NoError(t, err)
+	}
+
+	var emails []string
+	err := conn.Do(NewSelectRequest("users").Limit(10)).DecodeEach(
+		func(d *msgpack.Decoder) error {
+			l, err := d.DecodeArrayLen()
+			if err != nil {
+				return err
+			}
+			require.Equal(t, 3, l)
+			// The rest of the tuple could be skipped.
+			if err := d.Skip(); err != nil {
+				return err
+			}
+			email, err := d.DecodeString()
+			emails = append(emails, email)
+			return err
+		})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1@example.com", "2@example.com", "3@example.com"}, emails)
+
+	stop := errors.New("stop")
+	calls := 0
+	err = conn.Do(NewSelectRequest("users").Limit(10)).DecodeEach(
+		func(d *msgpack.Decoder) error {
+			calls++
+			return stop
+		})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, calls)
+}
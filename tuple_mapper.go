@@ -0,0 +1,299 @@
+package tarantool
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+)
+
+// tupleMapperTag is a struct tag with a space field name.
+const tupleMapperTag = "tarantool"
+
+// TupleCodec is a value that could be encoded into a tuple (or an array of
+// tuples) and decoded back. It could be passed to requests as a tuple and to
+// Future.GetTyped() as a result.
+type TupleCodec interface {
+	msgpack.CustomEncoder
+	msgpack.CustomDecoder
+}
+
+// TupleMapper encodes structs into tuples and decodes tuples into structs
+// according to a space format, so structs do not depend on an order of space
+// fields.
+//
+// Struct fields are matched with space fields by names from the `tarantool`
+// tag:
+//
+//	type User struct {
+//		Id    uint64 `tarantool:"id"`
+//		Name  string `tarantool:"name"`
+//		Email string `tarantool:"email"`
+//	}
+//
+// Struct fields without the tag are ignored. Space fields without a struct
+// field are encoded as nil. A tag with a name missing in the space format
+// leads to an error.
+//
+// The mapper uses the space format at the moment of creation, a new mapper
+// should be created after a schema change. Tuples received as MP_TUPLE values
+// with formats (see DMLTupleExtensionFeature) are decoded by names from their
+// own formats instead.
+type TupleMapper struct {
+	space *Space
+	plans sync.Map // reflect.Type -> *tuplePlan
+}
+
+// tuplePlan maps struct fields to tuple fields.
+type tuplePlan struct {
+	// fields contains indexes of struct fields by tuple field numbers or -1
+	// for unmapped tuple fields.
+	fields []int
+	// size is a number of tuple fields to encode: all fields up to the last
+	// mapped or not nullable one.
+	size int
+}
+
+// NewTupleMapper creates a new mapper for the space. The space must have
+// a format.
+func NewTupleMapper(space *Space) (*TupleMapper, error) {
+	if space == nil {
+		return nil, errors.New("space is nil")
+	}
+	if len(space.FieldsById) == 0 {
+		return nil, fmt.Errorf("space %q has no format", space.Name)
+	}
+	return &TupleMapper{space: space}, nil
+}
+
+// Tuple returns a codec for a single tuple. The value must be a pointer to
+// a struct for decoding, a struct or a pointer to it for encoding.
+//
+//	req := NewInsertRequest("users").Tuple(mapper.Tuple(&user))
+func (m *TupleMapper) Tuple(value interface{}) TupleCodec {
+	return &mappedTuple{mapper: m, value: value}
+}
+
+// Tuples returns a codec for an array of tuples. The value must be
+// a pointer to a slice of structs or pointers to structs.
+//
+//	var users []User
+//	err := conn.Do(NewSelectRequest("users")).GetTyped(mapper.Tuples(&users))
+func (m *TupleMapper) Tuples(value interface{}) TupleCodec {
+	return &mappedTuples{mapper: m, value: value}
+}
+
+func (m *TupleMapper) plan(typ reflect.Type) (*tuplePlan, error) {
+	if plan, ok := m.plans.Load(typ); ok {
+		return plan.(*tuplePlan), nil
+	}
+
+	fieldsCount := len(m.space.FieldsById)
+	plan := &tuplePlan{fields: make([]int, fieldsCount)}
+	for i := range plan.fields {
+		plan.fields[i] = -1
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		tag, ok := sf.Tag.Lookup(tupleMapperTag)
+		if !ok || tag == "-" {
+			continue
+		}
+		if sf.PkgPath != "" {
+			return nil, fmt.Errorf("field %s of %s is tagged, but not exported",
+				sf.Name, typ)
+		}
+		name := strings.Split(tag, ",")[0]
+		field, ok := m.space.Fields[name]
+		if !ok {
+			return nil, fmt.Errorf("field %q of %s is not found in space %q format",
+				name, typ, m.space.Name)
+		}
+		if int(field.Id) >= fieldsCount {
+			return nil, fmt.Errorf("unexpected field %q id %d in space %q format",
+				name, field.Id, m.space.Name)
+		}
+		if prev := plan.fields[field.Id]; prev >= 0 {
+			return nil, fmt.Errorf("fields %s and %s of %s are mapped to field %q",
+				typ.Field(prev).Name, sf.Name, typ, name)
+		}
+		plan.fields[field.Id] = i
+	}
+
+	for id, index := range plan.fields {
+		field := m.space.FieldsById[uint32(id)]
+		if index >= 0 || field == nil || !field.IsNullable {
+			plan.size = id + 1
+		}
+	}
+
+	m.plans.Store(typ, plan)
+	return plan, nil
+}
+
+func (m *TupleMapper) encodeTuple(e *msgpack.Encoder, v reflect.Value) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return errors.New("unable to encode a nil pointer as a tuple")
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("unable to map %s to a tuple, a struct is expected", v.Type())
+	}
+
+	plan, err := m.plan(v.Type())
+	if err != nil {
+		return err
+	}
+
+	if err := e.EncodeArrayLen(plan.size); err != nil {
+		return err
+	}
+	for _, index := range plan.fields[:plan.size] {
+		if index < 0 {
+			err = e.EncodeNil()
+		} else {
+			err = e.EncodeValue(v.Field(index))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *TupleMapper) decodeTuple(d *msgpack.Decoder, v reflect.Value) error {
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("unable to map a tuple to %s, a struct is expected", v.Type())
+	}
+
+	code, err := d.PeekCode()
+	if err != nil {
+		return err
+	}
+	if msgpcode.IsExt(code) {
+		var tuple FormattedTuple
+		if err := tuple.DecodeMsgpack(d); err != nil {
+			return err
+		}
+		d = msgpack.NewDecoder(bytes.NewReader(tuple.data))
+		if tuple.Format != nil {
+			// The format of the tuple is actual, the space format could be
+			// outdated.
+			return decodeTupleByFormat(d, v, tuple.Format)
+		}
+	}
+
+	plan, err := m.plan(v.Type())
+	if err != nil {
+		return err
+	}
+
+	l, err := d.DecodeArrayLen()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < l; i++ {
+		if i >= len(plan.fields) || plan.fields[i] < 0 {
+			err = d.Skip()
+		} else {
+			err = d.DecodeValue(v.Field(plan.fields[i]))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mappedTuple is a codec for a single tuple.
+type mappedTuple struct {
+	mapper *TupleMapper
+	value  interface{}
+}
+
+// EncodeMsgpack encodes the value as a tuple.
+func (t *mappedTuple) EncodeMsgpack(e *msgpack.Encoder) error {
+	return t.mapper.encodeTuple(e, reflect.ValueOf(t.value))
+}
+
+// DecodeMsgpack decodes a tuple into the value.
+func (t *mappedTuple) DecodeMsgpack(d *msgpack.Decoder) error {
+	v := reflect.ValueOf(t.value)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("unable to decode a tuple into %T, a non-nil pointer is expected",
+			t.value)
+	}
+	return t.mapper.decodeTuple(d, v.Elem())
+}
+
+// acceptTuples makes mappedTuple decode MP_TUPLE values with formats.
+func (t *mappedTuple) acceptTuples() {}
+
+// mappedTuples is a codec for an array of tuples.
+type mappedTuples struct {
+	mapper *TupleMapper
+	value  interface{}
+}
+
+// EncodeMsgpack encodes the slice as an array of tuples.
+func (t *mappedTuples) EncodeMsgpack(e *msgpack.Encoder) error {
+	v := reflect.Indirect(reflect.ValueOf(t.value))
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return fmt.Errorf("unable to encode %T as tuples, a slice is expected", t.value)
+	}
+
+	if err := e.EncodeArrayLen(v.Len()); err != nil {
+		return err
+	}
+	for i := 0; i < v.Len(); i++ {
+		if err := t.mapper.encodeTuple(e, v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeMsgpack decodes an array of tuples into the slice.
+func (t *mappedTuples) DecodeMsgpack(d *msgpack.Decoder) error {
+	ptr := reflect.ValueOf(t.value)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("unable to decode tuples into %T, a pointer to a slice is expected",
+			t.value)
+	}
+	slice := ptr.Elem()
+	elemType := slice.Type().Elem()
+
+	l, err := d.DecodeArrayLen()
+	if err != nil {
+		return err
+	}
+	if l < 0 {
+		slice.Set(reflect.Zero(slice.Type()))
+		return nil
+	}
+
+	result := reflect.MakeSlice(slice.Type(), l, l)
+	for i := 0; i < l; i++ {
+		elem := result.Index(i)
+		if elemType.Kind() == reflect.Ptr {
+			elem.Set(reflect.New(elemType.Elem()))
+			elem = elem.Elem()
+		}
+		if err := t.mapper.decodeTuple(d, elem); err != nil {
+			return err
+		}
+	}
+	slice.Set(result)
+	return nil
+}
+
+// acceptTuples makes mappedTuples decode MP_TUPLE values with formats.
+func (t *mappedTuples) acceptTuples() {}
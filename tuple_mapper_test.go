@@ -0,0 +1,202 @@
+package tarantool_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+
+	. "github.com/tarantool/go-tarantool/v2"
+	"github.com/tarantool/go-tarantool/v2/test_helpers"
+	"github.com/tarantool/go-tarantool/v2/test_helpers/fakeserver"
+)
+
+type mappedUser struct {
+	Id       uint64 `tarantool:"id"`
+	Name     string `tarantool:"name"`
+	Email    string `tarantool:"email"`
+	Internal string
+	Skipped  string `tarantool:"-"`
+}
+
+func newMapperSpace(fields ...Field) *Space {
+	space := &Space{
+		Id:         1000,
+		Name:       "users",
+		Fields:     make(map[string]*Field),
+		FieldsById: make(map[uint32]*Field),
+	}
+	for i := range fields {
+		field := fields[i]
+		field.Id = uint32(i)
+		space.Fields[field.Name] = &field
+		space.FieldsById[field.Id] = &field
+	}
+	return space
+}
+
+func newUsersSpace() *Space {
+	return newMapperSpace(
+		Field{Name: "id", Type: "unsigned"},
+		Field{Name: "email", Type: "string"},
+		Field{Name: "name", Type: "string"},
+	)
+}
+
+func TestNewTupleMapper_error(t *testing.T) {
+	_, err := NewTupleMapper(nil)
+	assert.Error(t, err)
+
+	_, err = NewTupleMapper(newMapperSpace())
+	assert.Error(t, err)
+}
+
+func TestTupleMapper_encode(t *testing.T) {
+	mapper, err := NewTupleMapper(newUsersSpace())
+	require.NoError(t, err)
+
+	user := mappedUser{Id: 1, Name: "alice", Email: "alice@example.com",
+		Internal: "internal", Skipped: "skipped"}
+	for _, value := range []interface{}{user, &user} {
+		data, err := msgpack.Marshal(mapper.Tuple(value))
+		require.NoError(t, err)
+
+		var tuple []interface{}
+		require.NoError(t, msgpack.Unmarshal(data, &tuple))
+		assert.Equal(t, []interface{}{uint64(1), "alice@example.com", "alice"}, tuple)
+	}
+}
+
+func TestTupleMapper_encode_unmappedFields(t *testing.T) {
+	mapper, err := NewTupleMapper(newMapperSpace(
+		Field{Name: "id", Type: "unsigned"},
+		Field{Name: "age", Type: "unsigned"},
+		Field{Name: "name", Type: "string"},
+		Field{Name: "email", Type: "string"},
+		Field{Name: "comment", Type: "string", IsNullable: true},
+	))
+	require.NoError(t, err)
+
+	data, err := msgpack.Marshal(mapper.Tuple(mappedUser{Id: 1, Name: "bob"}))
+	require.NoError(t, err)
+
+	var tuple []interface{}
+	require.NoError(t, msgpack.Unmarshal(data, &tuple))
+	// A not nullable field is sent as nil, a trailing nullable is omitted.
+	assert.Equal(t, []interface{}{uint64(1), nil, "bob", ""}, tuple)
+}
+
+func TestTupleMapper_decode(t *testing.T) {
+	mapper, err := NewTupleMapper(newUsersSpace())
+	require.NoError(t, err)
+
+	data, err := msgpack.Marshal([]interface{}{2, "bob@example.com", "bob", "extra"})
+	require.NoError(t, err)
+
+	user := mappedUser{Internal: "internal"}
+	require.NoError(t, msgpack.Unmarshal(data, mapper.Tuple(&user)))
+	assert.Equal(t, mappedUser{Id: 2, Name: "bob", Email: "bob@example.com",
+		Internal: "internal"}, user)
+
+	err = msgpack.Unmarshal(data, mapper.Tuple(user))
+	assert.Error(t, err)
+}
+
+func TestTupleMapper_tuples(t *testing.T) {
+	mapper, err := NewTupleMapper(newUsersSpace())
+	require.NoError(t, err)
+
+	users := []mappedUser{
+		{Id: 1, Name: "alice", Email: "alice@example.com"},
+		{Id: 2, Name: "bob", Email: "bob@example.com"},
+	}
+	data, err := msgpack.Marshal(mapper.Tuples(users))
+	require.NoError(t, err)
+
+	var decoded []mappedUser
+	require.NoError(t, msgpack.Unmarshal(data, mapper.Tuples(&decoded)))
+	assert.Equal(t, users, decoded)
+
+	var pointers []*mappedUser
+	require.NoError(t, msgpack.Unmarshal(data, mapper.Tuples(&pointers)))
+	require.Len(t, pointers, 2)
+	assert.Equal(t, users[0], *pointers[0])
+	assert.Equal(t, users[1], *pointers[1])
+}
+
+func TestTupleMapper_invalidStruct(t *testing.T) {
+	mapper, err := NewTupleMapper(newUsersSpace())
+	require.NoError(t, err)
+
+	cases := []struct {
+		name  string
+		value interface{}
+	}{
+		{"unknown field", &struct {
+			Id  uint64 `tarantool:"id"`
+			Age uint64 `tarantool:"age"`
+		}{}},
+		{"duplicate field", &struct {
+			Id    uint64 `tarantool:"id"`
+			Other uint64 `tarantool:"id"`
+		}{}},
+		{"unexported field", &struct {
+			id uint64 `tarantool:"id"`
+		}{}},
+		{"not a struct", 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := msgpack.Marshal(mapper.Tuple(tc.value))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestTupleMapper_fakeserver(t *testing.T) {
+	srv, err := fakeserver.StartWithSpace(fakeserver.Opts{}, fakeserver.Space{
+		Id:   1000,
+		Name: "users",
+		Format: []fakeserver.Field{
+			{Name: "id", Type: "unsigned"},
+			{Name: "email", Type: "string"},
+			{Name: "name", Type: "string"},
+		},
+		Indexes: []fakeserver.Index{
+			{Name: "primary", Parts: []fakeserver.IndexPart{{Field: 0, Type: "unsigned"}}},
+		},
+	})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{Timeout: 5 * time.Second})
+	defer conn.Close()
+
+	mapper, err := NewTupleMapper(conn.Schema.Spaces["users"])
+	require.NoError(t, err)
+
+	users := []mappedUser{
+		{Id: 1, Name: "alice", Email: "alice@example.com"},
+		{Id: 2, Name: "bob", Email: "bob@example.com"},
+	}
+	for i := range users {
+		var inserted []mappedUser
+		err := conn.Do(NewInsertRequest("users").Tuple(mapper.Tuple(&users[i]))).
+			GetTyped(mapper.Tuples(&inserted))
+		require.NoError(t, err)
+		assert.Equal(t, []mappedUser{users[i]}, inserted)
+	}
+
+	var selected []mappedUser
+	err = conn.Do(NewSelectRequest("users").Iterator(IterAll).Limit(10)).
+		GetTyped(mapper.Tuples(&selected))
+	require.NoError(t, err)
+	assert.Equal(t, users, selected)
+
+	var raw []interface{}
+	err = conn.Do(NewSelectRequest("users").Key([]interface{}{uint(2)})).GetTyped(&raw)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{[]interface{}{int8(2), "bob@example.com", "bob"}}, raw)
+}
@@ -0,0 +1,226 @@
+package tarantool_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+
+	. "github.com/tarantool/go-tarantool/v2"
+	"github.com/tarantool/go-tarantool/v2/test_helpers"
+	"github.com/tarantool/go-tarantool/v2/test_helpers/fakeserver"
+)
+
+type namedUser struct {
+	Name string `tarantool:"name"`
+	Id   uint64 `tarantool:"id"`
+}
+
+func startTupleServer(t *testing.T, opts fakeserver.Opts) *fakeserver.Server {
+	t.Helper()
+
+	srv, err := fakeserver.StartWithSpace(opts, fakeserver.Space{
+		Id:   1000,
+		Name: "users",
+		Format: []fakeserver.Field{
+			{Name: "id", Type: "unsigned"},
+			{Name: "email", Type: "string"},
+			{Name: "name", Type: "string", IsNullable: true},
+		},
+		Indexes: []fakeserver.Index{
+			{Name: "primary", Parts: []fakeserver.IndexPart{{Field: 0, Type: "unsigned"}}},
+		},
+	})
+	require.NoError(t, err)
+	return srv
+}
+
+// encodeTuple encodes an MP_TUPLE value with the format identifier.
+func encodeTuple(t *testing.T, formatId uint64, fields []interface{}) []byte {
+	t.Helper()
+
+	var payload bytes.Buffer
+	enc := msgpack.NewEncoder(&payload)
+	require.NoError(t, enc.EncodeUint(formatId))
+	require.NoError(t, enc.Encode(fields))
+
+	var buf bytes.Buffer
+	enc = msgpack.NewEncoder(&buf)
+	require.NoError(t, enc.EncodeExtHeader(7, payload.Len()))
+	buf.Write(payload.Bytes())
+	return buf.Bytes()
+}
+
+func TestFormattedTuple_fakeserver(t *testing.T) {
+	srv := startTupleServer(t, fakeserver.Opts{})
+	defer srv.Close()
+
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{Timeout: 5 * time.Second})
+	defer conn.Close()
+
+	var inserted []FormattedTuple
+	err := conn.Do(NewInsertRequest("users").
+		Tuple([]interface{}{uint(1), "bob@example.com", "bob"})).GetTyped(&inserted)
+	require.NoError(t, err)
+	require.Len(t, inserted, 1)
+
+	tuple := inserted[0]
+	assert.Equal(t, []Field{
+		{Id: 0, Name: "id", Type: "unsigned"},
+		{Id: 1, Name: "email", Type: "string"},
+		{Id: 2, Name: "name", Type: "string", IsNullable: true},
+	}, tuple.Format)
+	assert.Equal(t, []interface{}{int8(1), "bob@example.com", "bob"}, tuple.Fields)
+
+	name, ok := tuple.Get("name")
+	assert.True(t, ok)
+	assert.Equal(t, "bob", name)
+	_, ok = tuple.Get("unknown")
+	assert.False(t, ok)
+
+	var user namedUser
+	require.NoError(t, tuple.Decode(&user))
+	assert.Equal(t, namedUser{Name: "bob", Id: 1}, user)
+
+	// A tuple is encoded as an array of fields.
+	tuple.Fields[2] = "robert"
+	_, err = conn.Do(NewReplaceRequest("users").Tuple(&tuple)).Get()
+	require.NoError(t, err)
+
+	resp, err := conn.Do(NewSelectRequest("users").Key([]interface{}{uint(1)})).Get()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		[]interface{}{int8(1), "bob@example.com", "robert"},
+	}, resp.Data)
+
+	var raw [][]interface{}
+	err = conn.Do(NewSelectRequest("users").Key([]interface{}{uint(1)})).GetTyped(&raw)
+	require.NoError(t, err)
+	assert.Equal(t, [][]interface{}{{int8(1), "bob@example.com", "robert"}}, raw)
+
+	// Tuples are decoded as arrays even if a result could keep them as is.
+	var rawTuples []msgpack.RawMessage
+	err = conn.Do(NewSelectRequest("users").Key([]interface{}{uint(1)})).GetTyped(&rawTuples)
+	require.NoError(t, err)
+	require.Len(t, rawTuples, 1)
+	var fields []interface{}
+	require.NoError(t, msgpack.Unmarshal(rawTuples[0], &fields))
+	assert.Equal(t, []interface{}{int8(1), "bob@example.com", "robert"}, fields)
+
+	var single FormattedTuple
+	err = conn.Do(NewDeleteRequest("users").Key([]interface{}{uint(1)})).
+		GetTyped(&[]*FormattedTuple{&single})
+	require.NoError(t, err)
+	name, ok = single.Get("name")
+	assert.True(t, ok)
+	assert.Equal(t, "robert", name)
+}
+
+func TestFormattedTuple_fakeserver_noFeature(t *testing.T) {
+	srv := startTupleServer(t, fakeserver.Opts{
+		ProtocolInfo: ProtocolInfo{
+			Version:  ProtocolVersion(4),
+			Features: []ProtocolFeature{StreamsFeature, PaginationFeature},
+		},
+	})
+	defer srv.Close()
+
+	conn := test_helpers.ConnectWithValidation(t, srv.Addr(), Opts{Timeout: 5 * time.Second})
+	defer conn.Close()
+
+	var inserted []FormattedTuple
+	err := conn.Do(NewInsertRequest("users").
+		Tuple([]interface{}{uint(1), "bob@example.com", "bob"})).GetTyped(&inserted)
+	require.NoError(t, err)
+	require.Len(t, inserted, 1)
+	assert.Nil(t, inserted[0].Format)
+	assert.Equal(t, []interface{}{int8(1), "bob@example.com", "bob"}, inserted[0].Fields)
+
+	var fields []interface{}
+	require.NoError(t, inserted[0].Decode(&fields))
+	assert.Equal(t, []interface{}{int8(1), "bob@example.com", "bob"}, fields)
+}
+
+func TestFormattedTuple_DecodeMsgpack(t *testing.T) {
+	// A tuple with an unknown format.
+	var tuple FormattedTuple
+	data := encodeTuple(t, 42, []interface{}{1, "a"})
+	require.NoError(t, msgpack.Unmarshal(data, &tuple))
+	assert.Nil(t, tuple.Format)
+	assert.Equal(t, []interface{}{int8(1), "a"}, tuple.Fields)
+
+	data, err := msgpack.Marshal([]interface{}{2, "b"})
+	require.NoError(t, err)
+	require.NoError(t, msgpack.Unmarshal(data, &tuple))
+	assert.Nil(t, tuple.Format)
+	assert.Equal(t, []interface{}{int8(2), "b"}, tuple.Fields)
+
+	err = msgpack.Unmarshal([]byte{0xd4, 0x01, 0x00}, &tuple)
+	assert.Error(t, err)
+}
+
+func TestResolveTuples(t *testing.T) {
+	format, err := msgpack.Marshal([]interface{}{
+		map[string]interface{}{"name": "id", "type": "unsigned"},
+		map[string]interface{}{"name": "value", "type": "any"},
+	})
+	require.NoError(t, err)
+	formats := map[uint64][]byte{1: format}
+
+	long := strings.Repeat("x", 300)
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	require.NoError(t, enc.EncodeArrayLen(3))
+	require.NoError(t, enc.EncodeMapLen(2))
+	require.NoError(t, enc.EncodeString("tuple"))
+	buf.Write(encodeTuple(t, 1, []interface{}{1, long}))
+	require.NoError(t, enc.EncodeString("other"))
+	require.NoError(t, enc.Encode([]interface{}{1.5, -100000, []byte{1, 2}, nil, true}))
+	// A tuple with an unknown format is unwrapped too.
+	buf.Write(encodeTuple(t, 2, []interface{}{2}))
+	require.NoError(t, enc.Encode(uint64(1<<40)))
+	data := buf.Bytes()
+
+	unwrapped, err := ResolveTuples(data, formats, true)
+	require.NoError(t, err)
+	var decoded []interface{}
+	require.NoError(t, msgpack.Unmarshal(unwrapped, &decoded))
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"tuple": []interface{}{int8(1), long},
+			"other": []interface{}{1.5, int32(-100000), []byte{1, 2}, nil, true},
+		},
+		[]interface{}{int8(2)},
+		uint64(1 << 40),
+	}, decoded)
+
+	resolved, err := ResolveTuples(data, formats, false)
+	require.NoError(t, err)
+	d := msgpack.NewDecoder(bytes.NewReader(resolved))
+	_, err = d.DecodeArrayLen()
+	require.NoError(t, err)
+	_, err = d.DecodeMapLen()
+	require.NoError(t, err)
+	key, err := d.DecodeString()
+	require.NoError(t, err)
+	require.Equal(t, "tuple", key)
+
+	var tuple FormattedTuple
+	require.NoError(t, d.Decode(&tuple))
+	value, ok := tuple.Get("value")
+	assert.True(t, ok)
+	assert.Equal(t, long, value)
+
+	// Other extensions are kept as is.
+	ext := []byte{0xd4, 0x01, 0x00}
+	resolved, err = ResolveTuples(ext, formats, true)
+	require.NoError(t, err)
+	assert.Equal(t, ext, resolved)
+
+	_, err = ResolveTuples(data[:len(data)-1], formats, false)
+	assert.Error(t, err)
+}
@@ -0,0 +1,339 @@
+package tarantool
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// uriScheme is a scheme of connection URIs.
+	uriScheme = "tarantool://"
+	// uriMask replaces passwords in URI strings.
+	uriMask = "xxxxx"
+)
+
+// URI is a connection string parsed by ParseURI(). It allows to configure
+// a connection with a single string, for example, from an environment
+// variable.
+type URI struct {
+	// Addr is an address of an instance in a format of Connect().
+	Addr string
+	// Opts are connection options from the URI. Opts.User and Opts.Pass are
+	// taken from the user information part.
+	Opts Opts
+}
+
+// uriParam describes a query parameter of a URI.
+type uriParam struct {
+	name string
+	// get returns a value of the parameter or an empty string if it is not
+	// set.
+	get func(opts *Opts) string
+	set func(opts *Opts, value string) error
+	// secret parameters are masked by URI.String().
+	secret bool
+}
+
+// uriParams are query parameters of a URI in order of URI.String().
+var uriParams = []uriParam{
+	{
+		name: "timeout",
+		get:  func(opts *Opts) string { return formatURIDuration(opts.Timeout) },
+		set: func(opts *Opts, value string) (err error) {
+			opts.Timeout, err = time.ParseDuration(value)
+			return err
+		},
+	},
+	{
+		name: "reconnect",
+		get:  func(opts *Opts) string { return formatURIDuration(opts.Reconnect) },
+		set: func(opts *Opts, value string) (err error) {
+			opts.Reconnect, err = time.ParseDuration(value)
+			return err
+		},
+	},
+	{
+		name: "max_reconnects",
+		get:  func(opts *Opts) string { return formatURIUint(uint64(opts.MaxReconnects)) },
+		set: func(opts *Opts, value string) error {
+			n, err := strconv.ParseUint(value, 10, 0)
+			opts.MaxReconnects = uint(n)
+			return err
+		},
+	},
+	{
+		name: "concurrency",
+		get:  func(opts *Opts) string { return formatURIUint(uint64(opts.Concurrency)) },
+		set: func(opts *Opts, value string) error {
+			n, err := strconv.ParseUint(value, 10, 32)
+			opts.Concurrency = uint32(n)
+			return err
+		},
+	},
+	{
+		name: "rate_limit",
+		get:  func(opts *Opts) string { return formatURIUint(uint64(opts.RateLimit)) },
+		set: func(opts *Opts, value string) error {
+			n, err := strconv.ParseUint(value, 10, 0)
+			opts.RateLimit = uint(n)
+			return err
+		},
+	},
+	{
+		name: "rate_limit_action",
+		get: func(opts *Opts) string {
+			switch {
+			case opts.RateLimit == 0:
+				return ""
+			case opts.RLimitAction == RLimitWait:
+				return "wait"
+			default:
+				return "drop"
+			}
+		},
+		set: func(opts *Opts, value string) error {
+			switch value {
+			case "drop":
+				opts.RLimitAction = RLimitDrop
+			case "wait":
+				opts.RLimitAction = RLimitWait
+			default:
+				return fmt.Errorf("unknown action %q", value)
+			}
+			return nil
+		},
+	},
+	{
+		name: "skip_schema",
+		get: func(opts *Opts) string {
+			if opts.SkipSchema {
+				return "true"
+			}
+			return ""
+		},
+		set: func(opts *Opts, value string) (err error) {
+			opts.SkipSchema, err = strconv.ParseBool(value)
+			return err
+		},
+	},
+	{
+		name: "auth",
+		get: func(opts *Opts) string {
+			if opts.Auth == AutoAuth {
+				return ""
+			}
+			return opts.Auth.String()
+		},
+		set: func(opts *Opts, value string) error {
+			for _, auth := range []Auth{AutoAuth, ChapSha1Auth, PapSha256Auth} {
+				if value == auth.String() {
+					opts.Auth = auth
+					return nil
+				}
+			}
+			return fmt.Errorf("unknown auth type %q", value)
+		},
+	},
+	{
+		name: "transport",
+		get:  func(opts *Opts) string { return opts.Transport },
+		set: func(opts *Opts, value string) error {
+			switch value {
+			case dialTransportNone, dialTransportSsl, dialTransportTls:
+				opts.Transport = value
+				return nil
+			}
+			return fmt.Errorf("unknown transport %q", value)
+		},
+	},
+	{
+		name: "ssl_key_file",
+		get:  func(opts *Opts) string { return opts.Ssl.KeyFile },
+		set: func(opts *Opts, value string) error {
+			opts.Ssl.KeyFile = value
+			return nil
+		},
+	},
+	{
+		name: "ssl_cert_file",
+		get:  func(opts *Opts) string { return opts.Ssl.CertFile },
+		set: func(opts *Opts, value string) error {
+			opts.Ssl.CertFile = value
+			return nil
+		},
+	},
+	{
+		name: "ssl_ca_file",
+		get:  func(opts *Opts) string { return opts.Ssl.CaFile },
+		set: func(opts *Opts, value string) error {
+			opts.Ssl.CaFile = value
+			return nil
+		},
+	},
+	{
+		name: "ssl_ciphers",
+		get:  func(opts *Opts) string { return opts.Ssl.Ciphers },
+		set: func(opts *Opts, value string) error {
+			opts.Ssl.Ciphers = value
+			return nil
+		},
+	},
+	{
+		name: "ssl_password",
+		get:  func(opts *Opts) string { return opts.Ssl.Password },
+		set: func(opts *Opts, value string) error {
+			opts.Ssl.Password = value
+			return nil
+		},
+		secret: true,
+	},
+	{
+		name: "ssl_password_file",
+		get:  func(opts *Opts) string { return opts.Ssl.PasswordFile },
+		set: func(opts *Opts, value string) error {
+			opts.Ssl.PasswordFile = value
+			return nil
+		},
+	},
+}
+
+// ParseURI parses a connection URI:
+//
+//	tarantool://[user[:password]@]address[?param=value&...]
+//
+// The address could be in any format of Connect(), for example,
+// "host:3301", "[::1]:3301" or "unix/:/var/run/tarantool.sock". The user
+// and the password must be percent-encoded if they contain special
+// symbols.
+//
+// Parameters:
+//
+//   - timeout, reconnect: Opts.Timeout and Opts.Reconnect in a format of
+//     time.ParseDuration(), for example, "1s" or "500ms".
+//   - max_reconnects, concurrency, rate_limit: Opts.MaxReconnects,
+//     Opts.Concurrency and Opts.RateLimit.
+//   - rate_limit_action: Opts.RLimitAction, "drop" or "wait".
+//   - skip_schema: Opts.SkipSchema, "true" or "false".
+//   - auth: Opts.Auth, "auto", "chap-sha1" or "pap-sha256".
+//   - transport: Opts.Transport, "ssl" or "tls".
+//   - ssl_key_file, ssl_cert_file, ssl_ca_file, ssl_ciphers, ssl_password,
+//     ssl_password_file: fields of Opts.Ssl.
+//
+// An unknown parameter is an error.
+func ParseURI(uri string) (URI, error) {
+	var res URI
+	userinfo, hosts, query, err := SplitURI(uri)
+	if err != nil {
+		return res, err
+	}
+
+	if hosts == "" {
+		return res, fmt.Errorf("failed to parse URI: no address")
+	}
+	if strings.Contains(hosts, ",") {
+		return res, fmt.Errorf("failed to parse URI: several addresses, " +
+			"use pool.ParseURI() instead")
+	}
+	res.Addr = hosts
+
+	if userinfo != "" {
+		user, pass := userinfo, ""
+		if i := strings.IndexByte(userinfo, ':'); i >= 0 {
+			user, pass = userinfo[:i], userinfo[i+1:]
+		}
+		if res.Opts.User, err = url.PathUnescape(user); err != nil {
+			return res, fmt.Errorf("failed to parse URI user: %w", err)
+		}
+		if res.Opts.Pass, err = url.PathUnescape(pass); err != nil {
+			return res, fmt.Errorf("failed to parse URI password: %w", err)
+		}
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return res, fmt.Errorf("failed to parse URI parameters: %w", err)
+	}
+	for _, param := range uriParams {
+		if value, ok := values[param.name]; ok {
+			if err := param.set(&res.Opts, value[len(value)-1]); err != nil {
+				return res, fmt.Errorf("failed to parse URI parameter %s: %w",
+					param.name, err)
+			}
+			delete(values, param.name)
+		}
+	}
+	for name := range values {
+		return res, fmt.Errorf("failed to parse URI: unknown parameter %s", name)
+	}
+	return res, nil
+}
+
+// String returns the URI in a format of ParseURI() with masked passwords.
+// Options that could not be set by URI parameters are skipped.
+func (uri URI) String() string {
+	var sb strings.Builder
+	sb.WriteString(uriScheme)
+	if uri.Opts.User != "" || uri.Opts.Pass != "" {
+		// url.User() escapes ':' and '@' unlike url.PathEscape().
+		sb.WriteString(url.User(uri.Opts.User).String())
+		if uri.Opts.Pass != "" {
+			sb.WriteString(":" + uriMask)
+		}
+		sb.WriteByte('@')
+	}
+	sb.WriteString(uri.Addr)
+	if query := uriQuery(&uri.Opts); query != "" {
+		sb.WriteString("?" + query)
+	}
+	return sb.String()
+}
+
+// SplitURI splits a URI in a format of ParseURI() into a user information,
+// addresses and a query. It helps to parse URIs with extra parameters.
+func SplitURI(uri string) (userinfo, hosts, query string, err error) {
+	if !strings.HasPrefix(uri, uriScheme) {
+		return "", "", "", fmt.Errorf("failed to parse URI: scheme is not %q",
+			uriScheme)
+	}
+	rest := uri[len(uriScheme):]
+	if i := strings.IndexByte(rest, '?'); i >= 0 {
+		rest, query = rest[:i], rest[i+1:]
+	}
+	if i := strings.LastIndexByte(rest, '@'); i >= 0 {
+		userinfo, rest = rest[:i], rest[i+1:]
+	}
+	return userinfo, rest, query, nil
+}
+
+// uriQuery returns URI parameters of the options.
+func uriQuery(opts *Opts) string {
+	var params []string
+	for _, param := range uriParams {
+		value := param.get(opts)
+		if value == "" {
+			continue
+		}
+		if param.secret {
+			value = uriMask
+		}
+		params = append(params, param.name+"="+url.QueryEscape(value))
+	}
+	return strings.Join(params, "&")
+}
+
+func formatURIDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+func formatURIUint(n uint64) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.FormatUint(n, 10)
+}
@@ -0,0 +1,103 @@
+package vshard
+
+import (
+	"fmt"
+	"hash/crc32"
+	"strconv"
+)
+
+// DefaultBucketCount is a default total number of buckets in vshard.
+const DefaultBucketCount = 3000
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// BucketIdStrCRC32 calculates a bucket identifier of the sharding key in
+// the same way as vshard.router.bucket_id_strcrc32() does.
+//
+// The key could be a string, a []byte, a bool, a number or a slice of them
+// for a multipart key. Numbers are converted into strings in the same way
+// as Lua tostring() does it for Lua numbers.
+func BucketIdStrCRC32(key interface{}, bucketCount uint64) (uint64, error) {
+	if bucketCount == 0 {
+		return 0, fmt.Errorf("bucket count must be positive")
+	}
+
+	// digest.crc32() in Tarantool uses CRC32-C without a final xor, so an
+	// update of the checksum is the same as a checksum of a concatenation.
+	crc := ^uint32(0)
+	update := func(part interface{}) error {
+		str, err := keyPartString(part)
+		if err != nil {
+			return err
+		}
+		crc = ^crc32.Update(^crc, castagnoliTable, []byte(str))
+		return nil
+	}
+
+	var err error
+	switch key := key.(type) {
+	case []interface{}:
+		for _, part := range key {
+			if err = update(part); err != nil {
+				break
+			}
+		}
+	case []string:
+		for _, part := range key {
+			if err = update(part); err != nil {
+				break
+			}
+		}
+	default:
+		err = update(key)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return uint64(crc)%bucketCount + 1, nil
+}
+
+// keyPartString converts a part of a sharding key into a string like Lua
+// tostring() does.
+func keyPartString(part interface{}) (string, error) {
+	switch part := part.(type) {
+	case string:
+		return part, nil
+	case []byte:
+		return string(part), nil
+	case bool:
+		return strconv.FormatBool(part), nil
+	case int:
+		return luaNumberString(float64(part)), nil
+	case int8:
+		return luaNumberString(float64(part)), nil
+	case int16:
+		return luaNumberString(float64(part)), nil
+	case int32:
+		return luaNumberString(float64(part)), nil
+	case int64:
+		return luaNumberString(float64(part)), nil
+	case uint:
+		return luaNumberString(float64(part)), nil
+	case uint8:
+		return luaNumberString(float64(part)), nil
+	case uint16:
+		return luaNumberString(float64(part)), nil
+	case uint32:
+		return luaNumberString(float64(part)), nil
+	case uint64:
+		return luaNumberString(float64(part)), nil
+	case float32:
+		return luaNumberString(float64(part)), nil
+	case float64:
+		return luaNumberString(part), nil
+	case nil:
+		return "", fmt.Errorf("sharding key must not contain nil")
+	}
+	return "", fmt.Errorf("unsupported sharding key part type %T", part)
+}
+
+// luaNumberString formats a number with LUAI_NUMFFMT.
+func luaNumberString(number float64) string {
+	return strconv.FormatFloat(number, 'g', 14, 64)
+}
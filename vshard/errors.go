@@ -0,0 +1,91 @@
+package vshard
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Names of vshard errors handled by the Router.
+const (
+	// ErrNameWrongBucket means that a bucket is not stored on a replicaset.
+	ErrNameWrongBucket = "WRONG_BUCKET"
+	// ErrNameTransferIsInProgress means that a bucket is being transferred
+	// to another replicaset.
+	ErrNameTransferIsInProgress = "TRANSFER_IS_IN_PROGRESS"
+	// ErrNameBucketIsLocked means that a bucket is locked for writes during
+	// a transfer.
+	ErrNameBucketIsLocked = "BUCKET_IS_LOCKED"
+)
+
+var (
+	// ErrNoReplicasets is returned by Connect() if RouterOpts.Replicasets is
+	// empty.
+	ErrNoReplicasets = errors.New("no replicasets")
+	// ErrBucketNotFound is returned if a bucket is not found on replicasets.
+	ErrBucketNotFound = errors.New("bucket is not found")
+	// ErrWrongBucketId is returned for a bucket identifier out of
+	// [1, RouterOpts.BucketCount].
+	ErrWrongBucketId = errors.New("wrong bucket id")
+	// ErrClosed is returned after Router.Close().
+	ErrClosed = errors.New("router is closed")
+)
+
+// Error is an error returned by a vshard storage.
+type Error struct {
+	// Type is an error type, for example, "ShardingError".
+	Type string
+	// Code is an error code.
+	Code uint64
+	// Name is an error name, for example, "WRONG_BUCKET".
+	Name string
+	// Message is an error message.
+	Message string
+	// BucketId is a bucket identifier related to the error.
+	BucketId uint64
+	// Destination is a replicaset that stores or receives the bucket.
+	Destination string
+}
+
+// Error converts an Error to a string.
+func (err *Error) Error() string {
+	if err.Name == "" {
+		return err.Message
+	}
+	return fmt.Sprintf("%s: %s", err.Name, err.Message)
+}
+
+// DecodeMsgpack provides custom msgpack decoder.
+func (err *Error) DecodeMsgpack(d *msgpack.Decoder) error {
+	l, e := d.DecodeMapLen()
+	if e != nil {
+		return e
+	}
+	for i := 0; i < l; i++ {
+		key, e := d.DecodeString()
+		if e != nil {
+			return e
+		}
+		switch key {
+		case "type":
+			err.Type, e = d.DecodeString()
+		case "code":
+			err.Code, e = d.DecodeUint64()
+		case "name":
+			err.Name, e = d.DecodeString()
+		case "message":
+			err.Message, e = d.DecodeString()
+		case "bucket_id":
+			err.BucketId, e = d.DecodeUint64()
+		case "destination":
+			err.Destination, e = d.DecodeString()
+		default:
+			e = d.Skip()
+		}
+		if e != nil {
+			return e
+		}
+	}
+	return nil
+}
@@ -0,0 +1,503 @@
+// Package vshard implements a client-side router for a Tarantool cluster
+// sharded with the vshard module.
+//
+// The Router calculates bucket identifiers in the same way as vshard does,
+// loads the bucket-to-replicaset map from storages and sends requests
+// directly to a replicaset that stores a bucket, so an extra network hop
+// through a vshard router instance is not needed.
+//
+// # See also
+//
+// * Tarantool vshard module https://github.com/tarantool/vshard
+//
+// Since: 2.0.0
+package vshard
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+
+	"github.com/tarantool/go-tarantool/v2"
+	"github.com/tarantool/go-tarantool/v2/pool"
+)
+
+const (
+	// DefaultRetries is a default number of retries of a storage call after
+	// a bucket has been moved.
+	DefaultRetries = 3
+	// DefaultRetryDelay is a default delay before a retry of a storage call
+	// to a locked bucket.
+	DefaultRetryDelay = 100 * time.Millisecond
+	// defaultCheckTimeout is a default check timeout of connection pools.
+	defaultCheckTimeout = time.Second
+)
+
+// RouterOpts is options for a Router.
+type RouterOpts struct {
+	// Replicasets contains addresses of instances by replicaset identifiers.
+	// The identifiers must be the same as in the vshard configuration
+	// (replicaset UUIDs or names) to follow bucket moves without a full
+	// discovery.
+	Replicasets map[string][]string
+	// BucketCount is a total number of buckets. It must be the same as
+	// bucket_count in the vshard configuration. If BucketCount is zero,
+	// DefaultBucketCount is used.
+	BucketCount uint64
+	// ConnOpts is options for connections to storages.
+	ConnOpts tarantool.Opts
+	// PoolOpts is options for connection pools of replicasets. If
+	// PoolOpts.CheckTimeout is zero, one second is used.
+	PoolOpts pool.Opts
+	// Retries is a maximum number of retries of a storage call after
+	// a WRONG_BUCKET, TRANSFER_IS_IN_PROGRESS or BUCKET_IS_LOCKED error. If
+	// Retries is zero, DefaultRetries is used. A negative value disables
+	// retries.
+	Retries int
+	// RetryDelay is a delay before a retry after TRANSFER_IS_IN_PROGRESS or
+	// BUCKET_IS_LOCKED error. If RetryDelay is zero, DefaultRetryDelay is
+	// used.
+	RetryDelay time.Duration
+}
+
+// Router routes requests to replicasets by bucket identifiers.
+type Router struct {
+	opts  RouterOpts
+	pools map[string]*pool.ConnectionPool
+
+	// bucketsMutex protects buckets.
+	bucketsMutex sync.RWMutex
+	// buckets contains replicaset identifiers by bucket identifiers, an
+	// empty string for an unknown bucket.
+	buckets []string
+	// discoveryMutex serializes discoveries.
+	discoveryMutex sync.Mutex
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// Connect creates connection pools to replicasets and discovers buckets.
+func Connect(ctx context.Context, opts RouterOpts) (*Router, error) {
+	if len(opts.Replicasets) == 0 {
+		return nil, ErrNoReplicasets
+	}
+	if opts.BucketCount == 0 {
+		opts.BucketCount = DefaultBucketCount
+	}
+	if opts.Retries == 0 {
+		opts.Retries = DefaultRetries
+	}
+	if opts.RetryDelay == 0 {
+		opts.RetryDelay = DefaultRetryDelay
+	}
+	if opts.PoolOpts.CheckTimeout == 0 {
+		opts.PoolOpts.CheckTimeout = defaultCheckTimeout
+	}
+
+	router := &Router{
+		opts:    opts,
+		pools:   make(map[string]*pool.ConnectionPool),
+		buckets: make([]string, opts.BucketCount+1),
+		done:    make(chan struct{}),
+	}
+	for name, addrs := range opts.Replicasets {
+		instances := make([]pool.Instance, 0, len(addrs))
+		for _, addr := range addrs {
+			instances = append(instances, pool.Instance{Addr: addr, Opts: opts.ConnOpts})
+		}
+		connPool, err := pool.ConnectWithOpts(ctx, instances, opts.PoolOpts)
+		if err != nil {
+			router.Close()
+			return nil, fmt.Errorf("failed to connect to replicaset %s: %w", name, err)
+		}
+		router.pools[name] = connPool
+	}
+
+	if err := router.Discovery(ctx); err != nil {
+		router.Close()
+		return nil, err
+	}
+	return router, nil
+}
+
+// BucketId calculates a bucket identifier of the sharding key with
+// BucketIdStrCRC32.
+func (r *Router) BucketId(key interface{}) (uint64, error) {
+	return BucketIdStrCRC32(key, r.opts.BucketCount)
+}
+
+// BucketCount returns a total number of buckets.
+func (r *Router) BucketCount() uint64 {
+	return r.opts.BucketCount
+}
+
+// Route returns a replicaset identifier and a connection pool of the
+// replicaset that stores the bucket. Buckets are discovered if the bucket is
+// unknown.
+func (r *Router) Route(ctx context.Context,
+	bucketId uint64) (string, *pool.ConnectionPool, error) {
+	if bucketId == 0 || bucketId > r.opts.BucketCount {
+		return "", nil, fmt.Errorf("%w: %d", ErrWrongBucketId, bucketId)
+	}
+
+	if name := r.replicaset(bucketId); name != "" {
+		return name, r.pools[name], nil
+	}
+	if err := r.Discovery(ctx); err != nil {
+		return "", nil, err
+	}
+	if name := r.replicaset(bucketId); name != "" {
+		return name, r.pools[name], nil
+	}
+	return "", nil, fmt.Errorf("%w: %d", ErrBucketNotFound, bucketId)
+}
+
+// Do sends the request to the replicaset that stores the bucket. The
+// request is not checked by vshard on the storage, so the bucket could be
+// moved concurrently. Use Call() or CallTyped() to call a function with
+// the bucket check.
+func (r *Router) Do(bucketId uint64, req tarantool.Request, mode pool.Mode) *tarantool.Future {
+	_, connPool, err := r.Route(requestContext(req), bucketId)
+	if err != nil {
+		fut := tarantool.NewFuture()
+		fut.SetError(err)
+		return fut
+	}
+	return connPool.Do(req, mode)
+}
+
+// Call calls the function with vshard.storage.call() on the replicaset that
+// stores the bucket and returns results of the function. The function is
+// called on a master for pool.RW mode and on an instance selected by the mode
+// otherwise.
+//
+// If the bucket has been moved, the bucket-to-replicaset map is updated and
+// the call is retried up to RouterOpts.Retries times.
+func (r *Router) Call(ctx context.Context, bucketId uint64, mode pool.Mode,
+	functionName string, args interface{}) ([]interface{}, error) {
+	var result []interface{}
+	if err := r.CallTyped(ctx, bucketId, mode, functionName, args, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CallTyped is the same as Call(), but it decodes results of the function
+// into the result in the same way as tarantool.Future.GetTyped() does it for
+// a call request.
+func (r *Router) CallTyped(ctx context.Context, bucketId uint64, mode pool.Mode,
+	functionName string, args interface{}, result interface{}) error {
+	if args == nil {
+		args = []interface{}{}
+	}
+	storageMode := "read"
+	if mode == pool.RW {
+		storageMode = "write"
+	}
+
+	for attempt := 0; ; attempt++ {
+		name, connPool, err := r.Route(ctx, bucketId)
+		if err != nil {
+			return err
+		}
+
+		req := tarantool.NewCallRequest("vshard.storage.call").
+			Args([]interface{}{bucketId, storageMode, functionName, args}).
+			Context(ctx)
+		callResult := storageCallResult{result: result}
+		err = connPool.Do(req, mode).GetTyped(&callResult)
+		if err == nil {
+			if callResult.err == nil {
+				return nil
+			}
+			err = callResult.err
+		}
+
+		var vshardErr *Error
+		if !errors.As(err, &vshardErr) || attempt >= r.opts.Retries {
+			return err
+		}
+		switch vshardErr.Name {
+		case ErrNameWrongBucket:
+			r.moveBucket(bucketId, name, vshardErr.Destination)
+			continue
+		case ErrNameTransferIsInProgress:
+			r.moveBucket(bucketId, name, vshardErr.Destination)
+		case ErrNameBucketIsLocked:
+			// The bucket is still stored on the replicaset, wait for the
+			// end of a transfer.
+		default:
+			return err
+		}
+
+		select {
+		case <-time.After(r.opts.RetryDelay):
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-r.done:
+			return ErrClosed
+		}
+	}
+}
+
+// Discovery reloads the bucket-to-replicaset map from all replicasets.
+func (r *Router) Discovery(ctx context.Context) error {
+	r.discoveryMutex.Lock()
+	defer r.discoveryMutex.Unlock()
+
+	select {
+	case <-r.done:
+		return ErrClosed
+	default:
+	}
+
+	buckets := make([]string, r.opts.BucketCount+1)
+	for name, connPool := range r.pools {
+		ids, err := discoverBuckets(ctx, connPool)
+		if err != nil {
+			return fmt.Errorf("failed to discover buckets of replicaset %s: %w",
+				name, err)
+		}
+		for _, id := range ids {
+			if id > 0 && id <= r.opts.BucketCount {
+				buckets[id] = name
+			}
+		}
+	}
+
+	r.bucketsMutex.Lock()
+	r.buckets = buckets
+	r.bucketsMutex.Unlock()
+	return nil
+}
+
+// Replicasets returns connection pools by replicaset identifiers.
+func (r *Router) Replicasets() map[string]*pool.ConnectionPool {
+	pools := make(map[string]*pool.ConnectionPool, len(r.pools))
+	for name, connPool := range r.pools {
+		pools[name] = connPool
+	}
+	return pools
+}
+
+// Close closes connection pools of all replicasets.
+func (r *Router) Close() []error {
+	var errs []error
+	r.closeOnce.Do(func() {
+		close(r.done)
+		for name, connPool := range r.pools {
+			for _, err := range connPool.Close() {
+				errs = append(errs, fmt.Errorf("replicaset %s: %w", name, err))
+			}
+		}
+	})
+	return errs
+}
+
+func (r *Router) replicaset(bucketId uint64) string {
+	r.bucketsMutex.RLock()
+	defer r.bucketsMutex.RUnlock()
+
+	return r.buckets[bucketId]
+}
+
+// moveBucket sets a new replicaset of the bucket if it is known or forgets
+// the bucket to discover it again.
+func (r *Router) moveBucket(bucketId uint64, from string, to string) {
+	if _, ok := r.pools[to]; !ok {
+		to = ""
+	}
+
+	r.bucketsMutex.Lock()
+	defer r.bucketsMutex.Unlock()
+
+	// The map could be already updated by a concurrent discovery.
+	if r.buckets[bucketId] == from {
+		r.buckets[bucketId] = to
+	}
+}
+
+func requestContext(req tarantool.Request) context.Context {
+	if ctx := req.Ctx(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+// discoverBuckets returns identifiers of buckets stored on the replicaset.
+func discoverBuckets(ctx context.Context, connPool *pool.ConnectionPool) ([]uint64, error) {
+	var ids []uint64
+	from := uint64(1)
+	for {
+		req := tarantool.NewCallRequest("vshard.storage.buckets_discovery").
+			Args([]interface{}{map[string]interface{}{"from": from}}).
+			Context(ctx)
+		var page bucketsPage
+		if err := connPool.Do(req, pool.PreferRO).GetTyped(&page); err != nil {
+			return nil, err
+		}
+		ids = append(ids, page.buckets...)
+		if page.nextFrom == 0 {
+			return ids, nil
+		}
+		from = page.nextFrom
+	}
+}
+
+// bucketsPage is a result of vshard.storage.buckets_discovery(). Old vshard
+// versions return an array of bucket identifiers, new versions return
+// a page {buckets = {...}, next_from = id}.
+type bucketsPage struct {
+	buckets  []uint64
+	nextFrom uint64
+}
+
+// DecodeMsgpack provides custom msgpack decoder.
+func (p *bucketsPage) DecodeMsgpack(d *msgpack.Decoder) error {
+	l, err := d.DecodeArrayLen()
+	if err != nil {
+		return err
+	}
+	if l < 1 {
+		return fmt.Errorf("unexpected buckets discovery response length: %d", l)
+	}
+
+	code, err := d.PeekCode()
+	if err != nil {
+		return err
+	}
+	if isArray(code) {
+		err = d.Decode(&p.buckets)
+	} else {
+		err = p.decodePage(d)
+	}
+	if err != nil {
+		return err
+	}
+
+	for i := 1; i < l; i++ {
+		if err := d.Skip(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *bucketsPage) decodePage(d *msgpack.Decoder) error {
+	l, err := d.DecodeMapLen()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < l; i++ {
+		key, err := d.DecodeString()
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "buckets":
+			err = d.Decode(&p.buckets)
+		case "next_from":
+			p.nextFrom, err = d.DecodeUint64()
+		default:
+			err = d.Skip()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storageCallResult is a result of vshard.storage.call(): true and results
+// of the function or nil and an error.
+type storageCallResult struct {
+	result interface{}
+	err    error
+}
+
+// DecodeMsgpack provides custom msgpack decoder.
+func (r *storageCallResult) DecodeMsgpack(d *msgpack.Decoder) error {
+	l, err := d.DecodeArrayLen()
+	if err != nil {
+		return err
+	}
+	if l < 1 {
+		return fmt.Errorf("unexpected storage call response length: %d", l)
+	}
+
+	ok, err := d.DecodeInterface()
+	if err != nil {
+		return err
+	}
+
+	if ok != true {
+		if l < 2 {
+			return errors.New("storage call failed without an error")
+		}
+		r.err, err = decodeStorageError(d)
+		if err != nil {
+			return err
+		}
+		for i := 2; i < l; i++ {
+			if err := d.Skip(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Results of the function are decoded as an array.
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	if err := enc.EncodeArrayLen(l - 1); err != nil {
+		return err
+	}
+	for i := 1; i < l; i++ {
+		raw, err := d.DecodeRaw()
+		if err != nil {
+			return err
+		}
+		buf.Write(raw)
+	}
+	return msgpack.NewDecoder(&buf).Decode(r.result)
+}
+
+func decodeStorageError(d *msgpack.Decoder) (error, error) {
+	code, err := d.PeekCode()
+	if err != nil {
+		return nil, err
+	}
+	if isMap(code) {
+		vshardErr := &Error{}
+		if err := d.Decode(vshardErr); err != nil {
+			return nil, err
+		}
+		return vshardErr, nil
+	}
+
+	value, err := d.DecodeInterface()
+	if err != nil {
+		return nil, err
+	}
+	if valueErr, ok := value.(error); ok {
+		return valueErr, nil
+	}
+	return fmt.Errorf("%v", value), nil
+}
+
+func isArray(code byte) bool {
+	return code == msgpcode.Array16 || code == msgpcode.Array32 ||
+		msgpcode.IsFixedArray(code)
+}
+
+func isMap(code byte) bool {
+	return code == msgpcode.Map16 || code == msgpcode.Map32 ||
+		msgpcode.IsFixedMap(code)
+}
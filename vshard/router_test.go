@@ -0,0 +1,382 @@
+package vshard_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tarantool/go-tarantool/v2"
+	"github.com/tarantool/go-tarantool/v2/pool"
+	"github.com/tarantool/go-tarantool/v2/test_helpers/fakeserver"
+	"github.com/tarantool/go-tarantool/v2/vshard"
+)
+
+const testBucketCount = 10
+
+func TestBucketIdStrCRC32(t *testing.T) {
+	// The expected value is calculated by vshard with bucket_count = 300.
+	keys := []interface{}{
+		2010,
+		uint64(2010),
+		float64(2010),
+		"2010",
+		[]byte("2010"),
+		[]interface{}{2010},
+		[]interface{}{"20", 10},
+		[]string{"2", "0", "10"},
+	}
+	for _, key := range keys {
+		t.Run(fmt.Sprintf("%T", key), func(t *testing.T) {
+			bucketId, err := vshard.BucketIdStrCRC32(key, 300)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(45), bucketId)
+		})
+	}
+}
+
+func TestBucketIdStrCRC32_range(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		bucketId, err := vshard.BucketIdStrCRC32(i, testBucketCount)
+		require.NoError(t, err)
+		assert.True(t, bucketId >= 1 && bucketId <= testBucketCount)
+	}
+}
+
+func TestBucketIdStrCRC32_error(t *testing.T) {
+	_, err := vshard.BucketIdStrCRC32(1, 0)
+	assert.Error(t, err)
+
+	for _, key := range []interface{}{nil, []interface{}{1, nil}, struct{}{}} {
+		_, err := vshard.BucketIdStrCRC32(key, testBucketCount)
+		assert.Error(t, err)
+	}
+}
+
+// fakeCluster emulates vshard storages of replicasets with a single instance.
+type fakeCluster struct {
+	mutex sync.Mutex
+	// owners contains replicasets by bucket identifiers.
+	owners map[uint64]string
+	// locked contains a number of BUCKET_IS_LOCKED errors by buckets.
+	locked map[uint64]int
+	// calls contains a number of storage calls by replicasets.
+	calls   map[string]int
+	servers map[string]*fakeserver.Server
+}
+
+func startFakeCluster(t *testing.T) *fakeCluster {
+	t.Helper()
+
+	cluster := &fakeCluster{
+		owners:  make(map[uint64]string),
+		locked:  make(map[uint64]int),
+		calls:   make(map[string]int),
+		servers: make(map[string]*fakeserver.Server),
+	}
+	for id := uint64(1); id <= testBucketCount; id++ {
+		if id <= testBucketCount/2 {
+			cluster.owners[id] = "rs1"
+		} else {
+			cluster.owners[id] = "rs2"
+		}
+	}
+
+	for _, name := range []string{"rs1", "rs2"} {
+		srv, err := fakeserver.Start(fakeserver.Opts{})
+		require.NoError(t, err)
+		cluster.servers[name] = srv
+
+		name := name
+		// rs2 emulates an old vshard version without pagination.
+		legacy := name == "rs2"
+		srv.RegisterFunction("vshard.storage.buckets_discovery",
+			func(call fakeserver.Call) ([]interface{}, error) {
+				return cluster.discovery(name, legacy, call)
+			})
+		srv.RegisterFunction("vshard.storage.call",
+			func(call fakeserver.Call) ([]interface{}, error) {
+				return cluster.call(name, call)
+			})
+		srv.RegisterFunction("name", func(call fakeserver.Call) ([]interface{}, error) {
+			return []interface{}{name}, nil
+		})
+	}
+	return cluster
+}
+
+func (c *fakeCluster) close() {
+	for _, srv := range c.servers {
+		srv.Close()
+	}
+}
+
+func (c *fakeCluster) discovery(name string, legacy bool,
+	call fakeserver.Call) ([]interface{}, error) {
+	const pageSize = 2
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	from := uint64(1)
+	if opts, ok := call.Args[0].(map[interface{}]interface{}); ok && !legacy {
+		from = opts["from"].(uint64)
+	}
+
+	buckets := []interface{}{}
+	for id := from; id <= testBucketCount; id++ {
+		if c.owners[id] != name {
+			continue
+		}
+		if !legacy && len(buckets) == pageSize {
+			return []interface{}{map[string]interface{}{
+				"buckets":   buckets,
+				"next_from": id,
+			}}, nil
+		}
+		buckets = append(buckets, id)
+	}
+	if legacy {
+		return []interface{}{buckets}, nil
+	}
+	return []interface{}{map[string]interface{}{"buckets": buckets}}, nil
+}
+
+func (c *fakeCluster) call(name string, call fakeserver.Call) ([]interface{}, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.calls[name]++
+	bucketId := call.Args[0].(uint64)
+	if c.owners[bucketId] != name {
+		return []interface{}{nil, map[string]interface{}{
+			"type":        "ShardingError",
+			"code":        1,
+			"name":        vshard.ErrNameWrongBucket,
+			"bucket_id":   bucketId,
+			"destination": c.owners[bucketId],
+			"message":     "Cannot perform action with bucket",
+		}}, nil
+	}
+	if c.locked[bucketId] > 0 {
+		c.locked[bucketId]--
+		return []interface{}{nil, map[string]interface{}{
+			"type":      "ShardingError",
+			"code":      22,
+			"name":      vshard.ErrNameBucketIsLocked,
+			"bucket_id": bucketId,
+			"message":   "Bucket is locked",
+		}}, nil
+	}
+
+	switch call.Args[2] {
+	case "echo":
+		args := call.Args[3].([]interface{})
+		return append([]interface{}{true, name}, args...), nil
+	case "fail":
+		return []interface{}{nil, "some error"}, nil
+	}
+	return nil, fakeserver.Error{Msg: "unknown function"}
+}
+
+func (c *fakeCluster) move(bucketId uint64, to string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.owners[bucketId] = to
+}
+
+func (c *fakeCluster) lock(bucketId uint64, times int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.locked[bucketId] = times
+}
+
+func (c *fakeCluster) callsCount(name string) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.calls[name]
+}
+
+func (c *fakeCluster) connect(t *testing.T) *vshard.Router {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	router, err := vshard.Connect(ctx, vshard.RouterOpts{
+		Replicasets: map[string][]string{
+			"rs1": {c.servers["rs1"].Addr()},
+			"rs2": {c.servers["rs2"].Addr()},
+		},
+		BucketCount: testBucketCount,
+		ConnOpts:    tarantool.Opts{Timeout: 5 * time.Second},
+		RetryDelay:  time.Millisecond,
+	})
+	require.NoError(t, err)
+	return router
+}
+
+func TestConnect_error(t *testing.T) {
+	_, err := vshard.Connect(context.Background(), vshard.RouterOpts{})
+	assert.Equal(t, vshard.ErrNoReplicasets, err)
+}
+
+func TestRouter_Route(t *testing.T) {
+	cluster := startFakeCluster(t)
+	defer cluster.close()
+
+	router := cluster.connect(t)
+	defer router.Close()
+
+	for id := uint64(1); id <= testBucketCount; id++ {
+		name, connPool, err := router.Route(context.Background(), id)
+		require.NoError(t, err)
+		assert.Equal(t, cluster.owners[id], name)
+		assert.Same(t, router.Replicasets()[name], connPool)
+	}
+
+	for _, id := range []uint64{0, testBucketCount + 1} {
+		_, _, err := router.Route(context.Background(), id)
+		assert.True(t, errors.Is(err, vshard.ErrWrongBucketId))
+	}
+}
+
+func TestRouter_Do(t *testing.T) {
+	cluster := startFakeCluster(t)
+	defer cluster.close()
+
+	router := cluster.connect(t)
+	defer router.Close()
+
+	for id, expected := range map[uint64]string{1: "rs1", testBucketCount: "rs2"} {
+		var name []string
+		err := router.Do(id, tarantool.NewCallRequest("name"), pool.ANY).GetTyped(&name)
+		require.NoError(t, err)
+		assert.Equal(t, []string{expected}, name)
+	}
+
+	_, err := router.Do(0, tarantool.NewCallRequest("name"), pool.ANY).Get()
+	assert.True(t, errors.Is(err, vshard.ErrWrongBucketId))
+}
+
+func TestRouter_Call(t *testing.T) {
+	cluster := startFakeCluster(t)
+	defer cluster.close()
+
+	router := cluster.connect(t)
+	defer router.Close()
+
+	bucketId, err := router.BucketId("key")
+	require.NoError(t, err)
+
+	result, err := router.Call(context.Background(), bucketId, pool.RW, "echo",
+		[]interface{}{"value"})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{cluster.owners[bucketId], "value"}, result)
+
+	var typed struct {
+		_msgpack struct{} `msgpack:",asArray"` //nolint: structcheck,unused
+		Name     string
+		Value    uint64
+	}
+	err = router.CallTyped(context.Background(), bucketId, pool.PreferRO, "echo",
+		[]interface{}{42}, &typed)
+	require.NoError(t, err)
+	assert.Equal(t, cluster.owners[bucketId], typed.Name)
+	assert.Equal(t, uint64(42), typed.Value)
+}
+
+func TestRouter_Call_error(t *testing.T) {
+	cluster := startFakeCluster(t)
+	defer cluster.close()
+
+	router := cluster.connect(t)
+	defer router.Close()
+
+	_, err := router.Call(context.Background(), 1, pool.RW, "fail", nil)
+	assert.EqualError(t, err, "some error")
+
+	_, err = router.Call(context.Background(), 1, pool.RW, "unknown", nil)
+	assert.Error(t, err)
+}
+
+func TestRouter_Call_wrongBucket(t *testing.T) {
+	cluster := startFakeCluster(t)
+	defer cluster.close()
+
+	router := cluster.connect(t)
+	defer router.Close()
+
+	// The bucket is moved to the known replicaset.
+	cluster.move(1, "rs2")
+	result, err := router.Call(context.Background(), 1, pool.RW, "echo", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"rs2"}, result)
+	assert.Equal(t, 1, cluster.callsCount("rs1"))
+	assert.Equal(t, 1, cluster.callsCount("rs2"))
+
+	// The map is updated.
+	name, _, err := router.Route(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "rs2", name)
+
+	// The bucket is moved to an unknown replicaset and back, it is found
+	// with a discovery.
+	cluster.move(2, "")
+	cluster.move(2, "rs2")
+	result, err = router.Call(context.Background(), 2, pool.RW, "echo", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"rs2"}, result)
+}
+
+func TestRouter_Call_bucketNotFound(t *testing.T) {
+	cluster := startFakeCluster(t)
+	defer cluster.close()
+
+	router := cluster.connect(t)
+	defer router.Close()
+
+	cluster.move(1, "")
+	_, err := router.Call(context.Background(), 1, pool.RW, "echo", nil)
+	assert.True(t, errors.Is(err, vshard.ErrBucketNotFound))
+}
+
+func TestRouter_Call_bucketIsLocked(t *testing.T) {
+	cluster := startFakeCluster(t)
+	defer cluster.close()
+
+	router := cluster.connect(t)
+	defer router.Close()
+
+	cluster.lock(1, 2)
+	result, err := router.Call(context.Background(), 1, pool.RW, "echo", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"rs1"}, result)
+	assert.Equal(t, 3, cluster.callsCount("rs1"))
+
+	cluster.lock(1, vshard.DefaultRetries+1)
+	_, err = router.Call(context.Background(), 1, pool.RW, "echo", nil)
+	var vshardErr *vshard.Error
+	require.True(t, errors.As(err, &vshardErr))
+	assert.Equal(t, vshard.ErrNameBucketIsLocked, vshardErr.Name)
+	assert.Equal(t, uint64(1), vshardErr.BucketId)
+}
+
+func TestRouter_Close(t *testing.T) {
+	cluster := startFakeCluster(t)
+	defer cluster.close()
+
+	router := cluster.connect(t)
+	assert.Empty(t, router.Close())
+	assert.Empty(t, router.Close())
+
+	assert.Equal(t, vshard.ErrClosed, router.Discovery(context.Background()))
+}
@@ -2,6 +2,7 @@ package tarantool
 
 import (
 	"context"
+	"time"
 
 	"github.com/tarantool/go-iproto"
 	"github.com/vmihailenco/msgpack/v5"
@@ -35,6 +36,12 @@ func (req *BroadcastRequest) Context(ctx context.Context) *BroadcastRequest {
 	return req
 }
 
+// ResponseTimeout sets a timeout for a response to the broadcast request.
+func (req *BroadcastRequest) ResponseTimeout(timeout time.Duration) *BroadcastRequest {
+	req.call = req.call.ResponseTimeout(timeout)
+	return req
+}
+
 // Code returns IPROTO code for the broadcast request.
 func (req *BroadcastRequest) Type() iproto.Type {
 	return req.call.Type()
@@ -50,6 +57,10 @@ func (req *BroadcastRequest) Ctx() context.Context {
 	return req.call.Ctx()
 }
 
+func (req *BroadcastRequest) responseTimeout() time.Duration {
+	return req.call.responseTimeout()
+}
+
 // Async returns is the broadcast request expects a response.
 func (req *BroadcastRequest) Async() bool {
 	return req.call.Async()
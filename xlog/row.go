@@ -0,0 +1,204 @@
+package xlog
+
+import (
+	"bytes"
+	"math"
+	"time"
+
+	"github.com/tarantool/go-iproto"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Header is a header of a row.
+type Header struct {
+	// Type is a type of the request.
+	Type iproto.Type
+	// Sync is a request identifier of a client.
+	Sync uint64
+	// ReplicaId is an identifier of the replica that has made the row.
+	ReplicaId uint32
+	// GroupId is a replication group of the row.
+	GroupId uint32
+	// Lsn is a log sequence number of the row.
+	Lsn int64
+	// Tsn is a transaction identifier: LSN of the first row of
+	// the transaction.
+	Tsn int64
+	// Timestamp is a time of the row creation. It is zero for snapshot rows.
+	Timestamp time.Time
+	// Flags contains iproto.IPROTO_FLAG_* flags of the row. The
+	// IPROTO_FLAG_COMMIT flag is set for the last row of a transaction.
+	Flags iproto.Flag
+	// StreamId is an identifier of a stream of the request.
+	StreamId uint64
+}
+
+// DMLBody is a body of INSERT, REPLACE, UPDATE, UPSERT and DELETE requests.
+type DMLBody struct {
+	// SpaceId is an identifier of the space.
+	SpaceId uint32
+	// IndexId is an identifier of the index for UPDATE and DELETE requests.
+	IndexId uint32
+	// IndexBase is a base of field numbers in update operations.
+	IndexBase uint32
+	// Key is a key for UPDATE and DELETE requests.
+	Key []interface{}
+	// Tuple is a tuple for INSERT, REPLACE and UPSERT requests.
+	Tuple []interface{}
+	// Ops contains update operations for UPDATE and UPSERT requests.
+	Ops []interface{}
+}
+
+// Row is a row of an xlog or a snapshot file.
+type Row struct {
+	// Header is a header of the row.
+	Header Header
+	// Body is a body of the row. It is a *DMLBody for DML requests, nil
+	// for NOP requests and map[iproto.Key]interface{} for other requests.
+	// Integers are decoded as int64 or uint64 and maps are decoded as
+	// map[interface{}]interface{}.
+	Body interface{}
+}
+
+// decodeRow decodes a row from a tx block.
+func decodeRow(d *msgpack.Decoder, block *bytes.Reader) (Row, error) {
+	var row Row
+	if err := decodeHeader(d, &row.Header); err != nil {
+		return row, err
+	}
+
+	// A row of a NOP request has no body.
+	if block.Len() == 0 || row.Header.Type == iproto.IPROTO_NOP {
+		return row, nil
+	}
+
+	var err error
+	switch row.Header.Type {
+	case iproto.IPROTO_INSERT, iproto.IPROTO_REPLACE, iproto.IPROTO_UPDATE,
+		iproto.IPROTO_UPSERT, iproto.IPROTO_DELETE:
+		row.Body, err = decodeDMLBody(d, row.Header.Type)
+	default:
+		row.Body, err = decodeRawBody(d)
+	}
+	return row, err
+}
+
+func decodeHeader(d *msgpack.Decoder, header *Header) error {
+	l, err := d.DecodeMapLen()
+	if err != nil {
+		return err
+	}
+
+	hasTsn := false
+	for ; l > 0; l-- {
+		key, err := d.DecodeInt()
+		if err != nil {
+			return err
+		}
+
+		switch iproto.Key(key) {
+		case iproto.IPROTO_REQUEST_TYPE:
+			var typ int
+			typ, err = d.DecodeInt()
+			header.Type = iproto.Type(typ)
+		case iproto.IPROTO_SYNC:
+			header.Sync, err = d.DecodeUint64()
+		case iproto.IPROTO_REPLICA_ID:
+			header.ReplicaId, err = d.DecodeUint32()
+		case iproto.IPROTO_GROUP_ID:
+			header.GroupId, err = d.DecodeUint32()
+		case iproto.IPROTO_LSN:
+			header.Lsn, err = d.DecodeInt64()
+		case iproto.IPROTO_TSN:
+			// The transaction identifier is encoded as a difference between
+			// LSN and TSN.
+			hasTsn = true
+			header.Tsn, err = d.DecodeInt64()
+		case iproto.IPROTO_TIMESTAMP:
+			var timestamp float64
+			timestamp, err = d.DecodeFloat64()
+			sec, frac := math.Modf(timestamp)
+			header.Timestamp = time.Unix(int64(sec), int64(frac*1e9))
+		case iproto.IPROTO_FLAGS:
+			var flags int
+			flags, err = d.DecodeInt()
+			header.Flags = iproto.Flag(flags)
+		case iproto.IPROTO_STREAM_ID:
+			header.StreamId, err = d.DecodeUint64()
+		default:
+			err = d.Skip()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if hasTsn {
+		header.Tsn = header.Lsn - header.Tsn
+	} else {
+		// A single statement transaction.
+		header.Tsn = header.Lsn
+		header.Flags |= iproto.IPROTO_FLAG_COMMIT
+	}
+	return nil
+}
+
+func decodeDMLBody(d *msgpack.Decoder, typ iproto.Type) (*DMLBody, error) {
+	l, err := d.DecodeMapLen()
+	if err != nil {
+		return nil, err
+	}
+
+	body := &DMLBody{}
+	for ; l > 0; l-- {
+		key, err := d.DecodeInt()
+		if err != nil {
+			return nil, err
+		}
+
+		switch iproto.Key(key) {
+		case iproto.IPROTO_SPACE_ID:
+			body.SpaceId, err = d.DecodeUint32()
+		case iproto.IPROTO_INDEX_ID:
+			body.IndexId, err = d.DecodeUint32()
+		case iproto.IPROTO_INDEX_BASE:
+			body.IndexBase, err = d.DecodeUint32()
+		case iproto.IPROTO_KEY:
+			body.Key, err = d.DecodeSlice()
+		case iproto.IPROTO_TUPLE:
+			// UPDATE requests keep operations in the tuple field.
+			if typ == iproto.IPROTO_UPDATE {
+				body.Ops, err = d.DecodeSlice()
+			} else {
+				body.Tuple, err = d.DecodeSlice()
+			}
+		case iproto.IPROTO_OPS:
+			body.Ops, err = d.DecodeSlice()
+		default:
+			err = d.Skip()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
+
+func decodeRawBody(d *msgpack.Decoder) (map[iproto.Key]interface{}, error) {
+	l, err := d.DecodeMapLen()
+	if err != nil {
+		return nil, err
+	}
+
+	body := make(map[iproto.Key]interface{}, l)
+	for ; l > 0; l-- {
+		key, err := d.DecodeInt()
+		if err != nil {
+			return nil, err
+		}
+		if body[iproto.Key(key)], err = d.DecodeInterfaceLoose(); err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
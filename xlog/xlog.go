@@ -0,0 +1,329 @@
+// Package xlog provides an offline reader of Tarantool write-ahead log
+// (.xlog) and snapshot (.snap) files.
+//
+// The reader parses the file header, checks checksums of tx blocks,
+// decompresses zstd compressed tx blocks and decodes rows with IPROTO
+// requests. MessagePack extensions of datetime, decimal, uuid and error
+// types are decoded into types of the corresponding packages.
+//
+//	file, err := os.Open("00000000000000000000.xlog")
+//	if err != nil {
+//		return err
+//	}
+//	defer file.Close()
+//
+//	reader, err := xlog.NewReader(file)
+//	if err != nil {
+//		return err
+//	}
+//	for reader.Next() {
+//		row := reader.Row()
+//		fmt.Println(row.Header.Lsn, row.Header.Type)
+//	}
+//	if err := reader.Err(); err != nil {
+//		return err
+//	}
+//
+// Since: 2.0.0
+package xlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	_ "github.com/tarantool/go-tarantool/v2"
+	_ "github.com/tarantool/go-tarantool/v2/datetime"
+	_ "github.com/tarantool/go-tarantool/v2/decimal"
+	_ "github.com/tarantool/go-tarantool/v2/uuid"
+)
+
+const (
+	// rowMarker starts a tx block.
+	rowMarker = 0xd5ba0bab
+	// zrowMarker starts a zstd compressed tx block.
+	zrowMarker = 0xd5ba0bba
+	// eofMarker ends a file.
+	eofMarker = 0xd510aded
+	// fixHeaderSize is a size of a tx block header: a marker, a length of
+	// the block, checksums and a padding.
+	fixHeaderSize = 19
+	// maxBlockSize limits a memory allocation for a corrupted block length.
+	maxBlockSize = 1 << 30
+)
+
+var (
+	// ErrChecksum is returned if a checksum of a tx block does not match
+	// its data.
+	ErrChecksum = errors.New("xlog: checksum mismatch")
+	// ErrCorrupted is returned if a file does not match the xlog format.
+	ErrCorrupted = errors.New("xlog: corrupted file")
+)
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Meta is a header of an xlog or a snapshot file.
+type Meta struct {
+	// Filetype is a type of the file, for example, "XLOG" or "SNAP".
+	Filetype string
+	// Version is a version of the file format, for example, "0.13".
+	Version string
+	// ServerVersion is a version of Tarantool that has created the file.
+	ServerVersion string
+	// InstanceUUID is an UUID of the instance that has created the file.
+	InstanceUUID string
+	// VClock is a vector clock at the beginning of the file: LSNs by
+	// replica identifiers.
+	VClock map[uint32]int64
+	// PrevVClock is a vector clock at the beginning of the previous xlog
+	// file. It is nil if it is not set.
+	PrevVClock map[uint32]int64
+}
+
+// Reader reads rows of an xlog or a snapshot file. It reads the file
+// sequentially, so it could be used with a file that is still written by
+// Tarantool: Next() returns false without an error at the end of the
+// written data.
+//
+// Reader is not safe for concurrent use.
+type Reader struct {
+	r      *bufio.Reader
+	meta   Meta
+	offset int64
+	block  bytes.Reader
+	dec    *msgpack.Decoder
+	row    Row
+	err    error
+	done   bool
+}
+
+// NewReader creates a new reader of the file and reads the file header.
+func NewReader(r io.Reader) (*Reader, error) {
+	reader := &Reader{
+		r: bufio.NewReader(r),
+	}
+	reader.dec = msgpack.NewDecoder(&reader.block)
+	reader.dec.UseLooseInterfaceDecoding(true)
+	reader.dec.SetMapDecoder(func(dec *msgpack.Decoder) (interface{}, error) {
+		return dec.DecodeUntypedMap()
+	})
+
+	if err := reader.readMeta(); err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
+// Meta returns the file header.
+func (r *Reader) Meta() Meta {
+	return r.meta
+}
+
+// Next reads a next row. It returns false at the end of the file or on
+// an error, see Err().
+func (r *Reader) Next() bool {
+	if r.err != nil || r.done {
+		return false
+	}
+
+	for r.block.Len() == 0 {
+		ok, err := r.readBlock()
+		if err != nil {
+			r.err = err
+			return false
+		}
+		if !ok {
+			r.done = true
+			return false
+		}
+	}
+
+	row, err := decodeRow(r.dec, &r.block)
+	if err != nil {
+		r.err = fmt.Errorf("xlog: failed to decode a row of a tx block before "+
+			"offset %d: %w", r.offset, err)
+		return false
+	}
+	r.row = row
+	return true
+}
+
+// Row returns a row read by the last Next() call.
+func (r *Reader) Row() Row {
+	return r.row
+}
+
+// Err returns an error that has stopped the reading.
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// readMeta reads the file header: a type of the file, a version of
+// the format and key-value lines up to an empty line.
+func (r *Reader) readMeta() error {
+	filetype, err := r.readLine()
+	if err != nil {
+		return err
+	}
+	version, err := r.readLine()
+	if err != nil {
+		return err
+	}
+	if filetype == "" || !strings.HasPrefix(version, "0.") {
+		return fmt.Errorf("%w: unexpected file header %q, %q",
+			ErrCorrupted, filetype, version)
+	}
+	r.meta.Filetype = filetype
+	r.meta.Version = version
+
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			return err
+		}
+		if line == "" {
+			return nil
+		}
+
+		pos := strings.Index(line, ":")
+		if pos < 0 {
+			return fmt.Errorf("%w: invalid file header line %q", ErrCorrupted, line)
+		}
+		key, value := line[:pos], strings.TrimSpace(line[pos+1:])
+		switch key {
+		case "Version":
+			r.meta.ServerVersion = value
+		case "Instance", "Server":
+			r.meta.InstanceUUID = value
+		case "VClock":
+			if r.meta.VClock, err = parseVClock(value); err != nil {
+				return err
+			}
+		case "PrevVClock":
+			if r.meta.PrevVClock, err = parseVClock(value); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (r *Reader) readLine() (string, error) {
+	line, err := r.r.ReadString('\n')
+	r.offset += int64(len(line))
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return "", fmt.Errorf("xlog: failed to read the file header: %w", err)
+	}
+	return strings.TrimSuffix(line, "\n"), nil
+}
+
+// parseVClock parses a vector clock in the format: {1: 10, 2: 20}.
+func parseVClock(str string) (map[uint32]int64, error) {
+	if !strings.HasPrefix(str, "{") || !strings.HasSuffix(str, "}") {
+		return nil, fmt.Errorf("%w: invalid vclock %q", ErrCorrupted, str)
+	}
+
+	vclock := make(map[uint32]int64)
+	body := strings.TrimSpace(str[1 : len(str)-1])
+	if body == "" {
+		return vclock, nil
+	}
+	for _, component := range strings.Split(body, ",") {
+		parts := strings.Split(component, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%w: invalid vclock %q", ErrCorrupted, str)
+		}
+		id, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid vclock %q", ErrCorrupted, str)
+		}
+		lsn, err := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid vclock %q", ErrCorrupted, str)
+		}
+		vclock[uint32(id)] = lsn
+	}
+	return vclock, nil
+}
+
+// readBlock reads a next tx block. It returns false at the end of the file.
+func (r *Reader) readBlock() (bool, error) {
+	var fixHeader [fixHeaderSize]byte
+	n, err := io.ReadFull(r.r, fixHeader[:4])
+	if err == io.EOF {
+		// The file is not finished yet.
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("xlog: failed to read a tx block at offset %d: %w",
+			r.offset, err)
+	}
+
+	marker := binary.BigEndian.Uint32(fixHeader[:])
+	switch marker {
+	case eofMarker:
+		return false, nil
+	case rowMarker, zrowMarker:
+	default:
+		return false, fmt.Errorf("%w: unexpected marker %#x at offset %d",
+			ErrCorrupted, marker, r.offset)
+	}
+
+	if _, err := io.ReadFull(r.r, fixHeader[n:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return false, fmt.Errorf("xlog: failed to read a tx block at offset %d: %w",
+			r.offset, err)
+	}
+
+	// The length and the checksums are encoded as MessagePack numbers and
+	// followed by a padding.
+	dec := msgpack.NewDecoder(bytes.NewReader(fixHeader[4:]))
+	length, err := dec.DecodeUint32()
+	if err == nil {
+		// A checksum of the previous block is not used.
+		_, err = dec.DecodeUint32()
+	}
+	var checksum uint32
+	if err == nil {
+		checksum, err = dec.DecodeUint32()
+	}
+	if err != nil || length > maxBlockSize {
+		return false, fmt.Errorf("%w: invalid tx block header at offset %d",
+			ErrCorrupted, r.offset)
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return false, fmt.Errorf("xlog: failed to read a tx block at offset %d: %w",
+			r.offset, err)
+	}
+	// Tarantool calculates CRC32-C without the initial and the final xor.
+	if ^crc32.Update(^uint32(0), castagnoliTable, data) != checksum {
+		return false, fmt.Errorf("%w: tx block at offset %d", ErrChecksum, r.offset)
+	}
+
+	if marker == zrowMarker {
+		if data, err = zstdDecompress(data); err != nil {
+			return false, fmt.Errorf("xlog: failed to decompress a tx block at "+
+				"offset %d: %w", r.offset, err)
+		}
+	}
+
+	r.offset += fixHeaderSize + int64(length)
+	r.block.Reset(data)
+	return true, nil
+}
@@ -0,0 +1,263 @@
+package xlog_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tarantool/go-iproto"
+
+	"github.com/tarantool/go-tarantool/v2"
+	"github.com/tarantool/go-tarantool/v2/datetime"
+	"github.com/tarantool/go-tarantool/v2/decimal"
+	"github.com/tarantool/go-tarantool/v2/xlog"
+)
+
+// Files in testdata are synthetic. The xlog file contains a plain tx block
+// with a single statement transaction, a zstd compressed tx block with
+// a multi-statement transaction and a plain tx block with DELETE, NOP and
+// CONFIRM rows. The snapshot contains a zstd compressed tx block. The
+// compressed xlog file contains a zstd compressed tx block with 500 rows.
+const (
+	xlogFile           = "00000000000000000002.xlog"
+	snapFile           = "00000000000000000008.snap"
+	compressedXlogFile = "00000000000000000100.xlog"
+)
+
+func readFile(t *testing.T, name string) []byte {
+	t.Helper()
+
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	return data
+}
+
+func readRows(t *testing.T, reader *xlog.Reader) []xlog.Row {
+	t.Helper()
+
+	rows := []xlog.Row{}
+	for reader.Next() {
+		rows = append(rows, reader.Row())
+	}
+	require.NoError(t, reader.Err())
+	return rows
+}
+
+func TestReader_xlog(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", xlogFile))
+	require.NoError(t, err)
+	defer file.Close()
+
+	reader, err := xlog.NewReader(file)
+	require.NoError(t, err)
+
+	assert.Equal(t, xlog.Meta{
+		Filetype:      "XLOG",
+		Version:       "0.13",
+		ServerVersion: "2.11.1-0-g96877bd",
+		InstanceUUID:  "0d5bd431-7f3e-4695-a5c2-82de0a9cbc95",
+		VClock:        map[uint32]int64{1: 2},
+		PrevVClock:    map[uint32]int64{},
+	}, reader.Meta())
+
+	rows := readRows(t, reader)
+	require.Len(t, rows, 7)
+
+	timestamp := time.Unix(1700000000, 500000000)
+	header := func(typ iproto.Type, lsn, tsn int64, flags iproto.Flag) xlog.Header {
+		return xlog.Header{
+			Type:      typ,
+			ReplicaId: 1,
+			Lsn:       lsn,
+			Tsn:       tsn,
+			Timestamp: timestamp,
+			Flags:     flags,
+		}
+	}
+
+	dt, err := datetime.MakeDatetime(time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC))
+	require.NoError(t, err)
+	dec, err := decimal.MakeDecimalFromString("12.34")
+	require.NoError(t, err)
+
+	assert.Equal(t, header(iproto.IPROTO_INSERT, 3, 3, iproto.IPROTO_FLAG_COMMIT),
+		rows[0].Header)
+	require.IsType(t, &xlog.DMLBody{}, rows[0].Body)
+	body := rows[0].Body.(*xlog.DMLBody)
+	assert.Equal(t, uint32(512), body.SpaceId)
+	require.Len(t, body.Tuple, 5)
+	assert.Equal(t, []interface{}{int64(1), "first"}, body.Tuple[:2])
+	require.IsType(t, datetime.Datetime{}, body.Tuple[2])
+	actualDt := body.Tuple[2].(datetime.Datetime)
+	assert.True(t, dt.ToTime().Equal(actualDt.ToTime()))
+	require.IsType(t, decimal.Decimal{}, body.Tuple[3])
+	assert.True(t, dec.Equal(body.Tuple[3].(decimal.Decimal).Decimal))
+	assert.Equal(t, uuid.MustParse("c8f0fa1f-da29-438c-a040-393f1126ad39"), body.Tuple[4])
+
+	assert.Equal(t, header(iproto.IPROTO_REPLACE, 4, 4, 0), rows[1].Header)
+	assert.Equal(t, &xlog.DMLBody{
+		SpaceId: 512,
+		Tuple: []interface{}{int64(2), "second",
+			map[interface{}]interface{}{"a": int64(1)}},
+	}, rows[1].Body)
+
+	assert.Equal(t, header(iproto.IPROTO_UPDATE, 5, 4, 0), rows[2].Header)
+	assert.Equal(t, &xlog.DMLBody{
+		SpaceId:   512,
+		IndexBase: 1,
+		Key:       []interface{}{int64(1)},
+		Ops:       []interface{}{[]interface{}{"=", int64(2), "updated"}},
+	}, rows[2].Body)
+
+	assert.Equal(t, header(iproto.IPROTO_UPSERT, 6, 4, iproto.IPROTO_FLAG_COMMIT),
+		rows[3].Header)
+	body = rows[3].Body.(*xlog.DMLBody)
+	assert.Equal(t, []interface{}{[]interface{}{"+", int64(1), int64(1)}}, body.Ops)
+	require.Len(t, body.Tuple, 3)
+	require.IsType(t, &tarantool.BoxError{}, body.Tuple[2])
+	assert.Equal(t, "Unknown error", body.Tuple[2].(*tarantool.BoxError).Msg)
+
+	assert.Equal(t, header(iproto.IPROTO_DELETE, 7, 7, iproto.IPROTO_FLAG_COMMIT),
+		rows[4].Header)
+	assert.Equal(t, &xlog.DMLBody{
+		SpaceId: 512,
+		Key:     []interface{}{int64(2)},
+	}, rows[4].Body)
+
+	assert.Equal(t, header(iproto.IPROTO_NOP, 8, 8, iproto.IPROTO_FLAG_COMMIT),
+		rows[5].Header)
+	assert.Nil(t, rows[5].Body)
+
+	confirm := header(iproto.IPROTO_RAFT_CONFIRM, 0, 0, iproto.IPROTO_FLAG_COMMIT)
+	confirm.GroupId = 1
+	assert.Equal(t, confirm, rows[6].Header)
+	assert.Equal(t, map[iproto.Key]interface{}{
+		iproto.IPROTO_REPLICA_ID: int64(1),
+		iproto.IPROTO_LSN:        int64(7),
+	}, rows[6].Body)
+}
+
+func TestReader_snap(t *testing.T) {
+	reader, err := xlog.NewReader(bytes.NewReader(readFile(t, snapFile)))
+	require.NoError(t, err)
+
+	assert.Equal(t, "SNAP", reader.Meta().Filetype)
+	assert.Equal(t, map[uint32]int64{1: 8}, reader.Meta().VClock)
+	assert.Nil(t, reader.Meta().PrevVClock)
+
+	rows := readRows(t, reader)
+	require.Len(t, rows, 3)
+	for i, row := range rows {
+		lsn := int64(i + 1)
+		assert.Equal(t, xlog.Header{
+			Type:  iproto.IPROTO_INSERT,
+			Lsn:   lsn,
+			Tsn:   lsn,
+			Flags: iproto.IPROTO_FLAG_COMMIT,
+		}, row.Header)
+		assert.Equal(t, &xlog.DMLBody{
+			SpaceId: 512,
+			Tuple:   []interface{}{lsn, "snapshot"},
+		}, row.Body)
+	}
+}
+
+func TestReader_compressed(t *testing.T) {
+	reader, err := xlog.NewReader(bytes.NewReader(readFile(t, compressedXlogFile)))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[uint32]int64{1: 100}, reader.Meta().VClock)
+	assert.Equal(t, map[uint32]int64{1: 2}, reader.Meta().PrevVClock)
+
+	rows := readRows(t, reader)
+	require.Len(t, rows, 500)
+	for i, row := range rows {
+		lsn := int64(101 + i)
+		assert.Equal(t, xlog.Header{
+			Type:      iproto.IPROTO_INSERT,
+			ReplicaId: 1,
+			Lsn:       lsn,
+			Tsn:       lsn,
+			Flags:     iproto.IPROTO_FLAG_COMMIT,
+		}, row.Header)
+		require.IsType(t, &xlog.DMLBody{}, row.Body)
+		body := row.Body.(*xlog.DMLBody)
+		assert.Equal(t, uint32(512), body.SpaceId)
+		require.Len(t, body.Tuple, 2)
+		// Positive integers are encoded as unsigned.
+		assert.EqualValues(t, lsn, body.Tuple[0])
+		assert.Equal(t, fmt.Sprintf("compressed row %d", lsn), body.Tuple[1])
+	}
+}
+
+func TestReader_unfinished(t *testing.T) {
+	data := readFile(t, xlogFile)
+	// Remove the EOF marker.
+	reader, err := xlog.NewReader(bytes.NewReader(data[:len(data)-4]))
+	require.NoError(t, err)
+
+	assert.Len(t, readRows(t, reader), 7)
+}
+
+func TestNewReader_error(t *testing.T) {
+	cases := []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"no version", "XLOG\n"},
+		{"invalid version", "XLOG\n1.0\n\n"},
+		{"invalid line", "XLOG\n0.13\nVClock\n\n"},
+		{"invalid vclock", "XLOG\n0.13\nVClock: {1: a}\n\n"},
+		{"no end", "XLOG\n0.13\nVClock: {}\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader, err := xlog.NewReader(bytes.NewReader([]byte(tc.data)))
+			assert.Error(t, err)
+			assert.Nil(t, reader)
+		})
+	}
+}
+
+func TestReader_error(t *testing.T) {
+	data := readFile(t, xlogFile)
+	blockOffset := bytes.Index(data, []byte{0xd5, 0xba, 0x0b, 0xab})
+	require.True(t, blockOffset > 0)
+
+	corrupt := func(offset int, b byte) []byte {
+		corrupted := append([]byte{}, data...)
+		corrupted[offset] = b
+		return corrupted
+	}
+
+	cases := []struct {
+		name     string
+		data     []byte
+		expected error
+	}{
+		{"marker", corrupt(blockOffset, 0), xlog.ErrCorrupted},
+		{"checksum", corrupt(len(data)-10, data[len(data)-10]+1), xlog.ErrChecksum},
+		{"truncated", data[:len(data)-10], io.ErrUnexpectedEOF},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader, err := xlog.NewReader(bytes.NewReader(tc.data))
+			require.NoError(t, err)
+
+			for reader.Next() {
+			}
+			assert.True(t, errors.Is(reader.Err(), tc.expected), reader.Err())
+			assert.False(t, reader.Next())
+		})
+	}
+}
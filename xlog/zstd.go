@@ -0,0 +1,40 @@
+package xlog
+
+import (
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// zstdMaxDecodedSize limits a size of a decompressed tx block in the
+	// same way as a size of a plain one.
+	zstdMaxDecodedSize = maxBlockSize
+	// zstdMaxWindowSize limits a memory usage for a corrupted frame header.
+	// It is the default limit of the zstd utility, Tarantool compresses tx
+	// blocks with much smaller windows.
+	zstdMaxWindowSize = 1 << 27
+)
+
+var (
+	zstdDecoderOnce sync.Once
+	zstdDecoder     *zstd.Decoder
+	zstdDecoderErr  error
+)
+
+// zstdDecompress decompresses a zstd compressed tx block. Dictionaries are
+// not supported because Tarantool does not use them.
+func zstdDecompress(src []byte) ([]byte, error) {
+	zstdDecoderOnce.Do(func() {
+		// The decoder is created on demand since it preallocates buffers.
+		// It is safe to use DecodeAll() concurrently.
+		zstdDecoder, zstdDecoderErr = zstd.NewReader(nil,
+			zstd.WithDecoderConcurrency(1),
+			zstd.WithDecoderMaxMemory(zstdMaxDecodedSize),
+			zstd.WithDecoderMaxWindow(zstdMaxWindowSize))
+	})
+	if zstdDecoderErr != nil {
+		return nil, zstdDecoderErr
+	}
+	return zstdDecoder.DecodeAll(src, nil)
+}
@@ -0,0 +1,60 @@
+package xlog
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeHex(t *testing.T, str string) []byte {
+	t.Helper()
+
+	data, err := hex.DecodeString(str)
+	require.NoError(t, err)
+	return data
+}
+
+func TestZstdDecompress_small(t *testing.T) {
+	cases := []struct {
+		name     string
+		src      string
+		expected []byte
+	}{
+		{"empty", "", nil},
+		{"raw", "28b52ffd2401090000615b6e8ca9", []byte("a")},
+		{"rle", "28b52ffda420a107005400001078780100fbff39c002020010780200107803" +
+			"090d78310490fe", bytes.Repeat([]byte("x"), 500000)},
+		{"frames", "28b52ffd2401090000615b6e8ca9" + "502a4d1803000000aabbcc" +
+			"28b52ffd006809000062", []byte("ab")},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := zstdDecompress(decodeHex(t, tc.src))
+			require.NoError(t, err)
+			assert.True(t, bytes.Equal(tc.expected, data), "data mismatch")
+		})
+	}
+}
+
+func TestZstdDecompress_error(t *testing.T) {
+	cases := []struct {
+		name string
+		src  []byte
+	}{
+		{"magic", decodeHex(t, "28b52ffe2401090000615b6e8ca9")},
+		{"dictionary", decodeHex(t, "28b52ffd210501090000615b6e8ca9")},
+		{"reserved block", decodeHex(t, "28b52ffd2401070000615b6e8ca9")},
+		{"truncated", decodeHex(t, "28b52ffd24010900")},
+		{"truncated skippable", decodeHex(t, "502a4d1803000000aabb")},
+		{"window size", decodeHex(t, "28b52ffd00f801000061")},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := zstdDecompress(tc.src)
+			assert.Error(t, err)
+		})
+	}
+}